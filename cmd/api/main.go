@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"whattowatch/internal/api/tmdb"
+	"whattowatch/internal/catalog"
+	"whattowatch/internal/config"
+	"whattowatch/internal/metrics"
+	"whattowatch/internal/restapi"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/tracing"
+	"whattowatch/internal/utils"
+	"whattowatch/pkg/logger"
+)
+
+func main() {
+	cfg, err := config.MustLoad()
+	if err != nil {
+		ir, _ := os.Getwd()
+		slog.Error("failed to load config", "error", err.Error(), "current dir", ir)
+		panic("failed to load config: " + err.Error())
+	}
+
+	log, file := logger.SetupLogger(cfg.Env, cfg.LogDir+"/api")
+	defer file.Close()
+	log = slog.New(tracing.NewLogHandler(log.Handler()))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, "api")
+	if err != nil {
+		log.Error("tracing setup error", "error", err.Error())
+		panic("tracing setup error: " + err.Error())
+	}
+	defer shutdownTracing(context.Background())
+
+	log.Info("Current IP: " + utils.GetMyIP())
+
+	postgresDB, err := postgresql.New(cfg, log)
+	if err != nil {
+		log.Error("storage create error", "error", err.Error())
+		panic("storage create error: " + err.Error())
+	}
+
+	api, err := tmdb.New(cfg, log)
+	if err != nil {
+		log.Error("API create error", "error", err.Error())
+		panic("API create error: " + err.Error())
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	metrics.RegisterPool(postgresDB.Stat)
+	metrics.RegisterGenreCache(api)
+
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.Metrics.Addr, log); err != nil {
+				log.Error("metrics server error", "error", err.Error())
+			}
+		}()
+	}
+
+	provider := catalog.NewDataProvider(log, metrics.NewDataProvider(tracing.NewDataProvider(api)), postgresDB, cfg.Catalog)
+	if err := restapi.New(cfg, log, metrics.NewStorer(postgresDB), provider).Start(ctx); err != nil {
+		log.Error("rest api server error", "error", err.Error())
+		panic("rest api server error: " + err.Error())
+	}
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"os/signal"
 	"whattowatch/internal/api/tmdb"
 	"whattowatch/internal/botkit"
+	"whattowatch/internal/catalog"
 	"whattowatch/internal/config"
+	"whattowatch/internal/health"
+	"whattowatch/internal/metrics"
 	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/tracing"
 	"whattowatch/internal/utils"
+	"whattowatch/internal/webapp"
 	"whattowatch/pkg/logger"
 )
 
@@ -21,6 +28,14 @@ func main() {
 
 	log, file := logger.SetupLogger(cfg.Env, cfg.LogDir+"/bot")
 	defer file.Close()
+	log = slog.New(tracing.NewLogHandler(log.Handler()))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, "bot")
+	if err != nil {
+		log.Error("tracing setup error", "error", err.Error())
+		panic("tracing setup error: " + err.Error())
+	}
+	defer shutdownTracing(context.Background())
 
 	log.Info("Current IP: " + utils.GetMyIP())
 
@@ -36,10 +51,51 @@ func main() {
 		panic("API create error: " + err.Error())
 	}
 
-	bot, err := botkit.New(cfg, log, postgresDB, api)
+	storer := metrics.NewStorer(postgresDB)
+	tmdbProvider := metrics.NewDataProvider(tracing.NewDataProvider(api))
+	provider := catalog.NewDataProvider(log, tmdbProvider, postgresDB, cfg.Catalog)
+	metrics.RegisterPool(postgresDB.Stat)
+	metrics.RegisterGenreCache(api)
+
+	bot, err := botkit.New(cfg, log, storer, provider, tracing.BotMiddleware, metrics.BotMiddleware)
 	if err != nil {
 		log.Error("TGBot create error", "error", err.Error())
 		panic("TGBot create error: " + err.Error())
 	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	go catalog.NewEnricher(log, tmdbProvider, postgresDB, cfg.Catalog).Run(ctx)
+
+	if cfg.WebApp.Addr != "" {
+		go func() {
+			if err := webapp.New(cfg, log, storer, provider).Start(ctx); err != nil {
+				log.Error("web app server error", "error", err.Error())
+			}
+		}()
+	}
+
+	if cfg.Health.Addr != "" {
+		checker := health.New(log).
+			Add("postgres", health.Ping(postgresDB.Ping)).
+			Add("tmdb_genres", api.CheckGenres).
+			Add("telegram", health.Ping(bot.Ping))
+
+		go func() {
+			if err := checker.Serve(ctx, cfg.Health.Addr); err != nil {
+				log.Error("health server error", "error", err.Error())
+			}
+		}()
+	}
+
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.Metrics.Addr, log); err != nil {
+				log.Error("metrics server error", "error", err.Error())
+			}
+		}()
+	}
+
 	bot.Start()
 }
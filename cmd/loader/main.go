@@ -4,9 +4,14 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 	"whattowatch/internal/config"
+	"whattowatch/internal/health"
 	"whattowatch/internal/loader"
+	"whattowatch/internal/metrics"
 	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/tracing"
 	"whattowatch/internal/utils"
 	"whattowatch/pkg/logger"
 )
@@ -21,6 +26,14 @@ func main() {
 
 	log, file := logger.SetupLogger(cfg.Env, cfg.LogDir+"/loader")
 	defer file.Close()
+	log = slog.New(tracing.NewLogHandler(log.Handler()))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, "loader")
+	if err != nil {
+		log.Error("tracing setup error", "error", err.Error())
+		panic("tracing setup error: " + err.Error())
+	}
+	defer shutdownTracing(context.Background())
 
 	log.Info("Current IP: " + utils.GetMyIP())
 
@@ -29,16 +42,51 @@ func main() {
 		log.Error("storage create error", "error", err.Error())
 		panic("storage create error: " + err.Error())
 	}
-	loader, err := loader.NewTMDbLoader(cfg, log, postgresDB)
+	metrics.RegisterPool(postgresDB.Stat)
+
+	tmdbLoader, err := loader.NewTMDbLoader(cfg, log, metrics.NewLoaderStorer(postgresDB))
 	if err != nil {
 		log.Error("loader create error", "error", err.Error())
 		panic("loader create error: " + err.Error())
 	}
+	runner := loader.NewRunner(log, tmdbLoader, postgresDB, cfg.Loader.FullInterval)
+
+	// Without the interval the loader runs once, e.g. by cron.
+	if cfg.Loader.Interval == 0 {
+		if err = runner.Run(context.Background()); err != nil {
+			log.Error("load error", "error", err.Error())
+		}
+
+		if cfg.Metrics.PushgatewayURL != "" {
+			if err = metrics.Push(cfg.Metrics.PushgatewayURL, "loader"); err != nil {
+				log.Error("failed to push metrics", "error", err.Error())
+			}
+		}
+		return
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
-	err = loader.Load(ctx)
-	if err != nil {
-		log.Error("load error", "error", err.Error())
+
+	if cfg.Health.Addr != "" {
+		checker := health.New(log).
+			Add("postgres", health.Ping(postgresDB.Ping)).
+			Add("last_run", runner.CheckLastRun(cfg.Loader.MaxRunAge))
+
+		go func() {
+			if err := checker.Serve(ctx, cfg.Health.Addr); err != nil {
+				log.Error("health server error", "error", err.Error())
+			}
+		}()
 	}
+
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.Metrics.Addr, log); err != nil {
+				log.Error("metrics server error", "error", err.Error())
+			}
+		}()
+	}
+
+	runner.Schedule(ctx, cfg.Loader.Interval)
 }
@@ -26,6 +26,12 @@ func (c *Genres) GetAll() map[int64]string {
 	return c.data
 }
 
+func (c *Genres) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.data)
+}
+
 func (c *Genres) Get(key int64) (string, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
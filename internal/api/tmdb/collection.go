@@ -0,0 +1,41 @@
+package tmdb
+
+import (
+	"context"
+	"whattowatch/internal/api/tmdb/converter"
+	"whattowatch/internal/types"
+)
+
+func (a *TMDbApi) GetCollection(ctx context.Context, id int) (types.Collection, error) {
+	log := a.log.With("fn", "GetCollection", "id", id)
+
+	c, err := a.client.GetCollectionDetails(id, a.getOpts(ctx))
+	if err != nil {
+		return types.Collection{}, err
+	}
+	log.Debug("got collection details", "id", c.ID, "name", c.Name, "parts", len(c.Parts))
+
+	parts := make(types.Content, 0, len(c.Parts))
+	for _, v := range c.Parts {
+		mr := converter.MovieCollectionPartResult(v)
+		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		parts = append(parts, ci)
+	}
+
+	collection := types.Collection{
+		ID:           c.ID,
+		Name:         c.Name,
+		Overview:     c.Overview,
+		PosterPath:   a.cfg.Urls.TMDbImageUrl + c.PosterPath,
+		BackdropPath: a.cfg.Urls.TMDbImageUrl + c.BackdropPath,
+		Parts:        parts,
+	}
+	collection.SortParts()
+
+	return collection, nil
+}
@@ -0,0 +1,29 @@
+package tmdb
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetCollection(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	// Harry Potter Collection
+	res, err := a.GetCollection(ctx, 1241)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1241), res.ID)
+	assert.Greater(t, len(res.Parts), 0)
+
+	for i := 1; i < len(res.Parts); i++ {
+		if res.Parts[i].ReleaseDate.IsZero() {
+			continue
+		}
+		assert.False(t, res.Parts[i].ReleaseDate.Before(res.Parts[i-1].ReleaseDate))
+	}
+}
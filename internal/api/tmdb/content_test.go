@@ -0,0 +1,100 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"whattowatch/internal/api/cache"
+	"whattowatch/internal/config"
+	"whattowatch/internal/types"
+
+	tmdb "github.com/cyruzin/golang-tmdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	missingMovieID   = 7
+	announcedMovieID = 8
+)
+
+// newTestServer serves the movie details, the missing movie is answered at once and the rest after a delay,
+// so the other workers are still busy when the batch fails. The announced movie has no release date.
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/movie/")
+		if id == fmt.Sprint(missingMovieID) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status_code": 34, "status_message": "The resource you requested could not be found."}`)
+			return
+		}
+		releaseDate := "2020-01-01"
+		if id == fmt.Sprint(announcedMovieID) {
+			releaseDate = ""
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, `{"id": %s, "title": "title", "release_date": "%s", "videos": {"results": []}}`, id, releaseDate)
+	}))
+}
+
+func newTestApi(t *testing.T, url string) *TMDbApi {
+	c, err := tmdb.Init("key")
+	require.NoError(t, err)
+	c.SetCustomBaseURL(url)
+
+	return &TMDbApi{
+		client: c,
+		cache:  cache.New(),
+		cfg:    &config.Config{},
+		log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		opts:   map[string]string{"language": types.DefaultLanguage},
+	}
+}
+
+func Test_GetContentFailedTitle(t *testing.T) {
+	srv := newTestServer()
+	a := newTestApi(t, srv.URL)
+
+	ids := make([]int64, 0, 20)
+	for id := range int64(20) {
+		ids = append(ids, id+1)
+	}
+
+	_, err := a.GetContent(context.Background(), types.Movie, ids)
+	assert.Error(t, err)
+
+	// The workers busy on the failure send their results after GetContent returned.
+	srv.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	srv = newTestServer()
+	defer srv.Close()
+	a = newTestApi(t, srv.URL)
+
+	content, err := a.GetContent(context.Background(), types.Movie, []int64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Len(t, content, 3)
+}
+
+func Test_GetMovieReleaseDate(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	a := newTestApi(t, srv.URL)
+
+	item, err := a.GetMovie(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), item.ReleaseDate)
+
+	item, err = a.GetMovie(context.Background(), announcedMovieID)
+	require.NoError(t, err)
+	assert.True(t, item.ReleaseDate.IsZero())
+
+	_, err = a.GetMovie(context.Background(), missingMovieID)
+	assert.True(t, IsNotFound(err))
+}
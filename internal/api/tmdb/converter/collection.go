@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"time"
+	"whattowatch/internal/types"
+)
+
+type MovieCollectionPartResult struct {
+	Adult            bool
+	BackdropPath     string
+	GenreIDs         []int64
+	ID               int64
+	OriginalLanguage string
+	OriginalTitle    string
+	Overview         string
+	PosterPath       string
+	ReleaseDate      string
+	Title            string
+	Video            bool
+	VoteAverage      float32
+	VoteCount        int64
+	Popularity       float32
+}
+
+// Convert converts the collection part. Announced parts have no release date, so it is left empty.
+func (mr MovieCollectionPartResult) Convert(imageUrl string) (types.ContentItem, error) {
+	var rd time.Time
+	if mr.ReleaseDate != "" {
+		var err error
+		rd, err = time.Parse("2006-01-02", mr.ReleaseDate)
+		if err != nil {
+			return types.ContentItem{}, err
+		}
+	}
+
+	title := mr.Title
+	if mr.Title == "" {
+		title = mr.OriginalTitle
+	}
+
+	poster := imageUrl + mr.PosterPath
+	if mr.PosterPath == "" {
+		poster = emptyImageUrl
+	}
+
+	backdrop := imageUrl + mr.BackdropPath
+	if mr.BackdropPath == "" {
+		backdrop = emptyImageUrl
+	}
+
+	return types.ContentItem{
+		ID:           mr.ID,
+		ContentType:  types.Movie,
+		Title:        title,
+		Overview:     mr.Overview,
+		Popularity:   mr.Popularity,
+		PosterPath:   poster,
+		BackdropPath: backdrop,
+		ReleaseDate:  rd,
+		VoteAverage:  mr.VoteAverage,
+		VoteCount:    mr.VoteCount,
+		Adult:        mr.Adult,
+	}, nil
+}
@@ -58,6 +58,7 @@ func (mr MoviePageResult) Convert(imageUrl string) (types.ContentItem, error) {
 		ReleaseDate:  rd,
 		VoteAverage:  mr.VoteAverage,
 		VoteCount:    mr.VoteCount,
+		Adult:        mr.Adult,
 	}, nil
 }
 
@@ -123,6 +124,7 @@ func (mr MovieRecommendationResult) Convert(imageUrl string) (types.ContentItem,
 		ReleaseDate:  rd,
 		VoteAverage:  mr.VoteAverage,
 		VoteCount:    mr.VoteCount,
+		Adult:        mr.Adult,
 	}, nil
 }
 
@@ -175,6 +177,7 @@ func (mr MovieSearchResult) Convert(imageUrl string) (types.ContentItem, error)
 		ReleaseDate:  rd,
 		VoteAverage:  mr.VoteAverage,
 		VoteCount:    mr.VoteCount,
+		Adult:        mr.Adult,
 	}, nil
 }
 
@@ -227,5 +230,18 @@ func (mr MovieByGenreResult) Convert(imageUrl string) (types.ContentItem, error)
 		ReleaseDate:  rd,
 		VoteAverage:  mr.VoteAverage,
 		VoteCount:    mr.VoteCount,
+		Adult:        mr.Adult,
 	}, nil
 }
+
+type MovieNowPlayingResult MoviePageResult
+
+func (mr MovieNowPlayingResult) Convert(imageUrl string) (types.ContentItem, error) {
+	return MoviePageResult(mr).Convert(imageUrl)
+}
+
+type MovieUpcomingResult MoviePageResult
+
+func (mr MovieUpcomingResult) Convert(imageUrl string) (types.ContentItem, error) {
+	return MoviePageResult(mr).Convert(imageUrl)
+}
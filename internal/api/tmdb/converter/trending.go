@@ -0,0 +1,133 @@
+package converter
+
+import (
+	"errors"
+	"time"
+	"whattowatch/internal/types"
+)
+
+type trendingResult struct {
+	Adult              bool
+	Gender             int
+	BackdropPath       string
+	GenreIDs           []int64
+	ID                 int64
+	OriginalLanguage   string
+	OriginalTitle      string
+	Overview           string
+	PosterPath         string
+	ReleaseDate        string
+	Title              string
+	Video              bool
+	VoteAverage        float32
+	VoteCount          int64
+	Popularity         float32
+	FirstAirDate       string
+	Name               string
+	OriginCountry      []string
+	OriginalName       string
+	KnownForDepartment string
+	ProfilePath        string
+	KnownFor           []struct {
+		Adult            bool    `json:"adult"`
+		BackdropPath     string  `json:"backdrop_path"`
+		GenreIds         []int   `json:"genre_ids"`
+		ID               int     `json:"id"`
+		OriginalLanguage string  `json:"original_language"`
+		OriginalTitle    string  `json:"original_title"`
+		Overview         string  `json:"overview"`
+		PosterPath       string  `json:"poster_path"`
+		ReleaseDate      string  `json:"release_date"`
+		Title            string  `json:"title"`
+		Video            bool    `json:"video"`
+		VoteAverage      float64 `json:"vote_average"`
+		VoteCount        int     `json:"vote_count"`
+		Popularity       float64 `json:"popularity"`
+		MediaType        string  `json:"media_type"`
+	}
+}
+
+type MovieTrendingResult trendingResult
+
+func (mr MovieTrendingResult) Convert(imageUrl string) (types.ContentItem, error) {
+	if mr.Title == "" && mr.OriginalTitle == "" {
+		return types.ContentItem{}, errors.New("not a movie")
+	}
+
+	rd, err := time.Parse("2006-01-02", mr.ReleaseDate)
+	if err != nil {
+		return types.ContentItem{}, err
+	}
+
+	title := mr.Title
+	if mr.Title == "" {
+		title = mr.OriginalTitle
+	}
+
+	poster := imageUrl + mr.PosterPath
+	if mr.PosterPath == "" {
+		poster = emptyImageUrl
+	}
+
+	backdrop := imageUrl + mr.BackdropPath
+	if mr.BackdropPath == "" {
+		backdrop = emptyImageUrl
+	}
+
+	return types.ContentItem{
+		ID:           mr.ID,
+		ContentType:  types.Movie,
+		Title:        title,
+		Overview:     mr.Overview,
+		Popularity:   mr.Popularity,
+		PosterPath:   poster,
+		BackdropPath: backdrop,
+		ReleaseDate:  rd,
+		VoteAverage:  mr.VoteAverage,
+		VoteCount:    mr.VoteCount,
+		Adult:        mr.Adult,
+	}, nil
+}
+
+type TVTrendingResult trendingResult
+
+func (tr TVTrendingResult) Convert(imageUrl string) (types.ContentItem, error) {
+	if tr.Name == "" && tr.OriginalName == "" {
+		return types.ContentItem{}, errors.New("not a tv series")
+	}
+
+	rd, err := time.Parse("2006-01-02", tr.FirstAirDate)
+	if err != nil {
+		return types.ContentItem{}, err
+	}
+
+	title := tr.Name
+	if tr.Name == "" {
+		title = tr.OriginalName
+	}
+
+	poster := imageUrl + tr.PosterPath
+	if tr.PosterPath == "" {
+		poster = emptyImageUrl
+	}
+
+	backdrop := imageUrl + tr.BackdropPath
+	if tr.BackdropPath == "" {
+		backdrop = emptyImageUrl
+	}
+
+	return types.ContentItem{
+		ID:           tr.ID,
+		ContentType:  types.TV,
+		Title:        title,
+		Overview:     tr.Overview,
+		Popularity:   tr.Popularity,
+		PosterPath:   poster,
+		BackdropPath: backdrop,
+		ReleaseDate:  rd,
+		VoteAverage:  tr.VoteAverage,
+		VoteCount:    tr.VoteCount,
+		Counties:     tr.OriginCountry,
+		Adult:        tr.Adult,
+	}, nil
+}
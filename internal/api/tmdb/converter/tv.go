@@ -222,3 +222,17 @@ func (tr TVByGenreResult) Convert(imageUrl string) (types.ContentItem, error) {
 		VoteCount:    tr.VoteCount,
 	}, nil
 }
+
+type TVAiringTodayResult TVPageResult
+
+func (tr TVAiringTodayResult) Convert(imageUrl string) (types.ContentItem, error) {
+	pr := TVPageResult(tr)
+	return pr.Convert(imageUrl)
+}
+
+type TVOnTheAirResult TVPageResult
+
+func (tr TVOnTheAirResult) Convert(imageUrl string) (types.ContentItem, error) {
+	pr := TVPageResult(tr)
+	return pr.Convert(imageUrl)
+}
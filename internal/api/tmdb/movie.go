@@ -6,14 +6,17 @@ import (
 	"strings"
 	"time"
 	"whattowatch/internal/api/tmdb/converter"
+	"whattowatch/internal/tracing"
 	"whattowatch/internal/types"
 	"whattowatch/internal/utils"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func (a *TMDbApi) GetMovie(ctx context.Context, id int) (types.ContentItem, error) {
 	log := a.log.With("fn", "GetMovie", "id", id)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["append_to_response"] = "videos"
 
 	m, err := a.client.GetMovieDetails(id, opts)
@@ -22,9 +25,13 @@ func (a *TMDbApi) GetMovie(ctx context.Context, id int) (types.ContentItem, erro
 	}
 	log.Debug("got movie details", "id", m.ID, "title", m.Title, "opts", opts)
 
-	rd, err := time.Parse("2006-01-02", m.ReleaseDate)
-	if err != nil {
-		return types.ContentItem{}, err
+	// Announced titles have no release date, so it is left empty.
+	var rd time.Time
+	if m.ReleaseDate != "" {
+		rd, err = time.Parse("2006-01-02", m.ReleaseDate)
+		if err != nil {
+			return types.ContentItem{}, err
+		}
 	}
 
 	genres := make(types.Genres, 0, len(m.Genres))
@@ -55,13 +62,16 @@ func (a *TMDbApi) GetMovie(ctx context.Context, id int) (types.ContentItem, erro
 		Genres:       genres,
 		Counties:     m.OriginCountry,
 		TrailerURL:   trailerURL,
+		Adult:        m.Adult,
+		CollectionID: m.BelongsToCollection.ID,
+		Runtime:      m.Runtime,
 	}, nil
 }
 
 func (a *TMDbApi) GetMoviePopular(ctx context.Context, page int) (types.Content, error) {
 	log := a.log.With("fn", "GetMoviePopular", "page", page)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["page"] = fmt.Sprintf("%d", page)
 
 	m, err := a.client.GetMoviePopular(opts)
@@ -75,7 +85,7 @@ func (a *TMDbApi) GetMoviePopular(ctx context.Context, page int) (types.Content,
 		mr := converter.MoviePageResult(v)
 		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
 		if err != nil {
-			log.Warn("movie result convert error", "id", v.ID, "error", err.Error())
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
 			continue
 		}
 
@@ -88,7 +98,7 @@ func (a *TMDbApi) GetMoviePopular(ctx context.Context, page int) (types.Content,
 func (a *TMDbApi) GetMovieTop(ctx context.Context, page int) (types.Content, error) {
 	log := a.log.With("fn", "GetMovieTop", "page", page)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["page"] = fmt.Sprintf("%d", page)
 
 	m, err := a.client.GetMovieTopRated(opts)
@@ -102,7 +112,88 @@ func (a *TMDbApi) GetMovieTop(ctx context.Context, page int) (types.Content, err
 		mr := converter.MoviePageResult(v)
 		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
 		if err != nil {
-			log.Warn("movie result convert error", "id", v.ID, "error", err.Error())
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		res = append(res, ci)
+	}
+
+	return res, nil
+}
+
+func (a *TMDbApi) GetMovieTrending(ctx context.Context, window types.TrendingWindow, page int) (types.Content, error) {
+	log := a.log.With("fn", "GetMovieTrending", "window", window, "page", page)
+
+	opts := a.getOpts(ctx)
+	opts["page"] = fmt.Sprintf("%d", page)
+
+	m, err := a.client.GetTrending("movie", string(window), opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("got movie trending", "count", len(m.Results))
+
+	res := make(types.Content, 0, len(m.Results))
+	for _, v := range m.Results {
+		mr := converter.MovieTrendingResult(v)
+		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		res = append(res, ci)
+	}
+
+	return res, nil
+}
+
+func (a *TMDbApi) GetMovieNowPlaying(ctx context.Context, page int) (types.Content, error) {
+	log := a.log.With("fn", "GetMovieNowPlaying", "page", page)
+
+	opts := a.getOpts(ctx)
+	opts["page"] = fmt.Sprintf("%d", page)
+
+	m, err := a.client.GetMovieNowPlaying(opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("got movie now playing", "count", len(m.Results))
+
+	res := make(types.Content, 0, len(m.Results))
+	for _, v := range m.Results {
+		mr := converter.MovieNowPlayingResult(v)
+		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		res = append(res, ci)
+	}
+
+	return res, nil
+}
+
+func (a *TMDbApi) GetMovieUpcoming(ctx context.Context, page int) (types.Content, error) {
+	log := a.log.With("fn", "GetMovieUpcoming", "page", page)
+
+	opts := a.getOpts(ctx)
+	opts["page"] = fmt.Sprintf("%d", page)
+
+	m, err := a.client.GetMovieUpcoming(opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("got movie upcoming", "count", len(m.Results))
+
+	res := make(types.Content, 0, len(m.Results))
+	for _, v := range m.Results {
+		mr := converter.MovieUpcomingResult(v)
+		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
 			continue
 		}
 
@@ -122,13 +213,17 @@ func (a *TMDbApi) GetMovieRecommendations(ctx context.Context, ids []int64) (typ
 	for i := 0; i < workers; i++ {
 		go func(id int, jobCh <-chan int64, movieCh chan<- content) {
 			for job := range jobCh {
-				res, err := a.client.GetMovieRecommendations(int(job), a.opts)
+				jobCtx, span := tracing.Start(ctx, "tmdb GetMovieRecommendations job",
+					attribute.Int("worker_id", id), attribute.Int64("tmdb.id", job))
+				res, err := a.client.GetMovieRecommendations(int(job), a.getOpts(jobCtx))
+				tracing.End(span, err)
 				log.Info("request to TMDb", "worker_id", id, "movie_id", job)
 				if err != nil {
-					log.Error("request error", "id", id, "movie_id", job, "error", err.Error())
+					log.ErrorContext(ctx, "request error", "id", id, "movie_id", job, "error", err.Error())
 					movieCh <- content{
 						err: err,
 					}
+					continue
 				}
 
 				c := make(types.Content, 0, len(res.Results))
@@ -136,7 +231,7 @@ func (a *TMDbApi) GetMovieRecommendations(ctx context.Context, ids []int64) (typ
 					mr := converter.MovieRecommendationResult(v)
 					ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
 					if err != nil {
-						log.Warn("movie result convert error", "id", v.ID, "error", err.Error())
+						log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
 						continue
 					}
 					c = append(c, ci)
@@ -170,7 +265,7 @@ func (a *TMDbApi) GetMovieRecommendations(ctx context.Context, ids []int64) (typ
 	return result, nil
 }
 
-func (a *TMDbApi) searchMovieByTitle(_ context.Context, titles []string) (types.Content, error) {
+func (a *TMDbApi) searchMovieByTitle(ctx context.Context, titles []string) (types.Content, error) {
 	log := a.log.With("fn", "SearchMovieByTitle")
 
 	jobCh := make(chan string, len(titles))
@@ -180,13 +275,14 @@ func (a *TMDbApi) searchMovieByTitle(_ context.Context, titles []string) (types.
 	for i := 0; i < workers; i++ {
 		go func(id int, jobCh <-chan string, movieCh chan<- content) {
 			for job := range jobCh {
-				res, err := a.client.GetSearchMovies(job, a.opts)
+				res, err := a.client.GetSearchMovies(job, a.getOpts(ctx))
 				log.Info("request to TMDb", "worker_id", id, "title", job)
 				if err != nil {
-					log.Error("request error", "id", id, "error", err.Error())
+					log.ErrorContext(ctx, "request error", "id", id, "error", err.Error())
 					movieCh <- content{
 						err: err,
 					}
+					continue
 				}
 
 				c := make(types.Content, 0, len(res.Results))
@@ -198,7 +294,7 @@ func (a *TMDbApi) searchMovieByTitle(_ context.Context, titles []string) (types.
 					mr := converter.MovieSearchResult(v)
 					ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
 					if err != nil {
-						log.Warn("movie result convert error", "id", v.ID, "error", err.Error())
+						log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
 						continue
 					}
 					c = append(c, ci)
@@ -235,7 +331,7 @@ func (a *TMDbApi) searchMovieByTitle(_ context.Context, titles []string) (types.
 func (a *TMDbApi) GetMoviesByGenre(ctx context.Context, genreIDs []int, page int) (types.Content, error) {
 	log := a.log.With("fn", "DiscoverMovies", "page", page, "genres", genreIDs)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["page"] = fmt.Sprintf("%d", page)
 	opts["with_genres"] = strings.Join(utils.IntSliceToStringSlice(genreIDs), ",")
 
@@ -251,7 +347,7 @@ func (a *TMDbApi) GetMoviesByGenre(ctx context.Context, genreIDs []int, page int
 		mr := converter.MovieByGenreResult(v)
 		ci, err := mr.Convert(a.cfg.Urls.TMDbImageUrl)
 		if err != nil {
-			log.Warn("movie result convert error", "id", v.ID, "error", err.Error())
+			log.WarnContext(ctx, "movie result convert error", "id", v.ID, "error", err.Error())
 			continue
 		}
 
@@ -140,6 +140,42 @@ func Test_GetMovieTop(t *testing.T) {
 	assert.Equal(t, 20, len(res))
 }
 
+func Test_GetMovieTrending(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := a.GetMovieTrending(ctx, types.TrendingWeek, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Greater(t, len(res), 0)
+}
+
+func Test_GetMovieNowPlaying(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := a.GetMovieNowPlaying(ctx, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Greater(t, len(res), 0)
+}
+
+func Test_GetMovieUpcoming(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := a.GetMovieUpcoming(ctx, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Greater(t, len(res), 0)
+}
+
 func Test_GetMovieRecommendations(t *testing.T) {
 	a, err := New(getConfig(), slog.Default())
 	assert.NoError(t, err)
@@ -3,12 +3,16 @@ package tmdb
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strconv"
 	"whattowatch/internal/api/cache"
 	"whattowatch/internal/config"
+	"whattowatch/internal/tracing"
 	"whattowatch/internal/types"
 
 	tmdb "github.com/cyruzin/golang-tmdb"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -36,9 +40,18 @@ type (
 
 const workers = 5
 
+// notFoundStatusCode is the TMDb status code of a resource that doesn't exist.
+const notFoundStatusCode = 34
+
+// IsNotFound reports whether err is the TMDb error of a resource that doesn't exist.
+func IsNotFound(err error) bool {
+	var tmdbErr tmdb.Error
+	return errors.As(err, &tmdbErr) && tmdbErr.StatusCode == notFoundStatusCode
+}
+
 func New(cfg *config.Config, log *slog.Logger) (*TMDbApi, error) {
 	opts := make(map[string]string)
-	opts["language"] = "ru-RU"
+	opts["language"] = types.DefaultLanguage
 
 	c, err := tmdb.Init(cfg.Tokens.TMDb)
 	if err != nil {
@@ -49,13 +62,17 @@ func New(cfg *config.Config, log *slog.Logger) (*TMDbApi, error) {
 
 	api := &TMDbApi{
 		client: c,
+		cache:  cache.New(),
 		opts:   opts,
 
 		cfg: cfg,
 		log: log.With("pkg", "api"),
 	}
 
-	api.initCache()
+	// TMDb may be unavailable on start, the cache is reloaded by CheckGenres until it is loaded.
+	if err = api.initCache(); err != nil {
+		api.log.Error("failed to load genres", "error", err.Error())
+	}
 	return api, nil
 }
 
@@ -63,24 +80,37 @@ func (a *TMDbApi) GetContent(ctx context.Context, contentType types.ContentType,
 	log := a.log.With("fn", "GetContent", "content_type", contentType, "ids", ids)
 	log.Debug("func start log")
 
+	// The jobs left after a failed one are skipped. The results channel is never closed: it has room
+	// for every job, so the workers don't block on it after the first error is returned.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	jobCh := make(chan int64, len(ids))
 	contentItemCh := make(chan contentItem, len(ids))
-	defer close(contentItemCh)
 
 	log.Debug("start working pool", "ids", ids)
 	for i := 0; i < workers; i++ {
 		go func(id int, jobCh <-chan int64, contentItemCh chan<- contentItem) {
 			for job := range jobCh {
+				if ctx.Err() != nil {
+					contentItemCh <- contentItem{err: ctx.Err()}
+					continue
+				}
+
+				jobCtx, span := tracing.Start(ctx, "tmdb GetContent job",
+					attribute.Int("worker_id", id), attribute.Int64("tmdb.id", job))
 
 				var ci types.ContentItem
 				var err error
 
 				switch contentType {
 				case types.Movie:
-					ci, err = a.GetMovie(ctx, int(job))
+					ci, err = a.GetMovie(jobCtx, int(job))
 				case types.TV:
-					ci, err = a.GetTV(ctx, int(job))
+					ci, err = a.GetTV(jobCtx, int(job))
 				}
+				tracing.End(span, err)
+
 				contentItemCh <- contentItem{
 					contentItem: ci,
 					err:         err,
@@ -169,17 +199,38 @@ func (a *TMDbApi) SearchByTitles(ctx context.Context, titles []string) (types.Co
 	return append(movies.content, tvs.content...), nil
 }
 
-func (a *TMDbApi) getOpts() map[string]string {
+// getOpts returns a copy of the default request options
+// overridden by the user settings from ctx, if any.
+func (a *TMDbApi) getOpts(ctx context.Context) map[string]string {
 	optsCopy := make(map[string]string, len(a.opts))
 	for k, v := range a.opts {
 		optsCopy[k] = v
 	}
+
+	if s, ok := types.UserSettingsFromContext(ctx); ok {
+		if s.Language != "" {
+			optsCopy["language"] = s.Language
+		}
+		if s.Region != "" {
+			optsCopy["region"] = s.Region
+		}
+		optsCopy["include_adult"] = strconv.FormatBool(s.IncludeAdult)
+	}
+
 	return optsCopy
 }
 
-func (a *TMDbApi) initCache() error {
-	a.cache = cache.New()
+// CheckGenres returns an error if the genres cache is not loaded, it tries to load the cache first.
+func (a *TMDbApi) CheckGenres(ctx context.Context) (string, error) {
+	if a.cache.Genres.Movie.Len() == 0 || a.cache.Genres.TV.Len() == 0 {
+		if err := a.initCache(); err != nil {
+			return "", fmt.Errorf("genres are not loaded: %s", err.Error())
+		}
+	}
+	return fmt.Sprintf("%d movie and %d tv genres", a.cache.Genres.Movie.Len(), a.cache.Genres.TV.Len()), nil
+}
 
+func (a *TMDbApi) initCache() error {
 	g, _ := errgroup.WithContext(context.Background())
 	g.Go(func() error {
 		genres, err := a.client.GetGenreMovieList(a.opts)
@@ -206,7 +257,7 @@ func (a *TMDbApi) initCache() error {
 
 	err := g.Wait()
 
-	a.log.Debug("genres loaded", "movies count", len(a.cache.Genres.Movie.GetAll()), "tvs count", len(a.cache.Genres.TV.GetAll()))
+	a.log.Debug("genres loaded", "movies count", a.cache.Genres.Movie.Len(), "tvs count", a.cache.Genres.TV.Len())
 
 	return err
 }
@@ -6,14 +6,17 @@ import (
 	"strings"
 	"time"
 	"whattowatch/internal/api/tmdb/converter"
+	"whattowatch/internal/tracing"
 	"whattowatch/internal/types"
 	"whattowatch/internal/utils"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func (a *TMDbApi) GetTV(ctx context.Context, id int) (types.ContentItem, error) {
 	log := a.log.With("fn", "GetTV", "id", id)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["append_to_response"] = "videos"
 
 	tv, err := a.client.GetTVDetails(id, opts)
@@ -22,9 +25,13 @@ func (a *TMDbApi) GetTV(ctx context.Context, id int) (types.ContentItem, error)
 	}
 	log.Debug("got tv details", "id", tv.ID, "title", tv.Name)
 
-	rd, err := time.Parse("2006-01-02", tv.FirstAirDate)
-	if err != nil {
-		return types.ContentItem{}, err
+	// Announced titles have no first air date, so it is left empty.
+	var rd time.Time
+	if tv.FirstAirDate != "" {
+		rd, err = time.Parse("2006-01-02", tv.FirstAirDate)
+		if err != nil {
+			return types.ContentItem{}, err
+		}
 	}
 
 	genres := make(types.Genres, 0, len(tv.Genres))
@@ -55,13 +62,27 @@ func (a *TMDbApi) GetTV(ctx context.Context, id int) (types.ContentItem, error)
 		Genres:       genres,
 		Counties:     tv.OriginCountry,
 		TrailerURL:   trailerURL,
+		Runtime:      tvRuntime(tv.EpisodeRunTime, tv.NumberOfEpisodes),
 	}, nil
 }
 
+// tvRuntime estimates the whole TV show duration by the average episode runtime.
+func tvRuntime(episodeRunTime []int, episodes int) int {
+	if len(episodeRunTime) == 0 {
+		return 0
+	}
+
+	sum := 0
+	for _, r := range episodeRunTime {
+		sum += r
+	}
+	return sum / len(episodeRunTime) * episodes
+}
+
 func (a *TMDbApi) GetTVPopular(ctx context.Context, page int) (types.Content, error) {
 	log := a.log.With("fn", "GetTVPopular", "page", page)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["page"] = fmt.Sprintf("%d", page)
 
 	m, err := a.client.GetTVPopular(opts)
@@ -75,7 +96,7 @@ func (a *TMDbApi) GetTVPopular(ctx context.Context, page int) (types.Content, er
 		tr := converter.TVPageResult(v)
 		ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
 		if err != nil {
-			log.Warn("tv result convert error", "id", v.ID, "error", err.Error())
+			log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
 			continue
 		}
 
@@ -88,7 +109,7 @@ func (a *TMDbApi) GetTVPopular(ctx context.Context, page int) (types.Content, er
 func (a *TMDbApi) GetTVTop(ctx context.Context, page int) (types.Content, error) {
 	log := a.log.With("fn", "GetTVTop", "page", page)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["page"] = fmt.Sprintf("%d", page)
 
 	m, err := a.client.GetTVTopRated(opts)
@@ -102,7 +123,88 @@ func (a *TMDbApi) GetTVTop(ctx context.Context, page int) (types.Content, error)
 		tr := converter.TVPageResult(v)
 		ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
 		if err != nil {
-			log.Warn("tv result convert error", "id", v.ID, "error", err.Error())
+			log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		res = append(res, ci)
+	}
+
+	return res, nil
+}
+
+func (a *TMDbApi) GetTVTrending(ctx context.Context, window types.TrendingWindow, page int) (types.Content, error) {
+	log := a.log.With("fn", "GetTVTrending", "window", window, "page", page)
+
+	opts := a.getOpts(ctx)
+	opts["page"] = fmt.Sprintf("%d", page)
+
+	m, err := a.client.GetTrending("tv", string(window), opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("got tv trending", "count", len(m.Results))
+
+	res := make(types.Content, 0, len(m.Results))
+	for _, v := range m.Results {
+		tr := converter.TVTrendingResult(v)
+		ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		res = append(res, ci)
+	}
+
+	return res, nil
+}
+
+func (a *TMDbApi) GetTVAiringToday(ctx context.Context, page int) (types.Content, error) {
+	log := a.log.With("fn", "GetTVAiringToday", "page", page)
+
+	opts := a.getOpts(ctx)
+	opts["page"] = fmt.Sprintf("%d", page)
+
+	m, err := a.client.GetTVAiringToday(opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("got tv airing today", "count", len(m.Results))
+
+	res := make(types.Content, 0, len(m.Results))
+	for _, v := range m.Results {
+		tr := converter.TVAiringTodayResult(v)
+		ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
+			continue
+		}
+
+		res = append(res, ci)
+	}
+
+	return res, nil
+}
+
+func (a *TMDbApi) GetTVOnTheAir(ctx context.Context, page int) (types.Content, error) {
+	log := a.log.With("fn", "GetTVOnTheAir", "page", page)
+
+	opts := a.getOpts(ctx)
+	opts["page"] = fmt.Sprintf("%d", page)
+
+	m, err := a.client.GetTVOnTheAir(opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("got tv on the air", "count", len(m.Results))
+
+	res := make(types.Content, 0, len(m.Results))
+	for _, v := range m.Results {
+		tr := converter.TVOnTheAirResult(v)
+		ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
+		if err != nil {
+			log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
 			continue
 		}
 
@@ -122,13 +224,17 @@ func (a *TMDbApi) GetTVRecommendations(ctx context.Context, ids []int64) (types.
 	for i := 0; i < workers; i++ {
 		go func(id int, jobCh <-chan int64, tvCh chan<- content) {
 			for job := range jobCh {
-				res, err := a.client.GetTVRecommendations(int(job), a.opts)
+				jobCtx, span := tracing.Start(ctx, "tmdb GetTVRecommendations job",
+					attribute.Int("worker_id", id), attribute.Int64("tmdb.id", job))
+				res, err := a.client.GetTVRecommendations(int(job), a.getOpts(jobCtx))
+				tracing.End(span, err)
 				log.Info("request to TMDb", "worker_id", id, "tv_id", job)
 				if err != nil {
-					log.Error("request error", "id", id, "tv_id", job, "error", err.Error())
+					log.ErrorContext(ctx, "request error", "id", id, "tv_id", job, "error", err.Error())
 					tvCh <- content{
 						err: err,
 					}
+					continue
 				}
 
 				c := make(types.Content, 0, len(res.Results))
@@ -136,7 +242,7 @@ func (a *TMDbApi) GetTVRecommendations(ctx context.Context, ids []int64) (types.
 					tr := converter.TVRecommendationResult(v)
 					ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
 					if err != nil {
-						log.Warn("tv result convert error", "id", v.ID, "error", err.Error())
+						log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
 						continue
 					}
 
@@ -171,7 +277,7 @@ func (a *TMDbApi) GetTVRecommendations(ctx context.Context, ids []int64) (types.
 	return result, nil
 }
 
-func (a *TMDbApi) searchTVByTitle(_ context.Context, titles []string) (types.Content, error) {
+func (a *TMDbApi) searchTVByTitle(ctx context.Context, titles []string) (types.Content, error) {
 	log := a.log.With("fn", "SearchTVByTitle")
 
 	jobCh := make(chan string, len(titles))
@@ -181,13 +287,14 @@ func (a *TMDbApi) searchTVByTitle(_ context.Context, titles []string) (types.Con
 	for i := 0; i < workers; i++ {
 		go func(id int, jobCh <-chan string, movieCh chan<- content) {
 			for job := range jobCh {
-				res, err := a.client.GetSearchTVShow(job, a.opts)
+				res, err := a.client.GetSearchTVShow(job, a.getOpts(ctx))
 				log.Info("request to TMDb", "worker_id", id, "title", job)
 				if err != nil {
-					log.Error("request error", "id", id, "error", err.Error())
+					log.ErrorContext(ctx, "request error", "id", id, "error", err.Error())
 					movieCh <- content{
 						err: err,
 					}
+					continue
 				}
 
 				c := make(types.Content, 0, len(res.Results))
@@ -199,7 +306,7 @@ func (a *TMDbApi) searchTVByTitle(_ context.Context, titles []string) (types.Con
 					tr := converter.TVSearchResult(v)
 					ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
 					if err != nil {
-						log.Warn("tv result convert error", "id", v.ID, "error", err.Error())
+						log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
 						continue
 					}
 
@@ -237,7 +344,7 @@ func (a *TMDbApi) searchTVByTitle(_ context.Context, titles []string) (types.Con
 func (a *TMDbApi) GetTVsByGenre(ctx context.Context, genreIDs []int, page int) (types.Content, error) {
 	log := a.log.With("fn", "DiscoverTV", "page", page, "genres", genreIDs)
 
-	opts := a.getOpts()
+	opts := a.getOpts(ctx)
 	opts["page"] = fmt.Sprintf("%d", page)
 	opts["with_genres"] = strings.Join(utils.IntSliceToStringSlice(genreIDs), ",")
 
@@ -253,7 +360,7 @@ func (a *TMDbApi) GetTVsByGenre(ctx context.Context, genreIDs []int, page int) (
 		tr := converter.TVByGenreResult(v)
 		ci, err := tr.Convert(a.cfg.Urls.TMDbImageUrl)
 		if err != nil {
-			log.Warn("tv result convert error", "id", v.ID, "error", err.Error())
+			log.WarnContext(ctx, "tv result convert error", "id", v.ID, "error", err.Error())
 			continue
 		}
 
@@ -125,6 +125,42 @@ func Test_GetTVTop(t *testing.T) {
 	assert.Equal(t, 20, len(res))
 }
 
+func Test_GetTVTrending(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := a.GetTVTrending(ctx, types.TrendingDay, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Greater(t, len(res), 0)
+}
+
+func Test_GetTVAiringToday(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := a.GetTVAiringToday(ctx, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Greater(t, len(res), 0)
+}
+
+func Test_GetTVOnTheAir(t *testing.T) {
+	a, err := New(getConfig(), slog.Default())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := a.GetTVOnTheAir(ctx, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Greater(t, len(res), 0)
+}
+
 func Test_GetTVRecommendations(t *testing.T) {
 	a, err := New(getConfig(), slog.Default())
 	assert.NoError(t, err)
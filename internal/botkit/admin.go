@@ -0,0 +1,126 @@
+package botkit
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// adminReportDays is the default period of the funnel and the usage reports.
+	adminReportDays = 30
+	// adminRetentionWeeks is the default number of the retention cohorts.
+	adminRetentionWeeks = 8
+	adminUsageLimit     = 30
+	// adminRemovedLimit is the default number of the removed titles kept in the user lists.
+	adminRemovedLimit = 30
+)
+
+var (
+	adminFunnels = []struct {
+		title string
+		steps []types.EventType
+	}{
+		{"Поиск → карточка → избранное", []types.EventType{types.EventSearch, types.EventCardOpen, types.EventFavoriteAdd}},
+		{"Рекомендации → карточка → избранное", []types.EventType{types.EventRecommendationImpression, types.EventCardOpen, types.EventFavoriteAdd}},
+	}
+	adminUsageEvents = []types.EventType{types.EventCommand, types.EventButton, types.EventCallback}
+)
+
+// adminHandler sends the product analytics reports: /admin_funnel and /admin_usage for the last days,
+// /admin_retention for the last weeks, and the report of the removed titles kept in the user lists, /admin_removed.
+// The other users get the unknown command reply.
+func (t *TGBot) adminHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "adminHandler", "user_id", userID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	if !t.cfg.Admin.IsAdmin(userID) {
+		t.sendUnknownCommand(ctx, chatID)
+		return
+	}
+
+	command, arg, _ := strings.Cut(update.Message.Text, " ")
+	period := 0
+	if arg != "" {
+		// The regexp allows the digits only.
+		period, _ = strconv.Atoi(arg)
+	}
+
+	var (
+		text string
+		err  error
+	)
+	switch command {
+	case "/admin_funnel":
+		text, err = t.funnelReport(ctx, cmp.Or(period, adminReportDays))
+	case "/admin_retention":
+		text, err = t.retentionReport(ctx, cmp.Or(period, adminRetentionWeeks))
+	case "/admin_usage":
+		text, err = t.usageReport(ctx, cmp.Or(period, adminReportDays))
+	case "/admin_removed":
+		text, err = t.removedReport(ctx, cmp.Or(period, adminRemovedLimit))
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get report", "command", command, "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+}
+
+func (t *TGBot) funnelReport(ctx context.Context, days int) (string, error) {
+	since := time.Now().AddDate(0, 0, -days)
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("Воронки за %d дн., пользователей на шаге\n", days))
+	for _, f := range adminFunnels {
+		funnel, err := t.storer.GetFunnel(ctx, f.steps, since)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("\n" + f.title + "\n")
+		sb.WriteString(funnel.GetInfo())
+	}
+	return sb.String(), nil
+}
+
+func (t *TGBot) retentionReport(ctx context.Context, weeks int) (string, error) {
+	cohorts, err := t.storer.GetRetentionCohorts(ctx, time.Now().AddDate(0, 0, -7*weeks))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Недельные когорты за %d нед.: неделя (пользователей): активны в следующие недели\n\n", weeks) + cohorts.GetInfo(), nil
+}
+
+func (t *TGBot) usageReport(ctx context.Context, days int) (string, error) {
+	usage, err := t.storer.GetEventsUsage(ctx, adminUsageEvents, time.Now().AddDate(0, 0, -days), adminUsageLimit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Команды, кнопки и колбэки за %d дн.\n\n", days) + usage.GetInfo(), nil
+}
+
+func (t *TGBot) removedReport(ctx context.Context, limit int) (string, error) {
+	items, err := t.storer.GetRemovedListItems(ctx, limit)
+	if err != nil {
+		return "", err
+	}
+	return "Удаленные из TMDb фильмы и сериалы в списках пользователей\n\n" + items.GetInfo(), nil
+}
@@ -0,0 +1,75 @@
+package botkit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// collectionPrefix is a callback data prefix of the "Вся франшиза" button.
+const collectionPrefix = "cl_"
+
+func (t *TGBot) collectionHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log := t.log.With("fn", "collectionHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
+	log.Debug("handler func start log")
+
+	id, err := strconv.Atoi(update.Message.Text[2:])
+	if err != nil {
+		log.ErrorContext(ctx, "failed to parse id", "error", err.Error())
+		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		return
+	}
+
+	t.showCollection(ctx, update.Message.Chat.ID, update.Message.From.ID, id)
+}
+
+func (t *TGBot) onCollectionEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+
+	log := t.log.With("fn", "onCollectionEvent", "user_id", query.From.ID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+
+	id, err := strconv.Atoi(strings.TrimPrefix(query.Data, collectionPrefix))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to parse id", "error", err.Error())
+		t.sendErrorMessage(ctx, query.From.ID)
+		return
+	}
+
+	t.showCollection(ctx, query.From.ID, query.From.ID, id)
+}
+
+// showCollection shows all the collection parts in the release order marking the ones viewed by the user.
+func (t *TGBot) showCollection(ctx context.Context, chatID int64, userID int64, id int) {
+	log := t.log.With("fn", "showCollection", "chat_id", chatID, "collection_id", id)
+
+	collection, err := t.api.GetCollection(ctx, id)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get collection", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	viewedIDs, err := t.storer.GetViewedContentIDs(ctx, userID, types.Movie)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get user viewed", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      collection.GetInfo(viewedIDs),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
@@ -0,0 +1,82 @@
+package botkit
+
+import (
+	"context"
+	"time"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// communityFeedLimit limits the titles fetched from the data provider for the community feed.
+const communityFeedLimit = 30
+
+// onCommunityFeedEvent shows the titles popular with the bot users the user hasn't viewed yet.
+func (t *TGBot) onCommunityFeedEvent(contentType types.ContentType) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		userID := update.Message.From.ID
+		chatID := update.Message.Chat.ID
+
+		log := t.log.With("fn", "onCommunityFeedEvent", "user_id", userID, "chat_id", chatID, "content_type", contentType)
+		log.Debug("handler func start log")
+
+		ids, err := t.storer.GetCommunityFeedIDs(ctx, userID, contentType, communityFeedLimit)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get community feed", "error", err.Error())
+			t.sendErrorMessage(ctx, chatID)
+			return
+		}
+
+		content := make(types.Content, 0, len(ids))
+		if len(ids) > 0 {
+			content, err = t.api.GetContent(ctx, contentType, ids)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to get content", "error", err.Error())
+				t.sendErrorMessage(ctx, chatID)
+				return
+			}
+		}
+
+		settings, ok := types.UserSettingsFromContext(ctx)
+		if !ok {
+			settings = types.DefaultUserSettings(userID)
+		}
+		content = settings.Filter(content.SortByIDs(ids))
+
+		if len(content) == 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "Пока нечего показать: пользователи бота ещё не отметили ничего нового для вас",
+			})
+			return
+		}
+
+		slides := t.generateSlider(ctx, content, nil)
+		_, err = slides.Show(ctx, t.bot, chatID)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
+			t.sendErrorMessage(ctx, chatID)
+		}
+	}
+}
+
+// runCommunityFeedRefresher refreshes the community feed every interval until ctx is done.
+func (t *TGBot) runCommunityFeedRefresher(ctx context.Context) {
+	log := t.log.With("fn", "runCommunityFeedRefresher", "interval", t.cfg.CommunityFeedInterval)
+
+	ticker := time.NewTicker(t.cfg.CommunityFeedInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.storer.RefreshCommunityFeed(ctx); err != nil {
+			log.ErrorContext(ctx, "failed to refresh community feed", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
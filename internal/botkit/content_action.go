@@ -0,0 +1,127 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"whattowatch/internal/types"
+)
+
+type ContentAction string
+
+const (
+	AddToFavorite      ContentAction = "fav_add"
+	RemoveFromFavorite ContentAction = "fav_rm"
+	AddToViewed        ContentAction = "view_add"
+	RemoveFromViewed   ContentAction = "view_rm"
+	Rewatch            ContentAction = "rewatch"
+	WatchedYesterday   ContentAction = "seen_yd"
+	WatchedLastWeek    ContentAction = "seen_lw"
+	WatchedOnDate      ContentAction = "seen_dt"
+	RateAsk            ContentAction = "rate"
+	RateCancel         ContentAction = "rate_back"
+)
+
+// rateActionPrefix is a prefix of the rating actions like "rt8".
+const rateActionPrefix = "rt"
+
+func rateAction(rating int) ContentAction {
+	return ContentAction(fmt.Sprintf("%s%d", rateActionPrefix, rating))
+}
+
+// parseRateAction returns the rating of the "rt8" like action, ok is false for the other actions.
+func parseRateAction(action ContentAction) (int, bool) {
+	str, found := strings.CutPrefix(string(action), rateActionPrefix)
+	if !found {
+		return 0, false
+	}
+	rating, err := strconv.Atoi(str)
+	if err != nil || rating < 1 || rating > 10 {
+		return 0, false
+	}
+	return rating, true
+}
+
+// contentActionPrefix is a callback data prefix of the content card buttons.
+const contentActionPrefix = "ca_"
+
+// contentActionData builds callback data like "ca_fav_add:f123".
+// Callback data is limited to 64 bytes, so only the content type sign and id are passed.
+func contentActionData(action ContentAction, item types.ContentItem) string {
+	return fmt.Sprintf("%s%s:%s", contentActionPrefix, action, contentRef(item))
+}
+
+// contentRef builds the content reference like "f123" used in the commands and callback data.
+func contentRef(item types.ContentItem) string {
+	return fmt.Sprintf("%s%d", item.ContentType.Sign(), item.ID)
+}
+
+func parseContentActionData(data string) (ContentAction, types.ContentType, int, error) {
+	arr := strings.SplitN(strings.TrimPrefix(data, contentActionPrefix), ":", 2)
+	if len(arr) != 2 {
+		return "", 0, 0, fmt.Errorf("wrong content action data: %s", data)
+	}
+
+	contentType, id, err := parseContentRef(arr[1])
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	return ContentAction(arr[0]), contentType, id, nil
+}
+
+// parseContentRef parses the content reference like "f123" used in the commands and callback data.
+func parseContentRef(ref string) (types.ContentType, int, error) {
+	if len(ref) < 2 {
+		return 0, 0, fmt.Errorf("wrong content reference: %s", ref)
+	}
+
+	contentType, err := types.ParseContentTypeSign(ref[:1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := strconv.Atoi(ref[1:])
+	if err != nil {
+		return 0, 0, fmt.Errorf("wrong content id: %s", err.Error())
+	}
+
+	return contentType, id, nil
+}
+
+func (t *TGBot) getContentActionFunc(action ContentAction) (modifyUserContentFunc, string, error) {
+	switch action {
+	case AddToFavorite:
+		return t.storer.AddContentItemToFavorite, "Добавлено в избранное", nil
+	case RemoveFromFavorite:
+		return t.storer.RemoveContentItemFromFavorite, "Удалено из избранного", nil
+	case AddToViewed:
+		return t.storer.AddContentItemToViewed, "Добавлено в просмотренные", nil
+	case RemoveFromViewed:
+		return t.storer.RemoveContentItemFromViewed, "Удалено из просмотренных", nil
+	case Rewatch:
+		return t.storer.AddContentItemToViewed, "Повторный просмотр добавлен", nil
+	case WatchedYesterday:
+		return t.setLastWatchDateFunc(time.Now().AddDate(0, 0, -1)), "Дата просмотра изменена", nil
+	case WatchedLastWeek:
+		return t.setLastWatchDateFunc(time.Now().AddDate(0, 0, -7)), "Дата просмотра изменена", nil
+	}
+	if rating, ok := parseRateAction(action); ok {
+		return t.setRatingFunc(rating), "Оценка сохранена", nil
+	}
+	return nil, "", fmt.Errorf("unknown content action: %s", action)
+}
+
+func (t *TGBot) setLastWatchDateFunc(watchedAt time.Time) modifyUserContentFunc {
+	return func(ctx context.Context, userID int64, item types.ContentItem) error {
+		return t.storer.SetLastWatchDate(ctx, userID, item, watchedAt)
+	}
+}
+
+func (t *TGBot) setRatingFunc(rating int) modifyUserContentFunc {
+	return func(ctx context.Context, userID int64, item types.ContentItem) error {
+		return t.storer.SetRating(ctx, userID, item, rating)
+	}
+}
@@ -0,0 +1,29 @@
+package botkit
+
+import (
+	"testing"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseRateAction(t *testing.T) {
+	item := types.ContentItem{ID: 550, ContentType: types.Movie}
+
+	for rating := 1; rating <= 10; rating++ {
+		action, contentType, id, err := parseContentActionData(contentActionData(rateAction(rating), item))
+		require.NoError(t, err)
+		assert.Equal(t, types.Movie, contentType)
+		assert.Equal(t, 550, id)
+
+		got, ok := parseRateAction(action)
+		assert.True(t, ok)
+		assert.Equal(t, rating, got)
+	}
+
+	for _, action := range []ContentAction{RateAsk, RateCancel, Rewatch, "rt", "rt0", "rt11", "rtx"} {
+		_, ok := parseRateAction(action)
+		assert.False(t, ok, action)
+	}
+}
@@ -0,0 +1,56 @@
+package botkit
+
+import (
+	"context"
+	"strings"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// contentActionEvents are the event types of the favorite and viewed toggles.
+var contentActionEvents = map[ContentAction]types.EventType{
+	AddToFavorite:      types.EventFavoriteAdd,
+	RemoveFromFavorite: types.EventFavoriteRemove,
+	AddToViewed:        types.EventViewedAdd,
+	RemoveFromViewed:   types.EventViewedRemove,
+	Rewatch:            types.EventViewedAdd,
+}
+
+// eventsMiddleware records the commands, the menu buttons and the callbacks. The actions on the titles
+// are recorded by their handlers, the plain text is recorded as the search.
+func (t *TGBot) eventsMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		switch {
+		case update.CallbackQuery != nil:
+			t.events.Record(types.Event{UserID: update.CallbackQuery.From.ID, Type: types.EventCallback, Name: HandlerName(update)})
+		case update.Message != nil && strings.HasPrefix(update.Message.Text, "/"):
+			t.events.Record(types.Event{UserID: update.Message.From.ID, Type: types.EventCommand, Name: HandlerName(update)})
+		case update.Message != nil && t.isMenuButton(update.Message.Text):
+			t.events.Record(types.Event{UserID: update.Message.From.ID, Type: types.EventButton, Name: update.Message.Text})
+		}
+
+		next(ctx, b, update)
+	}
+}
+
+func (t *TGBot) isMenuButton(text string) bool {
+	for _, m := range t.menus {
+		for _, row := range m.rows {
+			for _, button := range row {
+				if button.text == text {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// recordImpressions records the titles shown to the user, the ones beyond the slider limit are not shown.
+func (t *TGBot) recordImpressions(userID int64, eventType types.EventType, content types.Content) {
+	for _, item := range content[:min(len(content), maxSlides)] {
+		t.events.Record(types.NewContentEvent(userID, eventType, item))
+	}
+}
@@ -0,0 +1,149 @@
+package botkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// forgetPrefix is a callback data prefix of the /forget confirmation buttons.
+	forgetPrefix  = "fg_"
+	forgetConfirm = forgetPrefix + "confirm"
+	forgetCancel  = forgetPrefix + "cancel"
+
+	purgeInterval = time.Hour
+)
+
+func (t *TGBot) forgetHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "forgetHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: "Вы уверены, что хотите удалить аккаунт? Избранное, просмотренное и настройки будут удалены без возможности восстановления.\n" +
+			"Перед удалением мы пришлем вам файл со всеми вашими данными.",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{Text: "Да, удалить", CallbackData: forgetConfirm},
+					{Text: "Отмена", CallbackData: forgetCancel},
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
+
+func (t *TGBot) onForgetEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	userID := query.From.ID
+
+	log := t.log.With("fn", "onForgetEvent", "user_id", userID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+
+	if query.Message.Message != nil {
+		_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    query.Message.Message.Chat.ID,
+			MessageID: query.Message.Message.ID,
+		})
+		if err != nil {
+			log.WarnContext(ctx, "failed to delete confirmation message", "error", err.Error())
+		}
+	}
+
+	if query.Data != forgetConfirm {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: userID,
+			Text:   "Удаление аккаунта отменено",
+		})
+		return
+	}
+
+	takeout, err := t.storer.GetUserTakeout(ctx, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get user takeout", "error", err.Error())
+		t.sendErrorMessage(ctx, userID)
+		return
+	}
+
+	data, err := json.MarshalIndent(takeout, "", "  ")
+	if err != nil {
+		log.ErrorContext(ctx, "failed to marshal user takeout", "error", err.Error())
+		t.sendErrorMessage(ctx, userID)
+		return
+	}
+
+	// The data is wiped only after the user has received the takeout.
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   userID,
+		Document: &models.InputFileUpload{Filename: "whattowatch.json", Data: bytes.NewReader(data)},
+		Caption:  "Ваши данные",
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send user takeout", "error", err.Error())
+		t.sendErrorMessage(ctx, userID)
+		return
+	}
+
+	// The queued events, e.g. of this very command, would be inserted after the events are purged.
+	t.events.Forget(userID)
+	err = t.storer.DeleteUser(ctx, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to delete user", "error", err.Error())
+		t.sendErrorMessage(ctx, userID)
+		return
+	}
+
+	t.mu.Lock()
+	delete(t.userData, userID)
+	t.mu.Unlock()
+
+	if err = t.fsm.Reset(ctx, userID); err != nil {
+		log.ErrorContext(ctx, "failed to reset user state", "error", err.Error())
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      userID,
+		Text:        "Ваш аккаунт удален. Чтобы начать заново, выполните /start",
+		ReplyMarkup: &models.ReplyKeyboardRemove{RemoveKeyboard: true},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+	log.Info("user deleted")
+}
+
+// runUserPurger hard-deletes the soft-deleted users after the retention period until ctx is done.
+func (t *TGBot) runUserPurger(ctx context.Context) {
+	log := t.log.With("fn", "runUserPurger", "retention", t.cfg.UserRetention)
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := t.storer.PurgeDeletedUsers(ctx, time.Now().Add(-t.cfg.UserRetention))
+		if err != nil {
+			log.ErrorContext(ctx, "failed to purge deleted users", "error", err.Error())
+		} else if count > 0 {
+			log.Info("deleted users purged", "count", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,323 @@
+// Package fsm is a per-user conversation state machine for the bot.
+//
+// The machine has named states and transitions keyed by a plain text, a command or a callback data prefix.
+// A transient state, like waiting for the user input, remembers the state it was entered from,
+// returns to it when the user does something else and expires after the state timeout.
+// The state of each user is kept in a pluggable Store.
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type StateID string
+
+const (
+	// AnyState is a source state of the transitions available in every state.
+	AnyState StateID = "*"
+	// Stay is a target of the transitions keeping the current state.
+	Stay StateID = ""
+	// Back is a target of the transitions leaving the transient state for the state it was entered from.
+	Back StateID = "<back>"
+)
+
+type State struct {
+	ID StateID
+	// Timeout is the time the user stays in the state without transitions. Zero means no timeout.
+	Timeout time.Duration
+	// Transient state is left for the previous state on any message it has no transition for.
+	// The transitions of the previous state are still available in the transient state.
+	Transient bool
+}
+
+// Action is run on the transition in the source state, so the state data is available.
+// The session moves to the target state after the action, the action may choose another target with Goto.
+type Action func(ctx context.Context, b *bot.Bot, update *models.Update, s *Session)
+
+// Handler adapts the bot handler to the transition action.
+func Handler(fn bot.HandlerFunc) Action {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update, s *Session) {
+		fn(ctx, b, update)
+	}
+}
+
+type Transition struct {
+	From    StateID
+	Trigger Trigger
+	To      StateID
+	Action  Action
+}
+
+type Machine struct {
+	store   Store
+	initial StateID
+
+	states      map[StateID]State
+	transitions map[StateID][]Transition
+
+	now func() time.Time
+}
+
+type Option func(m *Machine)
+
+// WithClock sets the time source of the state timeouts.
+func WithClock(now func() time.Time) Option {
+	return func(m *Machine) {
+		m.now = now
+	}
+}
+
+func New(store Store, initial State, opts ...Option) *Machine {
+	m := &Machine{
+		store:       store,
+		initial:     initial.ID,
+		states:      map[StateID]State{initial.ID: initial},
+		transitions: make(map[StateID][]Transition),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AddState registers the states. The transitions may refer to the registered states only.
+func (m *Machine) AddState(states ...State) *Machine {
+	for _, st := range states {
+		m.states[st.ID] = st
+	}
+	return m
+}
+
+// On registers the transition. It panics on the unknown states as the machine is built on the start.
+func (m *Machine) On(from StateID, trigger Trigger, to StateID, action Action) *Machine {
+	if _, ok := m.states[from]; !ok && from != AnyState {
+		panic(fmt.Sprintf("fsm: unknown source state %q", from))
+	}
+	if _, ok := m.states[to]; !ok && to != Stay && to != Back {
+		panic(fmt.Sprintf("fsm: unknown target state %q", to))
+	}
+
+	m.transitions[from] = append(m.transitions[from], Transition{From: from, Trigger: trigger, To: to, Action: action})
+	return m
+}
+
+// Handle runs the transition matched the update. It returns false if the update should be handled elsewhere.
+func (m *Machine) Handle(ctx context.Context, b *bot.Bot, update *models.Update) (bool, error) {
+	userID, ev, ok := eventOf(update)
+	if !ok {
+		return false, nil
+	}
+
+	s, changed, err := m.load(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	// A message the transient state has no own transition for leaves it before any other transition.
+	tr, ok := m.match(s, ev)
+	if (!ok || tr.From != s.State) && ev.kind != eventCallback && m.states[s.State].Transient {
+		m.move(&s, Back, nil)
+		changed = true
+	}
+	if !ok {
+		if changed {
+			return false, m.store.Save(ctx, userID, s)
+		}
+		return false, nil
+	}
+
+	if tr.Action != nil {
+		tr.Action(ctx, b, update, &s)
+	}
+
+	next := target{to: tr.To}
+	if s.next != nil {
+		next = *s.next
+		s.next = nil
+	} else if m.resolve(s, tr.To) == s.State {
+		// The data is kept while the transition stays in the same state.
+		next.data = s.Data
+	}
+	m.move(&s, next.to, next.data)
+
+	return true, m.store.Save(ctx, userID, s)
+}
+
+// Enter moves the user to the state with the data, e.g. when a prompt is sent outside the machine.
+func (m *Machine) Enter(ctx context.Context, userID int64, state StateID, data map[string]string) error {
+	if _, ok := m.states[state]; !ok {
+		return fmt.Errorf("unknown state %q", state)
+	}
+
+	s, _, err := m.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+	m.move(&s, state, data)
+	return m.store.Save(ctx, userID, s)
+}
+
+// Current returns the current session of the user.
+func (m *Machine) Current(ctx context.Context, userID int64) (Session, error) {
+	s, _, err := m.load(ctx, userID)
+	return s, err
+}
+
+// Reset forgets the state of the user.
+func (m *Machine) Reset(ctx context.Context, userID int64) error {
+	return m.store.Delete(ctx, userID)
+}
+
+// load returns the session of the user, a new, unknown or expired state is replaced.
+func (m *Machine) load(ctx context.Context, userID int64) (Session, bool, error) {
+	s, err := m.store.Load(ctx, userID)
+	if err != nil {
+		return Session{}, false, fmt.Errorf("failed to load session: %s", err.Error())
+	}
+
+	if _, ok := m.states[s.State]; !ok {
+		return Session{State: m.initial}, true, nil
+	}
+	if !s.ExpiresAt.IsZero() && m.now().After(s.ExpiresAt) {
+		to := s.Prev
+		if to == "" {
+			to = m.initial
+		}
+		m.move(&s, to, nil)
+		return s, true, nil
+	}
+	return s, false, nil
+}
+
+// match looks for the transition of the current state, then of the state the transient state was entered from,
+// then of any state. The any text transitions of the current state are the last resort.
+func (m *Machine) match(s Session, ev event) (Transition, bool) {
+	sources := []StateID{s.State}
+	if s.Prev != "" {
+		sources = append(sources, s.Prev)
+	}
+	sources = append(sources, AnyState)
+
+	for _, from := range sources {
+		for _, tr := range m.transitions[from] {
+			if !tr.Trigger.any && tr.Trigger.match(ev) {
+				return tr, true
+			}
+		}
+	}
+	for _, tr := range m.transitions[s.State] {
+		if tr.Trigger.any && tr.Trigger.match(ev) {
+			return tr, true
+		}
+	}
+	return Transition{}, false
+}
+
+// resolve returns the state the target refers to.
+func (m *Machine) resolve(s Session, to StateID) StateID {
+	switch {
+	case to == Stay:
+		return s.State
+	case to == Back && s.Prev != "":
+		return s.Prev
+	case to == Back:
+		return s.State
+	}
+	return to
+}
+
+// move sets the session state and data. Entering a transient state from a regular one remembers the latter.
+func (m *Machine) move(s *Session, to StateID, data map[string]string) {
+	to = m.resolve(*s, to)
+
+	s.Data = data
+	if m.states[to].Transient {
+		if !m.states[s.State].Transient {
+			s.Prev = s.State
+		}
+	} else {
+		s.Prev = ""
+	}
+	s.State = to
+
+	s.ExpiresAt = time.Time{}
+	if timeout := m.states[to].Timeout; timeout > 0 {
+		s.ExpiresAt = m.now().Add(timeout)
+	}
+}
+
+type eventKind int
+
+const (
+	eventText eventKind = iota
+	eventCommand
+	eventCallback
+)
+
+type event struct {
+	kind  eventKind
+	value string
+}
+
+func eventOf(update *models.Update) (int64, event, bool) {
+	switch {
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID, event{kind: eventCallback, value: update.CallbackQuery.Data}, true
+	case update.Message != nil && update.Message.From != nil:
+		text := update.Message.Text
+		if !strings.HasPrefix(text, "/") {
+			return update.Message.From.ID, event{kind: eventText, value: text}, true
+		}
+		command, _, _ := strings.Cut(strings.Fields(text)[0], "@")
+		return update.Message.From.ID, event{kind: eventCommand, value: command}, true
+	}
+	return 0, event{}, false
+}
+
+// Trigger is a condition of the transition.
+type Trigger struct {
+	kind  eventKind
+	value string
+	any   bool
+}
+
+// Text is triggered by the message with the exact text, e.g. the reply keyboard button.
+func Text(text string) Trigger {
+	return Trigger{kind: eventText, value: text}
+}
+
+// AnyText is triggered by any message but a command, e.g. the user input.
+// It has the lowest priority and is matched in the source state only.
+func AnyText() Trigger {
+	return Trigger{kind: eventText, any: true}
+}
+
+// Command is triggered by the command with or without the arguments, e.g. "/search".
+func Command(command string) Trigger {
+	return Trigger{kind: eventCommand, value: command}
+}
+
+// Callback is triggered by the callback query with the data prefix.
+func Callback(prefix string) Trigger {
+	return Trigger{kind: eventCallback, value: prefix}
+}
+
+func (tr Trigger) match(ev event) bool {
+	if tr.kind != ev.kind {
+		return false
+	}
+	switch {
+	case tr.any:
+		return true
+	case tr.kind == eventCallback:
+		return strings.HasPrefix(ev.value, tr.value)
+	}
+	return tr.value == ev.value
+}
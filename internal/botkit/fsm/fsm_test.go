@@ -0,0 +1,280 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userID = 1
+
+	stateMain   StateID = "main"
+	stateMovies StateID = "movies"
+	stateSearch StateID = "search"
+	stateNote   StateID = "note"
+	stateWizard StateID = "wizard"
+)
+
+func message(text string) *models.Update {
+	return &models.Update{Message: &models.Message{Text: text, From: &models.User{ID: userID}}}
+}
+
+func callback(data string) *models.Update {
+	return &models.Update{CallbackQuery: &models.CallbackQuery{Data: data, From: models.User{ID: userID}}}
+}
+
+// testMachine builds the menus and the prompts like the bot does, the actions record the calls.
+func testMachine(now func() time.Time, calls *[]string) *Machine {
+	record := func(name string) Action {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update, s *Session) {
+			call := name
+			if update.Message != nil && (s.State == stateSearch || s.State == stateNote) {
+				call += ":" + update.Message.Text
+			}
+			if ref := s.Get("ref"); ref != "" {
+				call += ":" + ref
+			}
+			*calls = append(*calls, call)
+		}
+	}
+
+	return New(NewMemoryStore(), State{ID: stateMain}, WithClock(now)).
+		AddState(
+			State{ID: stateMovies},
+			State{ID: stateSearch, Timeout: 5 * time.Minute, Transient: true},
+			State{ID: stateNote, Timeout: 5 * time.Minute, Transient: true},
+			State{ID: stateWizard, Timeout: time.Minute},
+		).
+		On(stateMain, Text("Фильмы"), stateMovies, record("menu movies")).
+		On(stateMain, Text("Мастер"), stateWizard, record("wizard")).
+		On(stateMovies, Text("Популярные"), Stay, record("popular")).
+		On(stateMovies, Text("Назад"), stateMain, record("menu main")).
+		On(AnyState, Text("Поиск"), stateSearch, record("ask search")).
+		On(AnyState, Command("/menu"), Back, record("menu")).
+		On(AnyState, Callback("nt_"), Stay, func(ctx context.Context, b *bot.Bot, update *models.Update, s *Session) {
+			ref := strings.TrimPrefix(update.CallbackQuery.Data, "nt_")
+			*calls = append(*calls, "ask note:"+ref)
+			s.Goto(stateNote, map[string]string{"ref": ref})
+		}).
+		On(stateSearch, AnyText(), Back, record("search")).
+		On(stateSearch, Callback("sr_cancel"), Back, record("cancel")).
+		On(stateNote, AnyText(), Back, func(ctx context.Context, b *bot.Bot, update *models.Update, s *Session) {
+			if update.Message.Text == "" {
+				// Stay in the state with the data on the invalid input.
+				record("invalid note")(ctx, b, update, s)
+				s.Goto(Stay, s.Data)
+				return
+			}
+			record("note")(ctx, b, update, s)
+		})
+}
+
+func Test_Machine(t *testing.T) {
+	type step struct {
+		update  *models.Update
+		after   time.Duration
+		handled bool
+		state   StateID
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		calls []string
+	}{
+		{
+			name: "menus",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: message("Популярные"), handled: true, state: stateMovies},
+				{update: message("Назад"), handled: true, state: stateMain},
+			},
+			calls: []string{"menu movies", "popular", "menu main"},
+		},
+		{
+			name: "button of another menu",
+			steps: []step{
+				{update: message("Популярные"), handled: false, state: stateMain},
+			},
+		},
+		{
+			name: "command",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: message("/menu@bot"), handled: true, state: stateMovies},
+				{update: message("/notes"), handled: false, state: stateMovies},
+			},
+			calls: []string{"menu movies", "menu"},
+		},
+		{
+			name: "input",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: message("Начало"), handled: true, state: stateMovies},
+				{update: message("Начало"), handled: false, state: stateMovies},
+			},
+			calls: []string{"menu movies", "ask search", "search:Начало"},
+		},
+		{
+			name: "input cancelled by callback",
+			steps: []step{
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: callback("sr_cancel"), handled: true, state: stateMain},
+			},
+			calls: []string{"ask search", "cancel"},
+		},
+		{
+			name: "input cancelled by command",
+			steps: []step{
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: message("/notes"), handled: false, state: stateMain},
+				{update: message("Начало"), handled: false, state: stateMain},
+			},
+			calls: []string{"ask search"},
+		},
+		{
+			name: "input cancelled by menu button of previous state",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: message("Популярные"), handled: true, state: stateMovies},
+			},
+			calls: []string{"menu movies", "ask search", "popular"},
+		},
+		{
+			name: "unrelated callback keeps input",
+			steps: []step{
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: callback("slider_next"), handled: false, state: stateSearch},
+				{update: message("Начало"), handled: true, state: stateMain},
+			},
+			calls: []string{"ask search", "search:Начало"},
+		},
+		{
+			name: "input timeout",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: message("Начало"), after: 6 * time.Minute, handled: false, state: stateMovies},
+			},
+			calls: []string{"menu movies", "ask search"},
+		},
+		{
+			name: "input within timeout",
+			steps: []step{
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: message("Начало"), after: 4 * time.Minute, handled: true, state: stateMain},
+			},
+			calls: []string{"ask search", "search:Начало"},
+		},
+		{
+			name: "regular state timeout",
+			steps: []step{
+				{update: message("Мастер"), handled: true, state: stateWizard},
+				{update: message("Фильмы"), after: 2 * time.Minute, handled: true, state: stateMovies},
+			},
+			calls: []string{"wizard", "menu movies"},
+		},
+		{
+			name: "callback with data",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: callback("nt_f42"), handled: true, state: stateNote},
+				{update: message(""), handled: true, state: stateNote},
+				{update: message("Посмотреть с друзьями"), handled: true, state: stateMovies},
+			},
+			calls: []string{"menu movies", "ask note:f42", "invalid note::f42", "note:Посмотреть с друзьями:f42"},
+		},
+		{
+			name: "transient from transient returns to regular",
+			steps: []step{
+				{update: message("Фильмы"), handled: true, state: stateMovies},
+				{update: message("Поиск"), handled: true, state: stateSearch},
+				{update: callback("nt_t7"), handled: true, state: stateNote},
+				{update: message("Досмотреть"), handled: true, state: stateMovies},
+			},
+			calls: []string{"menu movies", "ask search", "ask note:t7", "note:Досмотреть:t7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+			var calls []string
+			m := testMachine(func() time.Time { return now }, &calls)
+
+			for i, st := range tt.steps {
+				now = now.Add(st.after)
+
+				handled, err := m.Handle(context.Background(), nil, st.update)
+				require.NoError(t, err)
+				assert.Equal(t, st.handled, handled, "step %d", i)
+
+				s, err := m.Current(context.Background(), userID)
+				require.NoError(t, err)
+				assert.Equal(t, st.state, s.State, "step %d", i)
+			}
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func Test_MachineEnter(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	var calls []string
+	m := testMachine(func() time.Time { return now }, &calls)
+	ctx := context.Background()
+
+	require.NoError(t, m.Enter(ctx, userID, stateNote, map[string]string{"ref": "f1"}))
+	s, err := m.Current(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, Session{State: stateNote, Prev: stateMain, Data: map[string]string{"ref": "f1"}, ExpiresAt: now.Add(5 * time.Minute)}, s)
+
+	assert.Error(t, m.Enter(ctx, userID, "unknown", nil))
+
+	require.NoError(t, m.Reset(ctx, userID))
+	s, err = m.Current(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, Session{State: stateMain}, s)
+}
+
+func Test_MachineOnUnknownState(t *testing.T) {
+	m := New(NewMemoryStore(), State{ID: stateMain})
+
+	assert.Panics(t, func() { m.On("unknown", Text("a"), stateMain, nil) })
+	assert.Panics(t, func() { m.On(stateMain, Text("a"), "unknown", nil) })
+	assert.NotPanics(t, func() { m.On(AnyState, Text("a"), Back, nil) })
+}
+
+func Test_eventOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		update *models.Update
+		want   event
+		ok     bool
+	}{
+		{"text", message("Фильмы"), event{kind: eventText, value: "Фильмы"}, true},
+		{"empty text", message(""), event{kind: eventText}, true},
+		{"command", message("/search Начало"), event{kind: eventCommand, value: "/search"}, true},
+		{"command with bot name", message("/menu@whattowatch_bot"), event{kind: eventCommand, value: "/menu"}, true},
+		{"callback", callback("nt_f1"), event{kind: eventCallback, value: "nt_f1"}, true},
+		{"no sender", &models.Update{Message: &models.Message{Text: "a"}}, event{}, false},
+		{"other update", &models.Update{}, event{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ev, ok := eventOf(tt.update)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, ev)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Session is the conversation state of the user.
+type Session struct {
+	State StateID `json:"state"`
+	// Prev is the state the transient state was entered from.
+	Prev StateID           `json:"prev,omitempty"`
+	Data map[string]string `json:"data,omitempty"`
+	// ExpiresAt is the deadline of the state with the timeout.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	next *target
+}
+
+type target struct {
+	to   StateID
+	data map[string]string
+}
+
+// Goto moves the session to the state with the data after the action instead of the transition target.
+func (s *Session) Goto(state StateID, data map[string]string) {
+	s.next = &target{to: state, data: data}
+}
+
+// Base returns the regular state of the session: the state the transient state was entered from or the current one.
+func (s *Session) Base() StateID {
+	if s.Prev != "" {
+		return s.Prev
+	}
+	return s.State
+}
+
+// Set sets the session data value.
+func (s *Session) Set(key, value string) {
+	if s.Data == nil {
+		s.Data = make(map[string]string)
+	}
+	s.Data[key] = value
+}
+
+// Get returns the session data value.
+func (s *Session) Get(key string) string {
+	return s.Data[key]
+}
+
+// Store keeps the sessions of the users. Load returns a zero session for an unknown user.
+type Store interface {
+	Load(ctx context.Context, userID int64) (Session, error)
+	Save(ctx context.Context, userID int64, s Session) error
+	Delete(ctx context.Context, userID int64) error
+}
+
+// MemoryStore keeps the sessions in memory, they are lost on restart.
+type MemoryStore struct {
+	sessions map[int64]Session
+	mu       sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[int64]Session)}
+}
+
+func (ms *MemoryStore) Load(ctx context.Context, userID int64) (Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return ms.sessions[userID], nil
+}
+
+func (ms *MemoryStore) Save(ctx context.Context, userID int64, s Session) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s.next = nil
+	ms.sessions[userID] = s
+	return nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, userID int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.sessions, userID)
+	return nil
+}
+
+// SessionStorer keeps the encoded sessions, e.g. in the database. GetFSMSession returns nil for an unknown user.
+type SessionStorer interface {
+	GetFSMSession(ctx context.Context, userID int64) ([]byte, error)
+	SaveFSMSession(ctx context.Context, userID int64, data []byte, expiresAt sql.NullTime) error
+	DeleteFSMSession(ctx context.Context, userID int64) error
+}
+
+// StorerStore keeps the sessions encoded to JSON in the SessionStorer, so they survive the restarts.
+type StorerStore struct {
+	storer SessionStorer
+}
+
+func NewStorerStore(storer SessionStorer) *StorerStore {
+	return &StorerStore{storer: storer}
+}
+
+func (ss *StorerStore) Load(ctx context.Context, userID int64) (Session, error) {
+	data, err := ss.storer.GetFSMSession(ctx, userID)
+	if err != nil || data == nil {
+		return Session{}, err
+	}
+
+	var s Session
+	if err = json.Unmarshal(data, &s); err != nil {
+		return Session{}, fmt.Errorf("failed to decode session: %s", err.Error())
+	}
+	return s, nil
+}
+
+func (ss *StorerStore) Save(ctx context.Context, userID int64, s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %s", err.Error())
+	}
+	return ss.storer.SaveFSMSession(ctx, userID, data, sql.NullTime{Time: s.ExpiresAt, Valid: !s.ExpiresAt.IsZero()})
+}
+
+func (ss *StorerStore) Delete(ctx context.Context, userID int64) error {
+	return ss.storer.DeleteFSMSession(ctx, userID)
+}
@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSessionStorer keeps the encoded sessions like the fsm_sessions table.
+type fakeSessionStorer struct {
+	data      map[int64][]byte
+	expiresAt map[int64]sql.NullTime
+}
+
+func newFakeSessionStorer() *fakeSessionStorer {
+	return &fakeSessionStorer{data: make(map[int64][]byte), expiresAt: make(map[int64]sql.NullTime)}
+}
+
+func (f *fakeSessionStorer) GetFSMSession(ctx context.Context, userID int64) ([]byte, error) {
+	return f.data[userID], nil
+}
+
+func (f *fakeSessionStorer) SaveFSMSession(ctx context.Context, userID int64, data []byte, expiresAt sql.NullTime) error {
+	f.data[userID] = data
+	f.expiresAt[userID] = expiresAt
+	return nil
+}
+
+func (f *fakeSessionStorer) DeleteFSMSession(ctx context.Context, userID int64) error {
+	delete(f.data, userID)
+	delete(f.expiresAt, userID)
+	return nil
+}
+
+func Test_StorerStore(t *testing.T) {
+	ctx := context.Background()
+	storer := newFakeSessionStorer()
+	store := NewStorerStore(storer)
+
+	s, err := store.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, Session{}, s)
+
+	expiresAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	saved := Session{State: stateNote, Prev: stateMovies, Data: map[string]string{"ref": "m42"}, ExpiresAt: expiresAt}
+	saved.Goto(stateMain, nil)
+	require.NoError(t, store.Save(ctx, userID, saved))
+	assert.Equal(t, sql.NullTime{Time: expiresAt, Valid: true}, storer.expiresAt[userID])
+
+	s, err = store.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, stateNote, s.State)
+	assert.Equal(t, stateMovies, s.Prev)
+	assert.Equal(t, "m42", s.Get("ref"))
+	assert.True(t, expiresAt.Equal(s.ExpiresAt))
+	assert.Nil(t, s.next)
+
+	require.NoError(t, store.Save(ctx, userID, Session{State: stateMain}))
+	assert.False(t, storer.expiresAt[userID].Valid)
+
+	require.NoError(t, store.Delete(ctx, userID))
+	s, err = store.Load(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, Session{}, s)
+}
+
+func Test_StorerStoreMachine(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var calls []string
+
+	// The machine restarted with the same storer picks up the state where the previous one left it.
+	storer := newFakeSessionStorer()
+	m := testMachine(func() time.Time { return now }, &calls)
+	m.store = NewStorerStore(storer)
+
+	handled, err := m.Handle(ctx, nil, message("Фильмы"))
+	require.NoError(t, err)
+	assert.True(t, handled)
+
+	restarted := testMachine(func() time.Time { return now }, &calls)
+	restarted.store = NewStorerStore(storer)
+
+	s, err := restarted.Current(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, stateMovies, s.State)
+
+	require.NoError(t, restarted.Reset(ctx, userID))
+	assert.Empty(t, storer.data)
+}
@@ -3,15 +3,13 @@ package botkit
 import (
 	"context"
 	"database/sql"
+	"reflect"
 	"strconv"
-	"strings"
 	"time"
 	"whattowatch/internal/types"
-	"whattowatch/internal/utils"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
-	"github.com/go-telegram/ui/keyboard/inline"
 	"github.com/go-telegram/ui/slider"
 )
 
@@ -21,16 +19,9 @@ type showContentByGenreFunc func(ctx context.Context, chatID int64, userData Use
 func (t *TGBot) registerHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	log := t.log.With("fn", "registerHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
 	log.Debug("handler func start log")
-	err := t.storer.InsertUser(ctx, types.User{
-		ID:           update.Message.From.ID,
-		FirstName:    update.Message.From.FirstName,
-		LastName:     update.Message.From.LastName,
-		Username:     update.Message.From.Username,
-		LanguageCode: update.Message.From.LanguageCode,
-		CreatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
-	})
+	err := t.insertUser(ctx, update.Message.From)
 	if err != nil {
-		log.Error("failed to insert user", "error", err.Error())
+		log.ErrorContext(ctx, "failed to insert user", "error", err.Error())
 		t.sendErrorMessage(ctx, update.Message.Chat.ID)
 		return
 	}
@@ -40,158 +31,216 @@ func (t *TGBot) registerHandler(ctx context.Context, b *bot.Bot, update *models.
 		Text:   "Регистрация прошла успешно",
 	})
 	if err != nil {
-		log.Error("failed to send message", "error", err.Error())
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
 		t.sendErrorMessage(ctx, update.Message.Chat.ID)
 	}
 	log.Info("user registered")
 }
 
+func (t *TGBot) insertUser(ctx context.Context, user *models.User) error {
+	return t.storer.InsertUser(ctx, types.User{
+		ID:           user.ID,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Username:     user.Username,
+		LanguageCode: user.LanguageCode,
+		CreatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+}
+
 func (t *TGBot) helpHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	log := t.log.With("fn", "helpHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
 	log.Debug("handler func start log")
 
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   "/start - Регистрация\n/menu - Открыть меню\n/search - Поиск по названию. Пример: /search Начало\n/help - Помощь",
+		Text:   "/start - Регистрация\n/menu - Открыть меню\n/search - Поиск по названию. Пример: /search Начало. Можно просто отправить название\n/notes - Мои заметки\n/lists - Мои списки\n/app - Коллекции в приложении\n/token - Токен для REST API\n/history - История просмотров\n/mystats - Моя статистика\n/year - Итоги года картинкой\n/settings - Настройки\n/forget - Удалить аккаунт и все данные\n/help - Помощь",
 	})
 	if err != nil {
-		log.Error("failed to send message", "error", err.Error())
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
 		t.sendErrorMessage(ctx, update.Message.Chat.ID)
 	}
 }
 
-func (t *TGBot) searchByTitleHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	chatID := update.Message.Chat.ID
-
-	log := t.log.With("fn", "searchByTitleHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+func (t *TGBot) searchByIDHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log := t.log.With("fn", "searchByIDHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
 	log.Debug("handler func start log")
 
-	titlesStr := strings.Trim(update.Message.Text, "/search ")
-	if titlesStr == "" {
-		t.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatID,
-			Text:   "Введите название фильма после команды /search.\nПример: /search Начало",
-		})
+	contentType, err := types.ParseContentTypeSign(update.Message.Text[1:2])
+	if err != nil {
+		log.ErrorContext(ctx, "failed to parse content type", "error", err.Error())
+		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		return
 	}
-	titles := make([]string, 0)
-	for _, title := range strings.Split(titlesStr, ",") {
-		titles = append(titles, strings.TrimSpace(title))
+
+	strID := update.Message.Text[2:]
+	id, err := strconv.Atoi(strID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to parse id", "error", err.Error())
+		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		return
 	}
 
-	res, err := t.api.SearchByTitles(ctx, titles)
+	contentItem, err := t.getContentItem(ctx, contentType, id)
 	if err != nil {
-		log.Error("failed to get movies", "error", err.Error())
-		t.sendErrorMessage(ctx, chatID)
+		log.ErrorContext(ctx, "failed to get content item", "error", err.Error())
+		t.sendErrorMessage(ctx, update.Message.Chat.ID)
 		return
 	}
 
-	if len(res) == 0 {
-		t.bot.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatID,
-			Text:   "Ничего не найдено",
-		})
+	cs, err := t.storer.GetContentStatus(ctx, update.Message.From.ID, contentItem)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content status", "error", err.Error())
+		t.sendErrorMessage(ctx, update.Message.Chat.ID)
 		return
 	}
 
-	slides := t.generateSlider(res, nil)
-	_, err = slides.Show(ctx, t.bot, chatID)
+	err = t.sendContentItem(ctx, update.Message.Chat.ID, contentItem, cs)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
-		t.sendErrorMessage(ctx, chatID)
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, update.Message.Chat.ID)
 		return
 	}
+	t.events.Record(types.NewContentEvent(update.Message.From.ID, types.EventCardOpen, contentItem))
 }
 
-func (t *TGBot) searchByIDHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	log := t.log.With("fn", "searchByIDHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
+// onContentActionEvent handles the content card buttons. The card is edited in place,
+// a new card is sent only if the old one can't be edited.
+func (t *TGBot) onContentActionEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	userID := query.From.ID
+	chatID := userID
+	if query.Message.Message != nil {
+		chatID = query.Message.Message.Chat.ID
+	}
+
+	log := t.log.With("fn", "onContentActionEvent", "user_id", userID, "chat_id", chatID, "data", query.Data)
 	log.Debug("handler func start log")
 
-	contentType := update.Message.Text[:2]
-	strID := update.Message.Text[2:]
-	id, err := strconv.Atoi(strID)
+	action, contentType, id, err := parseContentActionData(query.Data)
 	if err != nil {
-		log.Error("failed to parse id", "error", err.Error())
-		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		log.ErrorContext(ctx, "failed to parse callback data", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
 		return
 	}
 
-	var contentItem types.ContentItem
-	switch contentType {
-	case "/f":
-		contentItem, err = t.api.GetMovie(ctx, id)
-	case "/t":
-		contentItem, err = t.api.GetTV(ctx, id)
-	}
+	item, err := t.getContentItem(ctx, contentType, id)
 	if err != nil {
-		log.Error("failed to get content item", "error", err.Error())
-		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		log.ErrorContext(ctx, "failed to get content item", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
 		return
 	}
 
-	cs, err := t.storer.GetContentStatus(ctx, update.Message.From.ID, contentItem)
+	if action == WatchedOnDate {
+		t.answerCallbackQuery(ctx, query.ID, "")
+		t.askWatchDate(ctx, chatID, userID, item)
+		return
+	}
+
+	if action == RateAsk || action == RateCancel {
+		t.answerCallbackQuery(ctx, query.ID, "")
+		t.toggleRatingKeyboard(ctx, b, query.Message.Message, userID, item, action == RateAsk)
+		return
+	}
+
+	fn, toast, err := t.getContentActionFunc(action)
 	if err != nil {
-		log.Error("failed to get content status", "error", err.Error())
-		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		log.ErrorContext(ctx, "failed to get content action", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
 		return
 	}
 
-	serializedItem := types.SerializeContentItem(contentItem)
-	kb := t.getContentActionKeyboard(cs, serializedItem)
+	prevStatus, err := t.storer.GetContentStatus(ctx, userID, item)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content status", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
+		return
+	}
 
-	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
-		ChatID:      update.Message.Chat.ID,
-		Photo:       &models.InputFileString{Data: contentItem.BackdropPath},
-		Caption:     contentItem.GetInfo(),
-		ParseMode:   "Markdown",
-		ReplyMarkup: kb,
-	})
+	err = fn(ctx, userID, item)
 	if err != nil {
-		log.Error("failed to send message", "error", err.Error())
-		t.sendErrorMessage(ctx, update.Message.Chat.ID)
+		log.ErrorContext(ctx, "failed to modify content", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
+		return
+	}
+	if eventType, ok := contentActionEvents[action]; ok {
+		t.events.Record(types.NewContentEvent(userID, eventType, item))
 	}
-}
 
-func (t *TGBot) onContentActionEvent(fn modifyUserContentFunc) inline.OnSelect {
-	return func(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage, data []byte) {
-		chatID := mes.Message.Chat.ID
+	cs, err := t.storer.GetContentStatus(ctx, userID, item)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content status", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
+		return
+	}
 
-		log := t.log.With("fn", "onContentActionEvent", "chat_id", chatID)
+	t.answerCallbackQuery(ctx, query.ID, toast)
 
-		item, err := types.UnserializeContentItem(data)
-		if err != nil {
-			log.Error("failed to unserialize item", "error", err.Error())
-			t.sendErrorMessage(ctx, mes.Message.Chat.ID)
-			return
+	if query.Message.Message != nil {
+		keyboard := t.getContentActionKeyboard(item, cs)
+		prevKeyboard := t.getContentActionKeyboard(item, prevStatus)
+		if _, ok := parseRateAction(action); ok {
+			// The rating is picked on the rating keyboard, the card one is always restored.
+			prevKeyboard = getRatingKeyboard(item)
 		}
-
-		err = fn(ctx, chatID, item)
-		if err != nil {
-			log.Error("failed to modify content", "error", err.Error())
-			t.sendErrorMessage(ctx, mes.Message.Chat.ID)
+		switch {
+		case prevStatus.GetInfo() != cs.GetInfo():
+			_, err = b.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+				ChatID:      chatID,
+				MessageID:   query.Message.Message.ID,
+				Caption:     contentItemCaption(item, cs),
+				ParseMode:   models.ParseModeMarkdown,
+				ReplyMarkup: keyboard,
+			})
+		case !reflect.DeepEqual(prevKeyboard, keyboard):
+			_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+				ChatID:      chatID,
+				MessageID:   query.Message.Message.ID,
+				ReplyMarkup: keyboard,
+			})
+		default:
+			// Nothing changed, e.g. the same date set twice, and Telegram rejects an edit that leaves the card as is.
 			return
 		}
-
-		cs, err := t.storer.GetContentStatus(ctx, chatID, item)
-		if err != nil {
-			log.Error("failed to get content status", "error", err.Error())
-			t.sendErrorMessage(ctx, mes.Message.Chat.ID)
+		if err == nil {
 			return
 		}
+		log.WarnContext(ctx, "failed to edit message, sending a new one", "error", err.Error())
+	}
 
-		kb := t.getContentActionKeyboard(cs, data)
+	err = t.sendContentItem(ctx, chatID, item, cs)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
 
-		_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
-			ChatID:      mes.Message.Chat.ID,
-			Photo:       &models.InputFileString{Data: item.BackdropPath},
-			Caption:     item.GetInfo(),
-			ParseMode:   "Markdown",
-			ReplyMarkup: kb,
-		})
+// toggleRatingKeyboard swaps the content card keyboard for the rating one and back.
+func (t *TGBot) toggleRatingKeyboard(ctx context.Context, b *bot.Bot, msg *models.Message, userID int64, item types.ContentItem, show bool) {
+	log := t.log.With("fn", "toggleRatingKeyboard", "user_id", userID)
+	if msg == nil {
+		log.WarnContext(ctx, "content card message is inaccessible")
+		return
+	}
+
+	keyboard := getRatingKeyboard(item)
+	if !show {
+		cs, err := t.storer.GetContentStatus(ctx, userID, item)
 		if err != nil {
-			log.Error("failed to send message", "error", err.Error())
-			t.sendErrorMessage(ctx, mes.Message.Chat.ID)
+			log.ErrorContext(ctx, "failed to get content status", "error", err.Error())
+			t.sendErrorMessage(ctx, msg.Chat.ID)
+			return
 		}
+		keyboard = t.getContentActionKeyboard(item, cs)
+	}
 
+	_, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to edit message", "error", err.Error())
 	}
 }
 
@@ -206,26 +255,17 @@ func (t *TGBot) onContentByGenreHandler(fn showContentByGenreFunc, page Page) bo
 		genreIDStr := update.Message.Text[3:]
 		genreID, err := strconv.Atoi(genreIDStr)
 		if err != nil {
-			log.Error("failed to parse genre id", "error", err.Error())
+			log.ErrorContext(ctx, "failed to parse genre id", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
 
-		t.mu.RLock()
-		userData, exists := t.userData[userID]
-		t.mu.RUnlock()
-
+		userData, exists := t.turnPage(userID, page, false)
 		if !exists {
 			log.Debug("user not found in userData map")
 			return
 		}
 
-		userData.pagesMap[page] = 1
-
-		t.mu.Lock()
-		t.userData[userID] = userData
-		t.mu.Unlock()
-
 		fn(ctx, chatID, userData, genreID)
 	}
 }
@@ -237,21 +277,12 @@ func (t *TGBot) onContentGenrePageHandler(fn showContentByGenreFunc, page Page,
 		log := t.log.With("fn", "onContentGenrePageHandler", "chat_id", chatID)
 		log.Debug("handler func start log")
 
-		t.mu.RLock()
-		userData, exists := t.userData[chatID]
-		t.mu.RUnlock()
-
+		userData, exists := t.turnPage(chatID, page, true)
 		if !exists {
 			log.Debug("user not found in userData map")
 			return
 		}
 
-		userData.pagesMap[page] = utils.HandlePage(userData.pagesMap[page], "next")
-
-		t.mu.Lock()
-		t.userData[chatID] = userData
-		t.mu.Unlock()
-
 		fn(ctx, chatID, userData, genreID)
 	}
 }
@@ -262,23 +293,33 @@ func (t *TGBot) showMovieByGenre(ctx context.Context, chatID int64, userData Use
 
 	movies, err := t.api.GetMoviesByGenre(ctx, []int{genreID}, userData.pagesMap[MovieByGenre])
 	if err != nil {
-		log.Error("failed to get content", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get content", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
-	if len(movies) == 0 {
+	movies, err = t.applyUserSettings(ctx, chatID, types.Movie, movies, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
+	if len(movies) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
 	opts := []slider.Option{
 		slider.OnCancel("Показать еще", true, t.onContentGenrePageHandler(t.showMovieByGenre, MovieByGenre, genreID)),
 	}
-	slides := t.generateSlider(movies, opts)
+	slides := t.generateSlider(ctx, movies, opts)
 	_, err = slides.Show(ctx, t.bot, chatID)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
@@ -290,23 +331,33 @@ func (t *TGBot) showTVByGenre(ctx context.Context, chatID int64, userData UserDa
 
 	movies, err := t.api.GetTVsByGenre(ctx, []int{genreID}, userData.pagesMap[TVByGenre])
 	if err != nil {
-		log.Error("failed to get content", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get content", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
-	if len(movies) == 0 {
+	movies, err = t.applyUserSettings(ctx, chatID, types.TV, movies, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
+	if len(movies) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
 	opts := []slider.Option{
 		slider.OnCancel("Показать еще", true, t.onContentGenrePageHandler(t.showTVByGenre, TVByGenre, genreID)),
 	}
-	slides := t.generateSlider(movies, opts)
+	slides := t.generateSlider(ctx, movies, opts)
 	_, err = slides.Show(ctx, t.bot, chatID)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
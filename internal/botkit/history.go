@@ -0,0 +1,145 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"whattowatch/internal/botkit/fsm"
+	"whattowatch/internal/types"
+	"whattowatch/internal/utils"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// historyPrefix is a callback data prefix of the history "Показать еще" button, e.g. "hs_30".
+	historyPrefix   = "hs_"
+	historyPageSize = 30
+
+	watchDateLayout = "02.01.2006"
+)
+
+func (t *TGBot) historyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log := t.log.With("fn", "historyHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
+	log.Debug("handler func start log")
+
+	t.showHistory(ctx, update.Message.Chat.ID, update.Message.From.ID, 0)
+}
+
+func (t *TGBot) onHistoryPageEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+
+	log := t.log.With("fn", "onHistoryPageEvent", "user_id", query.From.ID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(query.Data, historyPrefix))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to parse offset", "error", err.Error())
+		t.sendErrorMessage(ctx, query.From.ID)
+		return
+	}
+
+	t.showHistory(ctx, query.From.ID, query.From.ID, offset)
+}
+
+// showHistory shows the page of the user watch log grouped by month.
+func (t *TGBot) showHistory(ctx context.Context, chatID int64, userID int64, offset int) {
+	log := t.log.With("fn", "showHistory", "chat_id", chatID, "offset", offset)
+
+	// One more entry is requested to know if there is the next page.
+	history, err := t.storer.GetWatchHistory(ctx, userID, historyPageSize+1, offset)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get watch history", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(history) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "История просмотров пуста. Отмечайте просмотренное кнопкой в карточке фильма или сериала",
+		})
+		return
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    chatID,
+		ParseMode: models.ParseModeMarkdown,
+	}
+	if len(history) > historyPageSize {
+		history = history[:historyPageSize]
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: "Показать еще", CallbackData: fmt.Sprintf("%s%d", historyPrefix, offset+historyPageSize)}},
+			},
+		}
+	}
+	params.Text = history.GetInfo()
+
+	_, err = t.bot.SendMessage(ctx, params)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
+
+// askWatchDate waits for the user to send the watch date of the item.
+func (t *TGBot) askWatchDate(ctx context.Context, chatID int64, userID int64, item types.ContentItem) {
+	t.enterState(ctx, userID, stateWatchDate, map[string]string{"ref": contentRef(item)})
+	t.sendWatchDatePrompt(ctx, chatID, item)
+}
+
+func (t *TGBot) sendWatchDatePrompt(ctx context.Context, chatID int64, item types.ContentItem) {
+	_, err := t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: fmt.Sprintf("Отправьте дату просмотра «%s» в формате ДД.ММ.ГГГГ, например %s. Для отмены выполните любую команду, например /menu",
+			item.Title, time.Now().Format(watchDateLayout)),
+	})
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to send message", "fn", "sendWatchDatePrompt", "error", err.Error())
+	}
+}
+
+// watchDateInputHandler sets the user message as the latest watch date of the item of the session.
+func (t *TGBot) watchDateInputHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "watchDateInputHandler", "user_id", userID, "chat_id", chatID, "ref", s.Get("ref"))
+	log.Debug("handler func start log")
+
+	item, err := t.getContentItemByRef(ctx, s.Get("ref"))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content item", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	watchedAt, err := time.ParseInLocation(watchDateLayout, strings.TrimSpace(update.Message.Text), time.Local)
+	if err != nil || watchedAt.After(time.Now()) {
+		s.Goto(fsm.Stay, s.Data)
+		t.sendWatchDatePrompt(ctx, chatID, item)
+		return
+	}
+
+	err = t.storer.SetLastWatchDate(ctx, userID, item, watchedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to set watch date", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      fmt.Sprintf("Дата просмотра «%s» изменена на %s /%s\nИстория: /history", utils.EscapeMarkdown(item.Title), watchedAt.Format(watchDateLayout), contentRef(item)),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+}
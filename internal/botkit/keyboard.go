@@ -1,82 +1,167 @@
 package botkit
 
 import (
+	"fmt"
+	"strconv"
+	"whattowatch/internal/botkit/fsm"
 	"whattowatch/internal/types"
 
 	"github.com/go-telegram/bot"
-	"github.com/go-telegram/ui/keyboard/inline"
-	"github.com/go-telegram/ui/keyboard/reply"
+	"github.com/go-telegram/bot/models"
 )
 
-type keyboardFunc func() *reply.ReplyKeyboard
-
-func (t *TGBot) getMainKeyboard() *reply.ReplyKeyboard {
-	rk := reply.New(
-		t.bot,
-		reply.WithPrefix("rk_main"),
-		reply.IsSelective(),
-		reply.ResizableKeyboard(),
-	).
-		Button("Фильмы 🎥", t.bot, bot.MatchTypeExact, t.onKeyboardChangeEvent("Фильмы. Выберите раздел", t.getMoviesKeyboard)).
-		Row().
-		Button("Сериалы 📺", t.bot, bot.MatchTypeExact, t.onKeyboardChangeEvent("Сериалы. Выберите раздел", t.getTVsKeyboard))
+// menuButton is a reply keyboard button. It opens the menu of the state, runs the handler in the current menu
+// or runs the action starting a conversation, e.g. a prompt.
+type menuButton struct {
+	text    string
+	state   fsm.StateID
+	handler bot.HandlerFunc
+	action  fsm.Action
+}
 
-	return rk
+// menu is a reply keyboard shown in the state.
+type menu struct {
+	state fsm.StateID
+	title string
+	rows  [][]menuButton
 }
 
-func (t *TGBot) getMoviesKeyboard() *reply.ReplyKeyboard {
-	rk := reply.New(
-		t.bot,
-		reply.WithPrefix("rk_movies"),
-		reply.IsSelective(),
-		reply.ResizableKeyboard(),
-	).
-		Button("Популярные 🎥", t.bot, bot.MatchTypeExact, t.onContentEvent(t.showMoviePopular, MoviePopular)).
-		Button("Лучшие 🎥", t.bot, bot.MatchTypeExact, t.onContentEvent(t.showMovieTop, MovieTop)).
-		Button("Жанры 🎥", t.bot, bot.MatchTypePrefix, t.onGetGenresEvent(types.Movie)).
-		Row().
-		Button("Рекомендации 🎥", t.bot, bot.MatchTypeExact, t.onRecommendationsEvent(t.api.GetRecommendations, types.Movie)).
-		Button("Избранные 🎥", t.bot, bot.MatchTypeExact, t.onUserContentEvent(t.storer.GetFavoriteContentIDs, t.api.GetContent, types.Movie, "У вас нет избранных фильмов")).
-		Button("Просмотренные 🎥", t.bot, bot.MatchTypeExact, t.onUserContentEvent(t.storer.GetViewedContentIDs, t.api.GetContent, types.Movie, "У вас нет просмотренных фильмов")).
-		Row().
-		Button("🔙 Назад", t.bot, bot.MatchTypePrefix, t.onKeyboardChangeEvent("Выберите тип контента", t.getMainKeyboard))
+func (t *TGBot) getMenus() []menu {
+	search := menuButton{text: "🔍 Поиск", action: t.askSearchQuery}
+	back := menuButton{text: "🔙 Назад", state: stateMain}
 
-	return rk
+	return []menu{
+		{
+			state: stateMain,
+			title: "Выберите тип контента",
+			rows: [][]menuButton{
+				{{text: "Фильмы 🎥", state: stateMovies}},
+				{{text: "Сериалы 📺", state: stateTVs}},
+				{search, {text: "История 🕘", handler: t.historyHandler}},
+			},
+		},
+		{
+			state: stateMovies,
+			title: "Фильмы. Выберите раздел",
+			rows: [][]menuButton{
+				{
+					{text: "Популярные 🎥", handler: t.onContentEvent(t.showMoviePopular, MoviePopular)},
+					{text: "Лучшие 🎥", handler: t.onContentEvent(t.showMovieTop, MovieTop)},
+					{text: "Жанры 🎥", handler: t.onGetGenresEvent(types.Movie)},
+				},
+				{
+					{text: "В тренде 🎥", handler: t.onContentEvent(t.showMovieTrending, MovieTrending)},
+					{text: "Сейчас в кино 🎥", handler: t.onContentEvent(t.showMovieNowPlaying, MovieNowPlaying)},
+					{text: "Скоро 🎥", handler: t.onContentEvent(t.showMovieUpcoming, MovieUpcoming)},
+				},
+				{
+					{text: "Рекомендации 🎥", handler: t.onRecommendationsEvent(t.api.GetRecommendations, types.Movie)},
+					{text: "Избранные 🎥", handler: t.onUserContentEvent(t.storer.GetFavoriteContentIDs, t.api.GetContent, types.Movie, "У вас нет избранных фильмов")},
+					{text: "Просмотренные 🎥", handler: t.onUserContentEvent(t.storer.GetViewedContentIDs, t.api.GetContent, types.Movie, "У вас нет просмотренных фильмов")},
+				},
+				{{text: "Популярно у пользователей бота 🎥", handler: t.onCommunityFeedEvent(types.Movie)}},
+				{search, back},
+			},
+		},
+		{
+			state: stateTVs,
+			title: "Сериалы. Выберите раздел",
+			rows: [][]menuButton{
+				{
+					{text: "Популярные 📺", handler: t.onContentEvent(t.showTVPopular, TVPopular)},
+					{text: "Лучшие 📺", handler: t.onContentEvent(t.showTVTop, TVTop)},
+					{text: "Жанры 📺", handler: t.onGetGenresEvent(types.TV)},
+				},
+				{
+					{text: "В тренде 📺", handler: t.onContentEvent(t.showTVTrending, TVTrending)},
+					{text: "Сегодня в эфире 📺", handler: t.onContentEvent(t.showTVAiringToday, TVAiringToday)},
+					{text: "На этой неделе 📺", handler: t.onContentEvent(t.showTVOnTheAir, TVOnTheAir)},
+				},
+				{
+					{text: "Рекомендации 📺", handler: t.onRecommendationsEvent(t.api.GetRecommendations, types.TV)},
+					{text: "Избранные 📺", handler: t.onUserContentEvent(t.storer.GetFavoriteContentIDs, t.api.GetContent, types.TV, "У вас нет избранных сериалов")},
+					{text: "Просмотренные 📺", handler: t.onUserContentEvent(t.storer.GetViewedContentIDs, t.api.GetContent, types.TV, "У вас нет просмотренных сериалов")},
+				},
+				{{text: "Популярно у пользователей бота 📺", handler: t.onCommunityFeedEvent(types.TV)}},
+				{search, back},
+			},
+		},
+	}
 }
 
-func (t *TGBot) getTVsKeyboard() *reply.ReplyKeyboard {
-	rk := reply.New(
-		t.bot,
-		reply.WithPrefix("rk_tvs"),
-		reply.IsSelective(),
-		reply.ResizableKeyboard(),
-	).
-		Button("Популярные 📺", t.bot, bot.MatchTypeExact, t.onContentEvent(t.showTVPopular, TVPopular)).
-		Button("Лучшие 📺", t.bot, bot.MatchTypeExact, t.onContentEvent(t.showTVTop, TVTop)).
-		Button("Жанры 📺", t.bot, bot.MatchTypePrefix, t.onGetGenresEvent(types.TV)).
-		Row().
-		Button("Рекомендации 📺", t.bot, bot.MatchTypeExact, t.onRecommendationsEvent(t.api.GetRecommendations, types.TV)).
-		Button("Избранные 📺", t.bot, bot.MatchTypeExact, t.onUserContentEvent(t.storer.GetFavoriteContentIDs, t.api.GetContent, types.TV, "У вас нет избранных сериалов")).
-		Button("Просмотренные 📺", t.bot, bot.MatchTypeExact, t.onUserContentEvent(t.storer.GetViewedContentIDs, t.api.GetContent, types.TV, "У вас нет просмотренных сериалов")).
-		Row().
-		Button("🔙 Назад", t.bot, bot.MatchTypePrefix, t.onKeyboardChangeEvent("Выберите тип контента", t.getMainKeyboard))
+func (m menu) markup() *models.ReplyKeyboardMarkup {
+	keyboard := make([][]models.KeyboardButton, 0, len(m.rows))
+	for _, row := range m.rows {
+		buttons := make([]models.KeyboardButton, 0, len(row))
+		for _, button := range row {
+			buttons = append(buttons, models.KeyboardButton{Text: button.text})
+		}
+		keyboard = append(keyboard, buttons)
+	}
 
-	return rk
+	return &models.ReplyKeyboardMarkup{
+		Keyboard:       keyboard,
+		ResizeKeyboard: true,
+		Selective:      true,
+	}
 }
 
-func (t *TGBot) getContentActionKeyboard(contentStatus types.ContentStatus, data []byte) *inline.Keyboard {
-	kb := inline.New(t.bot).Row()
+func (t *TGBot) getContentActionKeyboard(item types.ContentItem, contentStatus types.ContentStatus) *models.InlineKeyboardMarkup {
+	favorite := models.InlineKeyboardButton{Text: "Добавить в избранные", CallbackData: contentActionData(AddToFavorite, item)}
 	if contentStatus.IsFavorite {
-		kb = kb.Button("Удалить из избранных", data, t.onContentActionEvent(t.storer.RemoveContentItemFromFavorite))
-	} else {
-		kb = kb.Button("Добавить в избранные", data, t.onContentActionEvent(t.storer.AddContentItemToFavorite))
+		favorite = models.InlineKeyboardButton{Text: "Удалить из избранных", CallbackData: contentActionData(RemoveFromFavorite, item)}
+	}
+
+	viewed := models.InlineKeyboardButton{Text: "Добавить в просмотренные", CallbackData: contentActionData(AddToViewed, item)}
+	if contentStatus.IsViewed {
+		viewed = models.InlineKeyboardButton{Text: "Удалить из просмотренных", CallbackData: contentActionData(RemoveFromViewed, item)}
 	}
 
+	kb := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{favorite, viewed},
+		},
+	}
+
+	row := make([]models.InlineKeyboardButton, 0, 4)
 	if contentStatus.IsViewed {
-		kb = kb.Button("Удалить из просмотренных", data, t.onContentActionEvent(t.storer.RemoveContentItemFromViewed))
-	} else {
-		kb = kb.Button("Добавить в просмотренные", data, t.onContentActionEvent(t.storer.AddContentItemToViewed))
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: "Смотрел вчера", CallbackData: contentActionData(WatchedYesterday, item)},
+			{Text: "На прошлой неделе", CallbackData: contentActionData(WatchedLastWeek, item)},
+			{Text: "Дата…", CallbackData: contentActionData(WatchedOnDate, item)},
+		})
+		row = append(row,
+			models.InlineKeyboardButton{Text: "🔁 Пересмотрел", CallbackData: contentActionData(Rewatch, item)},
+			models.InlineKeyboardButton{Text: "🏅 Оценить", CallbackData: contentActionData(RateAsk, item)},
+		)
+	}
+	row = append(row,
+		models.InlineKeyboardButton{Text: "📝 Заметка", CallbackData: notePrefix + contentRef(item)},
+		models.InlineKeyboardButton{Text: "В список…", CallbackData: listPickData(item)},
+	)
+	kb.InlineKeyboard = append(kb.InlineKeyboard, row)
+
+	if item.CollectionID != 0 {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+			{Text: "Вся франшиза", CallbackData: fmt.Sprintf("%s%d", collectionPrefix, item.CollectionID)},
+		})
 	}
 
 	return kb
 }
+
+// getRatingKeyboard replaces the content card keyboard while the user picks a rating.
+func getRatingKeyboard(item types.ContentItem) *models.InlineKeyboardMarkup {
+	kb := &models.InlineKeyboardMarkup{InlineKeyboard: make([][]models.InlineKeyboardButton, 0, 3)}
+	for from := 1; from <= 10; from += 5 {
+		row := make([]models.InlineKeyboardButton, 0, 5)
+		for rating := from; rating < from+5; rating++ {
+			row = append(row, models.InlineKeyboardButton{Text: strconv.Itoa(rating), CallbackData: contentActionData(rateAction(rating), item)})
+		}
+		kb.InlineKeyboard = append(kb.InlineKeyboard, row)
+	}
+	kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{
+		{Text: "Отмена", CallbackData: contentActionData(RateCancel, item)},
+	})
+	return kb
+}
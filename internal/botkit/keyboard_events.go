@@ -2,9 +2,12 @@ package botkit
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sort"
+	"strings"
+	"unicode/utf8"
 	"whattowatch/internal/types"
-	"whattowatch/internal/utils"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -15,50 +18,6 @@ type showContentDataFunc func(ctx context.Context, chatID int64, userData UserDa
 type getUserContentIDsFunc func(ctx context.Context, userID int64, contentType types.ContentType) ([]int64, error)
 type getContentByIDsFunc func(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error)
 
-func (t *TGBot) handlerReplyKeyboard(ctx context.Context, b *bot.Bot, update *models.Update) {
-	t.mu.RLock()
-	entry, ok := t.userData[update.Message.From.ID]
-	t.mu.RUnlock()
-
-	if ok {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      update.Message.Chat.ID,
-			Text:        "Выберите тип контента",
-			ReplyMarkup: entry.replyKeyboard,
-		})
-	}
-}
-
-func (t *TGBot) onKeyboardChangeEvent(msg string, keyboardFn keyboardFunc) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
-		userID := update.Message.From.ID
-		chatID := update.Message.Chat.ID
-
-		log := t.log.With("fn", "onKeyboardChangeEvent", "user_id", userID, "chat_id", chatID)
-		log.Debug("handler func start log")
-
-		t.mu.RLock()
-		userData, exists := t.userData[userID]
-		t.mu.RUnlock()
-		if !exists {
-			log.Debug("user not found in userData map")
-			return
-		}
-
-		userData.replyKeyboard = keyboardFn()
-
-		t.mu.Lock()
-		t.userData[userID] = userData
-		t.mu.Unlock()
-
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:      chatID,
-			Text:        msg,
-			ReplyMarkup: userData.replyKeyboard,
-		})
-	}
-}
-
 func (t *TGBot) onContentEvent(fn showContentDataFunc, page Page) bot.HandlerFunc {
 	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
 		userID := update.Message.From.ID
@@ -67,21 +26,12 @@ func (t *TGBot) onContentEvent(fn showContentDataFunc, page Page) bot.HandlerFun
 		log := t.log.With("fn", "onContentEvent", "user_id", userID, "chat_id", chatID)
 		log.Debug("handler func start log")
 
-		t.mu.RLock()
-		userData, exists := t.userData[userID]
-		t.mu.RUnlock()
-
+		userData, exists := t.turnPage(userID, page, false)
 		if !exists {
 			log.Debug("user not found in userData map")
 			return
 		}
 
-		userData.pagesMap[page] = 1
-
-		t.mu.Lock()
-		t.userData[userID] = userData
-		t.mu.Unlock()
-
 		fn(ctx, chatID, userData)
 	}
 }
@@ -93,21 +43,12 @@ func (t *TGBot) onContentPageEvent(fn showContentDataFunc, page Page) slider.OnC
 		log := t.log.With("fn", "onContentPageEvent", "chat_id", chatID)
 		log.Debug("handler func start log")
 
-		t.mu.RLock()
-		userData, exists := t.userData[chatID]
-		t.mu.RUnlock()
-
+		userData, exists := t.turnPage(chatID, page, true)
 		if !exists {
 			log.Debug("user not found in userData map")
 			return
 		}
 
-		userData.pagesMap[page] = utils.HandlePage(userData.pagesMap[page], "next")
-
-		t.mu.Lock()
-		t.userData[chatID] = userData
-		t.mu.Unlock()
-
 		fn(ctx, chatID, userData)
 	}
 }
@@ -122,34 +63,111 @@ func (t *TGBot) onUserContentEvent(userContentFn getUserContentIDsFunc, getConte
 
 		userContentIDs, err := userContentFn(ctx, userID, contentType)
 		if err != nil {
-			log.Error("failed to get user content ids", "error", err.Error())
+			log.ErrorContext(ctx, "failed to get user content ids", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
 
-		if len(userContentIDs) == 0 {
-			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: chatID,
-				Text:   emptyMessage,
+		t.showUserContent(ctx, chatID, getContentFn, map[types.ContentType][]int64{contentType: userContentIDs}, emptyMessage)
+	}
+}
+
+// showUserContent resolves the content by the ids of each content type and shows it as a single slider.
+// The titles removed from TMDb can't be resolved, they are listed by their catalog titles after the slider.
+func (t *TGBot) showUserContent(ctx context.Context, chatID int64, getContentFn getContentByIDsFunc, ids map[types.ContentType][]int64, emptyMessage string) {
+	log := t.log.With("fn", "showUserContent", "chat_id", chatID)
+
+	content := make(types.Content, 0)
+	removed := make(types.Content, 0)
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		if len(ids[contentType]) == 0 {
+			continue
+		}
+
+		contentIDs := ids[contentType]
+		r, err := t.storer.GetRemovedContent(ctx, contentType, contentIDs)
+		if err != nil {
+			log.WarnContext(ctx, "failed to get removed content", "content_type", contentType, "error", err.Error())
+		}
+		if len(r) > 0 {
+			removedIDs := r.IDs()
+			contentIDs = slices.DeleteFunc(slices.Clone(contentIDs), func(id int64) bool {
+				return slices.Contains(removedIDs, id)
 			})
-			return
+			removed = append(removed, r...)
+		}
+		if len(contentIDs) == 0 {
+			continue
 		}
 
-		content, err := getContentFn(ctx, contentType, userContentIDs)
+		c, err := getContentFn(ctx, contentType, contentIDs)
 		if err != nil {
-			log.Error("failed to get content", "error", err.Error())
+			log.ErrorContext(ctx, "failed to get content", "content_type", contentType, "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
+		content = append(content, c...)
+	}
 
-		slides := t.generateSlider(content, nil)
-		_, err = slides.Show(ctx, t.bot, chatID)
-		if err != nil {
-			log.Error("failed to show slider", "error", err.Error())
+	if len(content) == 0 && len(removed) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   emptyMessage,
+		})
+		return
+	}
+
+	if len(content) > 0 {
+		slides := t.generateSlider(ctx, content, nil)
+		if _, err := slides.Show(ctx, t.bot, chatID); err != nil {
+			log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
 	}
+
+	if len(removed) > 0 {
+		_, err := t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   removedContentText(removed),
+		})
+		if err != nil {
+			log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		}
+	}
+}
+
+// removedContentText lists the titles removed from TMDb, their cards can't be opened anymore.
+// The list is cut to fit the message length, the titles left out are counted.
+func removedContentText(removed types.Content) string {
+	sb := strings.Builder{}
+	sb.WriteString("Эти фильмы и сериалы удалены из TMDb, их карточки больше недоступны:\n")
+	length := utf8.RuneCountInString(sb.String())
+	// The counter of the left out titles is never longer than the one of all of them.
+	moreLength := utf8.RuneCountInString(removedMoreText(len(removed)))
+	for i, item := range removed {
+		sign := "🎥"
+		if item.ContentType == types.TV {
+			sign = "📺"
+		}
+		line := fmt.Sprintf("%s %s\n", sign, item.Title)
+
+		limit := messageMaxLength
+		if i < len(removed)-1 {
+			limit -= moreLength
+		}
+		if length+utf8.RuneCountInString(line) > limit {
+			sb.WriteString(removedMoreText(len(removed) - i))
+			break
+		}
+		sb.WriteString(line)
+		length += utf8.RuneCountInString(line)
+	}
+	return sb.String()
+}
+
+func removedMoreText(n int) string {
+	return fmt.Sprintf("…и еще %d\n", n)
 }
 
 func (t *TGBot) onRecommendationsEvent(getContentFn getContentByIDsFunc, contentType types.ContentType) bot.HandlerFunc {
@@ -162,30 +180,48 @@ func (t *TGBot) onRecommendationsEvent(getContentFn getContentByIDsFunc, content
 
 		favoriteIDs, err := t.storer.GetFavoriteContentIDs(ctx, userID, contentType)
 		if err != nil {
-			log.Error("failed to get user favorites", "error", err.Error())
+			log.ErrorContext(ctx, "failed to get user favorites", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
 
 		viewedIDs, err := t.storer.GetViewedContentIDs(ctx, userID, contentType)
 		if err != nil {
-			log.Error("failed to get user viewed", "error", err.Error())
+			log.ErrorContext(ctx, "failed to get user viewed", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
 
 		recomendations, err := getContentFn(ctx, contentType, favoriteIDs)
 		if err != nil {
-			log.Error("failed to get recommendations", "error", err.Error())
+			log.ErrorContext(ctx, "failed to get recommendations", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
 
-		recomendations = recomendations.RemoveByIDs(viewedIDs).RemoveDuplicates()
+		settings, ok := types.UserSettingsFromContext(ctx)
+		if !ok {
+			settings = types.DefaultUserSettings(userID)
+		}
+
+		recomendations = settings.Filter(recomendations.RemoveByIDs(viewedIDs).RemoveDuplicates())
 		sort.Slice(recomendations, func(i, j int) bool {
 			return recomendations[i].Popularity > recomendations[j].Popularity
 		})
 
+		// The recommendations of the similar users are optional, TMDb ones are shown alone on the cold start or an error.
+		similar, err := t.getSimilarUsersContent(ctx, userID, contentType)
+		if err != nil {
+			log.WarnContext(ctx, "failed to get similar users content", "error", err.Error())
+		}
+		similar = settings.Filter(similar.RemoveByIDs(viewedIDs))
+
+		labels := make(map[int64]string, len(similar))
+		for _, item := range similar {
+			labels[item.ID] = similarUsersLabel
+		}
+		recomendations = blendRecommendations(similar, recomendations)
+
 		if len(recomendations) == 0 {
 			b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID: chatID,
@@ -194,13 +230,14 @@ func (t *TGBot) onRecommendationsEvent(getContentFn getContentByIDsFunc, content
 			return
 		}
 
-		slides := t.generateSlider(recomendations, nil)
+		slides := t.generateLabeledSlider(ctx, recomendations, labels, nil)
 		_, err = slides.Show(ctx, t.bot, chatID)
 		if err != nil {
-			log.Error("failed to show slider", "error", err.Error())
+			log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
+		t.recordImpressions(userID, types.EventRecommendationImpression, recomendations)
 	}
 }
 
@@ -213,18 +250,33 @@ func (t *TGBot) showMoviePopular(ctx context.Context, chatID int64, userData Use
 	page := userData.pagesMap[MoviePopular]
 	m, err := t.api.GetMoviePopular(ctx, page)
 	if err != nil {
-		log.Error("failed to get popular movies", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get popular movies", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
+	m, err = t.applyUserSettings(ctx, chatID, types.Movie, m, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(m) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
 	opts := []slider.Option{
 		slider.OnCancel("Показать еще", true, t.onContentPageEvent(t.showMoviePopular, MoviePopular)),
 	}
-	slides := t.generateSlider(m, opts)
+	slides := t.generateSlider(ctx, m, opts)
 	_, err = slides.Show(ctx, t.bot, chatID)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
@@ -239,19 +291,34 @@ func (t *TGBot) showMovieTop(ctx context.Context, chatID int64, userData UserDat
 
 	content, err := t.api.GetMovieTop(ctx, page)
 	if err != nil {
-		log.Error("failed to get movie top", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get movie top", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
+	content, err = t.applyUserSettings(ctx, chatID, types.Movie, content, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(content) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
 	opts := []slider.Option{
 		slider.OnCancel("Показать еще", true, t.onContentPageEvent(t.showMovieTop, MovieTop)),
 	}
 
-	slides := t.generateSlider(content, opts)
+	slides := t.generateSlider(ctx, content, opts)
 	_, err = slides.Show(ctx, t.bot, chatID)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
@@ -266,18 +333,33 @@ func (t *TGBot) showTVPopular(ctx context.Context, chatID int64, userData UserDa
 
 	content, err := t.api.GetTVPopular(ctx, page)
 	if err != nil {
-		log.Error("failed to get popular tvs", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get popular tvs", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	content, err = t.applyUserSettings(ctx, chatID, types.TV, content, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
+	if len(content) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
 	opts := []slider.Option{
 		slider.OnCancel("Показать еще", true, t.onContentPageEvent(t.showTVPopular, TVPopular)),
 	}
-	slides := t.generateSlider(content, opts)
+	slides := t.generateSlider(ctx, content, opts)
 	_, err = slides.Show(ctx, t.bot, chatID)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
@@ -293,18 +375,33 @@ func (t *TGBot) showTVTop(ctx context.Context, chatID int64, userData UserData)
 
 	content, err := t.api.GetTVTop(ctx, page)
 	if err != nil {
-		log.Error("failed to get top tvs", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get top tvs", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
 
+	content, err = t.applyUserSettings(ctx, chatID, types.TV, content, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(content) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
 	opts := []slider.Option{
 		slider.OnCancel("Показать еще", true, t.onContentPageEvent(t.showTVTop, TVTop)),
 	}
-	slides := t.generateSlider(content, opts)
+	slides := t.generateSlider(ctx, content, opts)
 	_, err = slides.Show(ctx, t.bot, chatID)
 	if err != nil {
-		log.Error("failed to show slider", "error", err.Error())
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
 		t.sendErrorMessage(ctx, chatID)
 		return
 	}
@@ -320,7 +417,7 @@ func (t *TGBot) onGetGenresEvent(contentType types.ContentType) bot.HandlerFunc
 
 		genres, err := t.api.GetGenres(ctx, contentType)
 		if err != nil {
-			log.Error("failed to get genres", "error", err.Error())
+			log.ErrorContext(ctx, "failed to get genres", "error", err.Error())
 			t.sendErrorMessage(ctx, chatID)
 			return
 		}
@@ -332,3 +429,122 @@ func (t *TGBot) onGetGenresEvent(contentType types.ContentType) bot.HandlerFunc
 		})
 	}
 }
+
+type getContentPageFunc func(ctx context.Context, page int) (types.Content, error)
+
+// showContentPage retrieves the current page of the content list, filters it by the user settings
+// and shows it to the user with the "Показать еще" button.
+func (t *TGBot) showContentPage(ctx context.Context, chatID int64, userData UserData, contentType types.ContentType, page Page, getFn getContentPageFunc, showFn showContentDataFunc, opts ...slider.Option) {
+	log := t.log.With("fn", "showContentPage", "chat_id", chatID, "page", userData.pagesMap[page], "content_type", contentType)
+	log.Debug("handler func start log")
+
+	content, err := getFn(ctx, userData.pagesMap[page])
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	content, err = t.applyUserSettings(ctx, chatID, contentType, content, userData.settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to apply user settings", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(content) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено. Проверьте фильтры в /settings",
+		})
+		return
+	}
+
+	opts = append(opts, slider.OnCancel("Показать еще", true, t.onContentPageEvent(showFn, page)))
+	slides := t.generateSlider(ctx, content, opts)
+	_, err = slides.Show(ctx, t.bot, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+}
+
+// onTrendingWindowEvent switches the trending list between the day and the week and shows it from the first page.
+func (t *TGBot) onTrendingWindowEvent(fn showContentDataFunc, page Page, contentType types.ContentType) slider.OnSelectFunc {
+	return func(ctx context.Context, b *bot.Bot, message models.MaybeInaccessibleMessage, _ int) {
+		chatID := message.Message.Chat.ID
+
+		log := t.log.With("fn", "onTrendingWindowEvent", "chat_id", chatID)
+		log.Debug("handler func start log")
+
+		t.mu.RLock()
+		userData, exists := t.userData[chatID]
+		t.mu.RUnlock()
+
+		if !exists {
+			log.Debug("user not found in userData map")
+			return
+		}
+
+		userData.trendWindow[contentType] = userData.trendWindow[contentType].Toggle()
+		userData.pagesMap[page] = 1
+
+		t.mu.Lock()
+		t.userData[chatID] = userData
+		t.mu.Unlock()
+
+		fn(ctx, chatID, userData)
+	}
+}
+
+func trendingWindowButtonText(window types.TrendingWindow) string {
+	if window == types.TrendingWeek {
+		return "За день"
+	}
+	return "За неделю"
+}
+
+// showMovieTrending shows the movies trending today or this week.
+func (t *TGBot) showMovieTrending(ctx context.Context, chatID int64, userData UserData) {
+	window := userData.trendWindow[types.Movie]
+	getFn := func(ctx context.Context, page int) (types.Content, error) {
+		return t.api.GetMovieTrending(ctx, window, page)
+	}
+
+	t.showContentPage(ctx, chatID, userData, types.Movie, MovieTrending, getFn, t.showMovieTrending,
+		slider.OnSelect(trendingWindowButtonText(window), true, t.onTrendingWindowEvent(t.showMovieTrending, MovieTrending, types.Movie)),
+	)
+}
+
+// showMovieNowPlaying shows the movies in theatres.
+func (t *TGBot) showMovieNowPlaying(ctx context.Context, chatID int64, userData UserData) {
+	t.showContentPage(ctx, chatID, userData, types.Movie, MovieNowPlaying, t.api.GetMovieNowPlaying, t.showMovieNowPlaying)
+}
+
+// showMovieUpcoming shows the upcoming movies.
+func (t *TGBot) showMovieUpcoming(ctx context.Context, chatID int64, userData UserData) {
+	t.showContentPage(ctx, chatID, userData, types.Movie, MovieUpcoming, t.api.GetMovieUpcoming, t.showMovieUpcoming)
+}
+
+// showTVTrending shows the TV shows trending today or this week.
+func (t *TGBot) showTVTrending(ctx context.Context, chatID int64, userData UserData) {
+	window := userData.trendWindow[types.TV]
+	getFn := func(ctx context.Context, page int) (types.Content, error) {
+		return t.api.GetTVTrending(ctx, window, page)
+	}
+
+	t.showContentPage(ctx, chatID, userData, types.TV, TVTrending, getFn, t.showTVTrending,
+		slider.OnSelect(trendingWindowButtonText(window), true, t.onTrendingWindowEvent(t.showTVTrending, TVTrending, types.TV)),
+	)
+}
+
+// showTVAiringToday shows the TV shows airing today.
+func (t *TGBot) showTVAiringToday(ctx context.Context, chatID int64, userData UserData) {
+	t.showContentPage(ctx, chatID, userData, types.TV, TVAiringToday, t.api.GetTVAiringToday, t.showTVAiringToday)
+}
+
+// showTVOnTheAir shows the TV shows with an episode airing in the next 7 days.
+func (t *TGBot) showTVOnTheAir(ctx context.Context, chatID int64, userData UserData) {
+	t.showContentPage(ctx, chatID, userData, types.TV, TVOnTheAir, t.api.GetTVOnTheAir, t.showTVOnTheAir)
+}
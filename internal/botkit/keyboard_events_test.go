@@ -0,0 +1,29 @@
+package botkit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_removedContentText(t *testing.T) {
+	removed := make(types.Content, 0, 200)
+	for i := range 200 {
+		removed = append(removed, types.ContentItem{ID: int64(i), ContentType: types.Movie, Title: strings.Repeat("Название ", 5)})
+	}
+
+	text := removedContentText(removed)
+	assert.LessOrEqual(t, utf8.RuneCountInString(text), messageMaxLength)
+
+	listed := strings.Count(text, "🎥")
+	assert.Less(t, listed, len(removed))
+	assert.True(t, strings.HasSuffix(text, fmt.Sprintf("…и еще %d\n", len(removed)-listed)))
+
+	text = removedContentText(removed[:3])
+	assert.Equal(t, 3, strings.Count(text, "🎥"))
+	assert.NotContains(t, text, "…и еще")
+}
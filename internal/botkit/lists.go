@@ -0,0 +1,577 @@
+package botkit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"whattowatch/internal/botkit/fsm"
+	"whattowatch/internal/types"
+	"whattowatch/internal/utils"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type ListAction string
+
+const (
+	PickListAction          ListAction = "pick"
+	ToggleListAction        ListAction = "tgl"
+	NewListAction           ListAction = "new"
+	ShowListAction          ListAction = "show"
+	RenameListAction        ListAction = "ren"
+	ShareListAction         ListAction = "share"
+	DeleteListAction        ListAction = "del"
+	ConfirmDeleteListAction ListAction = "delok"
+)
+
+const (
+	// listPrefix is a callback data prefix of the list buttons, e.g. "ls_tgl:12:f123".
+	listPrefix = "ls_"
+
+	// sharedListPayload is a /start payload prefix of the shared list links.
+	sharedListPayload = "list_"
+
+	listNameMaxLength = 64
+)
+
+func listData(action ListAction, args ...any) string {
+	sb := strings.Builder{}
+	sb.WriteString(listPrefix)
+	sb.WriteString(string(action))
+	for _, arg := range args {
+		sb.WriteString(fmt.Sprintf(":%v", arg))
+	}
+	return sb.String()
+}
+
+func listPickData(item types.ContentItem) string {
+	return listData(PickListAction, contentRef(item))
+}
+
+func (t *TGBot) listsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "listsHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	lists, err := t.storer.GetLists(ctx, update.Message.From.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get lists", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	text := "Ваши списки. Добавить фильм или сериал в список можно кнопкой «В список…» в его карточке"
+	if len(lists) == 0 {
+		text = "У вас пока нет списков. Создайте первый, например «Хорроры на Хэллоуин»"
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(lists)+1)
+	for _, list := range lists {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%s (%d)", list.Name, list.ItemsCount), CallbackData: listData(ShowListAction, list.ID)},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: "➕ Новый список", CallbackData: listData(NewListAction)}})
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
+
+func (t *TGBot) onListEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	userID := query.From.ID
+
+	log := t.log.With("fn", "onListEvent", "user_id", userID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	args := strings.Split(strings.TrimPrefix(query.Data, listPrefix), ":")
+	action := ListAction(args[0])
+	args = args[1:]
+
+	var err error
+	switch action {
+	case PickListAction:
+		err = t.onPickList(ctx, query, args)
+	case ToggleListAction:
+		err = t.onToggleList(ctx, query, args)
+	case NewListAction:
+		err = t.onNewList(ctx, query, args)
+	case ShowListAction:
+		err = t.onShowList(ctx, query, args)
+	case RenameListAction:
+		err = t.onRenameList(ctx, query, args)
+	case ShareListAction:
+		err = t.onShareList(ctx, query, args)
+	case DeleteListAction, ConfirmDeleteListAction:
+		err = t.onDeleteList(ctx, query, action, args)
+	default:
+		err = fmt.Errorf("unknown list action: %s", action)
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to handle list action", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
+	}
+}
+
+// parseListArgs parses the list id and, if present, the content reference from the callback data args.
+func parseListArgs(args []string) (int64, string, error) {
+	if len(args) == 0 {
+		return 0, "", fmt.Errorf("list id is missing")
+	}
+	listID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("wrong list id: %s", err.Error())
+	}
+	if len(args) > 1 {
+		return listID, args[1], nil
+	}
+	return listID, "", nil
+}
+
+func (t *TGBot) getContentItemByRef(ctx context.Context, ref string) (types.ContentItem, error) {
+	contentType, id, err := parseContentRef(ref)
+	if err != nil {
+		return types.ContentItem{}, err
+	}
+	return t.getContentItem(ctx, contentType, id)
+}
+
+// getListPickerKeyboard marks the lists containing the item and offers to create a new one.
+func (t *TGBot) getListPickerKeyboard(ctx context.Context, userID int64, item types.ContentItem) (*models.InlineKeyboardMarkup, error) {
+	lists, err := t.storer.GetLists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	listIDs, err := t.storer.GetItemListIDs(ctx, userID, item)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]models.InlineKeyboardButton, 0, len(lists)+1)
+	for _, list := range lists {
+		mark := "▫️"
+		if slices.Contains(listIDs, list.ID) {
+			mark = "✅"
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: mark + " " + list.Name, CallbackData: listData(ToggleListAction, list.ID, contentRef(item))},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: "➕ Новый список", CallbackData: listData(NewListAction, contentRef(item))}})
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+func (t *TGBot) onPickList(ctx context.Context, query *models.CallbackQuery, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("content reference is missing")
+	}
+
+	item, err := t.getContentItemByRef(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	kb, err := t.getListPickerKeyboard(ctx, query.From.ID, item)
+	if err != nil {
+		return err
+	}
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+	_, err = t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      query.From.ID,
+		Text:        fmt.Sprintf("Выберите списки для «%s»", item.Title),
+		ReplyMarkup: kb,
+	})
+	return err
+}
+
+func (t *TGBot) onToggleList(ctx context.Context, query *models.CallbackQuery, args []string) error {
+	userID := query.From.ID
+
+	listID, ref, err := parseListArgs(args)
+	if err != nil {
+		return err
+	}
+
+	item, err := t.getContentItemByRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storer.GetList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+
+	listIDs, err := t.storer.GetItemListIDs(ctx, userID, item)
+	if err != nil {
+		return err
+	}
+
+	toast := fmt.Sprintf("Добавлено в «%s»", list.Name)
+	if slices.Contains(listIDs, listID) {
+		err = t.storer.RemoveListItem(ctx, userID, listID, item)
+		toast = fmt.Sprintf("Удалено из «%s»", list.Name)
+	} else {
+		err = t.storer.AddListItem(ctx, userID, listID, item)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.answerCallbackQuery(ctx, query.ID, toast)
+	t.editListMessageMarkup(ctx, query, func() (*models.InlineKeyboardMarkup, error) {
+		return t.getListPickerKeyboard(ctx, userID, item)
+	})
+	return nil
+}
+
+func (t *TGBot) onNewList(ctx context.Context, query *models.CallbackQuery, args []string) error {
+	data := make(map[string]string)
+	if len(args) > 0 {
+		if _, _, err := parseContentRef(args[0]); err != nil {
+			return err
+		}
+		data["ref"] = args[0]
+	}
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+	t.enterState(ctx, query.From.ID, stateListName, data)
+
+	_, err := t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: query.From.ID,
+		Text:   "Отправьте название нового списка. Для отмены выполните любую команду, например /lists",
+	})
+	return err
+}
+
+func (t *TGBot) onRenameList(ctx context.Context, query *models.CallbackQuery, args []string) error {
+	listID, _, err := parseListArgs(args)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storer.GetList(ctx, query.From.ID, listID)
+	if err != nil {
+		return err
+	}
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+	t.enterState(ctx, query.From.ID, stateListName, map[string]string{"list_id": strconv.FormatInt(list.ID, 10)})
+
+	_, err = t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: query.From.ID,
+		Text:   fmt.Sprintf("Отправьте новое название списка «%s». Для отмены выполните любую команду, например /lists", list.Name),
+	})
+	return err
+}
+
+// listNameInputHandler creates the list (and adds the item of the session to it) or renames the list of the session.
+func (t *TGBot) listNameInputHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	listID, _ := strconv.ParseInt(s.Get("list_id"), 10, 64)
+
+	log := t.log.With("fn", "listNameInputHandler", "user_id", userID, "chat_id", chatID, "list_id", listID)
+	log.Debug("handler func start log")
+
+	name := strings.TrimSpace(update.Message.Text)
+	if name == "" || len([]rune(name)) > listNameMaxLength {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("Название списка должно быть текстом не длиннее %d символов. Попробуйте еще раз: /lists", listNameMaxLength),
+		})
+		return
+	}
+
+	var item *types.ContentItem
+	if ref := s.Get("ref"); ref != "" {
+		i, err := t.getContentItemByRef(ctx, ref)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get content item", "error", err.Error())
+			t.sendErrorMessage(ctx, chatID)
+			return
+		}
+		item = &i
+	}
+
+	lists, err := t.storer.GetLists(ctx, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get lists", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+	for _, list := range lists {
+		if list.ID != listID && strings.EqualFold(list.Name, name) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   fmt.Sprintf("Список «%s» уже есть. Попробуйте еще раз: /lists", list.Name),
+			})
+			return
+		}
+	}
+
+	var reply string
+	if listID != 0 {
+		err = t.storer.RenameList(ctx, userID, listID, name)
+		reply = fmt.Sprintf("Список переименован в «%s»", name)
+	} else {
+		var list types.UserList
+		list, err = t.storer.CreateList(ctx, userID, name)
+		reply = fmt.Sprintf("Список «%s» создан", name)
+		if err == nil && item != nil {
+			err = t.storer.AddListItem(ctx, userID, list.ID, *item)
+			reply = fmt.Sprintf("Список «%s» создан, «%s» добавлен в него", name, item.Title)
+		}
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save list", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   reply + "\nВсе списки: /lists",
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+}
+
+func (t *TGBot) getListKeyboard(list types.UserList) *models.InlineKeyboardMarkup {
+	share := "🔗 Поделиться"
+	if list.IsShared() {
+		share = "🔒 Закрыть доступ"
+	}
+
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "Переименовать", CallbackData: listData(RenameListAction, list.ID)},
+				{Text: share, CallbackData: listData(ShareListAction, list.ID)},
+			},
+			{{Text: "Удалить", CallbackData: listData(DeleteListAction, list.ID)}},
+		},
+	}
+}
+
+func (t *TGBot) onShowList(ctx context.Context, query *models.CallbackQuery, args []string) error {
+	userID := query.From.ID
+
+	listID, _, err := parseListArgs(args)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storer.GetList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+	if err = t.showList(ctx, userID, list); err != nil {
+		return err
+	}
+
+	_, err = t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      userID,
+		Text:        fmt.Sprintf("Список «%s»: %d", list.Name, list.ItemsCount),
+		ReplyMarkup: t.getListKeyboard(list),
+	})
+	return err
+}
+
+// showList shows the list items of all the content types as a single slider.
+func (t *TGBot) showList(ctx context.Context, chatID int64, list types.UserList) error {
+	ids := make(map[types.ContentType][]int64)
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		contentIDs, err := t.storer.GetListContentIDs(ctx, list.ID, contentType)
+		if err != nil {
+			return err
+		}
+		ids[contentType] = contentIDs
+	}
+
+	t.showUserContent(ctx, chatID, t.api.GetContent, ids, fmt.Sprintf("Список «%s» пуст", list.Name))
+	return nil
+}
+
+func (t *TGBot) onShareList(ctx context.Context, query *models.CallbackQuery, args []string) error {
+	userID := query.From.ID
+
+	listID, _, err := parseListArgs(args)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storer.GetList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+
+	if list.IsShared() {
+		if err = t.storer.SetListShareToken(ctx, userID, listID, ""); err != nil {
+			return err
+		}
+		list.ShareToken = ""
+
+		t.answerCallbackQuery(ctx, query.ID, "Доступ по ссылке закрыт")
+		t.editListMessageMarkup(ctx, query, func() (*models.InlineKeyboardMarkup, error) {
+			return t.getListKeyboard(list), nil
+		})
+		return nil
+	}
+
+	me, err := t.bot.GetMe(ctx)
+	if err != nil {
+		return err
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return err
+	}
+	if err = t.storer.SetListShareToken(ctx, userID, listID, token); err != nil {
+		return err
+	}
+	list.ShareToken = token
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+	t.editListMessageMarkup(ctx, query, func() (*models.InlineKeyboardMarkup, error) {
+		return t.getListKeyboard(list), nil
+	})
+
+	_, err = t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: userID,
+		Text: fmt.Sprintf("Ссылка на список «%s» (только просмотр):\nhttps://t.me/%s?start=%s%s",
+			list.Name, me.Username, sharedListPayload, token),
+	})
+	return err
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (t *TGBot) onDeleteList(ctx context.Context, query *models.CallbackQuery, action ListAction, args []string) error {
+	userID := query.From.ID
+
+	listID, _, err := parseListArgs(args)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storer.GetList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+
+	if action == DeleteListAction {
+		t.answerCallbackQuery(ctx, query.ID, "")
+		_, err = t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: userID,
+			Text:   fmt.Sprintf("Удалить список «%s»? Фильмы и сериалы останутся в избранном и просмотренном", list.Name),
+			ReplyMarkup: &models.InlineKeyboardMarkup{
+				InlineKeyboard: [][]models.InlineKeyboardButton{
+					{{Text: "Да, удалить", CallbackData: listData(ConfirmDeleteListAction, list.ID)}},
+				},
+			},
+		})
+		return err
+	}
+
+	if err = t.storer.DeleteList(ctx, userID, listID); err != nil {
+		return err
+	}
+
+	t.answerCallbackQuery(ctx, query.ID, fmt.Sprintf("Список «%s» удален", list.Name))
+	if query.Message.Message != nil {
+		t.bot.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    query.Message.Message.Chat.ID,
+			MessageID: query.Message.Message.ID,
+		})
+	}
+	return nil
+}
+
+// editListMessageMarkup replaces the keyboard of the message the callback query came from.
+func (t *TGBot) editListMessageMarkup(ctx context.Context, query *models.CallbackQuery, kbFn func() (*models.InlineKeyboardMarkup, error)) {
+	log := t.log.With("fn", "editListMessageMarkup", "user_id", query.From.ID)
+
+	if query.Message.Message == nil {
+		return
+	}
+
+	kb, err := kbFn()
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get keyboard", "error", err.Error())
+		return
+	}
+
+	_, err = t.bot.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      query.Message.Message.Chat.ID,
+		MessageID:   query.Message.Message.ID,
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to edit message", "error", err.Error())
+	}
+}
+
+// sharedListHandler shows the list shared by the link read-only.
+func (t *TGBot) sharedListHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "sharedListHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	// The link may be the first contact with the bot.
+	if err := t.insertUser(ctx, update.Message.From); err != nil {
+		log.ErrorContext(ctx, "failed to insert user", "error", err.Error())
+	}
+
+	token := strings.TrimPrefix(strings.TrimPrefix(update.Message.Text, "/start "), sharedListPayload)
+	list, err := t.storer.GetSharedList(ctx, token)
+	if err != nil {
+		log.WarnContext(ctx, "failed to get shared list", "error", err.Error())
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Список не найден или доступ к нему закрыт",
+		})
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      fmt.Sprintf("Вам поделились списком *%s*", utils.EscapeMarkdown(list.Name)),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+
+	if err = t.showList(ctx, chatID, list); err != nil {
+		log.ErrorContext(ctx, "failed to show list", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
@@ -2,6 +2,10 @@ package botkit
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"unicode"
+	"whattowatch/internal/types"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -18,6 +22,12 @@ func (t *TGBot) userDataMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
 			id = update.Message.From.ID
 		}
 
+		settings, err := t.storer.GetUserSettings(ctx, id)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get user settings", "userID", id, "error", err.Error())
+			settings = types.DefaultUserSettings(id)
+		}
+
 		t.mu.RLock()
 		entry, ok := t.userData[id]
 		t.mu.RUnlock()
@@ -25,17 +35,69 @@ func (t *TGBot) userDataMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
 		if !ok {
 			log.Debug("init user data", "userID", id)
 
-			t.mu.Lock()
-			t.userData[id] = initUserData(t.getMainKeyboard)
-			t.mu.Unlock()
+			entry = initUserData(id)
 
-			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID:      id,
-				Text:        "Выберите тип контента",
-				ReplyMarkup: entry.replyKeyboard,
-			})
+			s, err := t.fsm.Current(ctx, id)
+			if err != nil {
+				log.ErrorContext(ctx, "failed to get user state", "userID", id, "error", err.Error())
+			}
+			t.showMenu(ctx, id, t.getMenu(s.Base()))
+		}
+		entry.settings = settings
+
+		t.mu.Lock()
+		t.userData[id] = entry
+		t.mu.Unlock()
+
+		ctx = types.ContextWithUserSettings(ctx, settings)
+
+		// The menus and the input prompts are handled by the state machine, the rest by the registered handlers.
+		handled, err := t.fsm.Handle(ctx, b, update)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to handle user state", "userID", id, "error", err.Error())
+		}
+		if handled {
+			return
 		}
 
 		next(ctx, b, update)
 	}
 }
+
+var (
+	// handlerCommands are the commands with the ids dropped, e.g. "/f" for "/f123".
+	handlerCommands = []string{
+		"/start", "/help", "/menu", "/search", "/settings", "/forget", "/notes", "/lists", "/history",
+		"/mystats", "/year", "/app", "/token", "/f", "/t", "/c", "/gf", "/gt",
+		"/admin_funnel", "/admin_retention", "/admin_usage", "/admin_removed",
+	}
+	handlerCallbackPrefixes = []string{
+		contentActionPrefix, settingsPrefix, forgetPrefix, collectionPrefix, listPrefix, historyPrefix, notePrefix, notesPagePrefix, searchPrefix,
+	}
+)
+
+// HandlerName returns the name of the command or the callback prefix the update is routed to.
+// The names are bounded, so they are fine to be the metrics labels and the span names.
+func HandlerName(update *models.Update) string {
+	switch {
+	case update.CallbackQuery != nil:
+		for _, prefix := range handlerCallbackPrefixes {
+			if strings.HasPrefix(update.CallbackQuery.Data, prefix) {
+				return prefix
+			}
+		}
+		return "callback"
+	case update.Message != nil:
+		fields := strings.Fields(update.Message.Text)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+			return "text"
+		}
+		command, _, _ := strings.Cut(fields[0], "@")
+		command = strings.TrimRightFunc(command, func(r rune) bool { return unicode.IsDigit(r) || r == ',' })
+		if slices.Contains(handlerCommands, command) {
+			return command
+		}
+		return "command"
+	}
+	return "other"
+}
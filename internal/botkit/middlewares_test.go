@@ -0,0 +1,43 @@
+package botkit
+
+import (
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandlerName(t *testing.T) {
+	message := func(text string) *models.Update {
+		return &models.Update{Message: &models.Message{Text: text}}
+	}
+	callback := func(data string) *models.Update {
+		return &models.Update{CallbackQuery: &models.CallbackQuery{Data: data}}
+	}
+
+	tests := []struct {
+		name   string
+		update *models.Update
+		want   string
+	}{
+		{"command", message("/help"), "/help"},
+		{"command with bot name", message("/mystats@whattowatch_bot"), "/mystats"},
+		{"command with args", message("/search Начало"), "/search"},
+		{"id command", message("/f550"), "/f"},
+		{"genre command", message("/gt10759"), "/gt"},
+		{"admin command with args", message("/admin_funnel 7"), "/admin_funnel"},
+		{"unknown command", message("/f550x"), "command"},
+		{"text", message("Фильмы 🎬"), "text"},
+		{"empty text", message(""), "text"},
+		{"callback", callback(contentActionPrefix + "1_f550"), contentActionPrefix},
+		{"notes page callback", callback(notesPagePrefix + "10"), notesPagePrefix},
+		{"unknown callback", callback("slider_next"), "callback"},
+		{"other", &models.Update{}, "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HandlerName(tt.update))
+		})
+	}
+}
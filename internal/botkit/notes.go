@@ -0,0 +1,172 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"whattowatch/internal/botkit/fsm"
+	"whattowatch/internal/utils"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// notePrefix is a callback data prefix of the "Заметка" button.
+	notePrefix = "nt_"
+	// notesPagePrefix is a callback data prefix of the /notes "Показать еще" button, e.g. "ns_10".
+	notesPagePrefix = "ns_"
+	notesPageSize   = 10
+	// messageMaxLength is the Telegram limit of the message text.
+	messageMaxLength = 4096
+
+	// noteDeleteText is a text to send instead of the note to delete it.
+	noteDeleteText = "-"
+	noteMaxLength  = 1000
+)
+
+func (t *TGBot) notesHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log := t.log.With("fn", "notesHandler", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
+	log.Debug("handler func start log")
+
+	t.showNotes(ctx, update.Message.Chat.ID, update.Message.From.ID, 0)
+}
+
+func (t *TGBot) onNotesPageEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+
+	log := t.log.With("fn", "onNotesPageEvent", "user_id", query.From.ID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(query.Data, notesPagePrefix))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to parse offset", "error", err.Error())
+		t.sendErrorMessage(ctx, query.From.ID)
+		return
+	}
+
+	t.showNotes(ctx, query.From.ID, query.From.ID, offset)
+}
+
+// showNotes shows the page of the user notes, the page is shortened to fit the message length.
+func (t *TGBot) showNotes(ctx context.Context, chatID int64, userID int64, offset int) {
+	log := t.log.With("fn", "showNotes", "chat_id", chatID, "offset", offset)
+
+	// One more note is requested to know if there is the next page.
+	notes, err := t.storer.GetNotes(ctx, userID, notesPageSize+1, offset)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get notes", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(notes) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "У вас пока нет заметок. Добавить заметку можно кнопкой «📝 Заметка» в карточке фильма или сериала",
+		})
+		return
+	}
+
+	page := notes[:min(len(notes), notesPageSize)].Fit(messageMaxLength)
+	params := &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      page.GetInfo(),
+		ParseMode: models.ParseModeMarkdown,
+	}
+	if len(notes) > len(page) {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: "Показать еще", CallbackData: fmt.Sprintf("%s%d", notesPagePrefix, offset+len(page))}},
+			},
+		}
+	}
+
+	_, err = t.bot.SendMessage(ctx, params)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
+
+func (t *TGBot) onNoteEvent(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	query := update.CallbackQuery
+	userID := query.From.ID
+
+	log := t.log.With("fn", "onNoteEvent", "user_id", userID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	t.answerCallbackQuery(ctx, query.ID, "")
+
+	ref := strings.TrimPrefix(query.Data, notePrefix)
+	item, err := t.getContentItemByRef(ctx, ref)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content item", "error", err.Error())
+		t.sendErrorMessage(ctx, userID)
+		return
+	}
+
+	s.Goto(stateNoteText, map[string]string{"ref": ref})
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: userID,
+		Text: fmt.Sprintf("Отправьте текст заметки к «%s». Заметку видите только вы.\n"+
+			"Чтобы удалить заметку, отправьте «%s». Для отмены выполните любую команду, например /menu", item.Title, noteDeleteText),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, userID)
+	}
+}
+
+// noteInputHandler saves the user message as the note to the item of the session.
+func (t *TGBot) noteInputHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "noteInputHandler", "user_id", userID, "chat_id", chatID, "ref", s.Get("ref"))
+	log.Debug("handler func start log")
+
+	text := strings.TrimSpace(update.Message.Text)
+	if text == "" {
+		s.Goto(fsm.Stay, s.Data)
+		b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "Заметка может содержать только текст"})
+		return
+	}
+
+	item, err := t.getContentItemByRef(ctx, s.Get("ref"))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get content item", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	var reply string
+	if text == noteDeleteText {
+		err = t.storer.DeleteNote(ctx, userID, item)
+		reply = fmt.Sprintf("Заметка к «%s» удалена", utils.EscapeMarkdown(item.Title))
+	} else {
+		if len([]rune(text)) > noteMaxLength {
+			text = string([]rune(text)[:noteMaxLength])
+		}
+		err = t.storer.SetNote(ctx, userID, item, text)
+		reply = fmt.Sprintf("Заметка к «%s» сохранена /%s%d\nВсе заметки: /notes", utils.EscapeMarkdown(item.Title), item.ContentType.Sign(), item.ID)
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save note", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      reply,
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+}
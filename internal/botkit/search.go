@@ -0,0 +1,294 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"whattowatch/internal/botkit/fsm"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// searchPrefix is a callback data prefix of the search prompt buttons.
+	searchPrefix = "sr_"
+	searchCancel = searchPrefix + "cancel"
+
+	// catalogSearchLimit limits the catalog hits of each title, their details are fetched from TMDb one by one.
+	catalogSearchLimit = 5
+	// catalogMinSimilarity is the trigram similarity of a good catalog hit, the titles without one are searched in TMDb.
+	catalogMinSimilarity = 0.5
+
+	// The search sources recorded with the search event.
+	searchSourceCatalog  = "catalog"
+	searchSourceTMDb     = "tmdb"
+	searchSourceMixed    = "catalog_tmdb"
+	searchSourceDegraded = "catalog_degraded"
+)
+
+func (t *TGBot) searchByTitleHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "searchByTitleHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	query := strings.TrimSpace(strings.TrimPrefix(update.Message.Text, "/search"))
+	if query == "" {
+		t.askSearchQuery(ctx, b, update, s)
+		return
+	}
+
+	t.searchByTitles(ctx, chatID, query)
+}
+
+// askSearchQuery waits for the user to send the titles to search.
+func (t *TGBot) askSearchQuery(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	log := t.log.With("fn", "askSearchQuery", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID)
+	log.Debug("handler func start log")
+
+	s.Goto(stateSearchQuery, nil)
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "Отправьте название фильма или сериала. Можно несколько через запятую.\nПример: Начало, Во все тяжкие",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: "Отмена", CallbackData: searchCancel}},
+			},
+		},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+	}
+}
+
+func (t *TGBot) searchInputHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "searchInputHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	query := strings.TrimSpace(update.Message.Text)
+	if query == "" {
+		t.askSearchQuery(ctx, b, update, s)
+		return
+	}
+
+	t.searchByTitles(ctx, chatID, query)
+}
+
+func (t *TGBot) onSearchCancelEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+
+	log := t.log.With("fn", "onSearchCancelEvent", "user_id", query.From.ID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	t.answerCallbackQuery(ctx, query.ID, "Поиск отменен")
+
+	if query.Message.Message == nil {
+		return
+	}
+	_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    query.Message.Message.Chat.ID,
+		MessageID: query.Message.Message.ID,
+	})
+	if err != nil {
+		log.WarnContext(ctx, "failed to delete search prompt", "error", err.Error())
+	}
+}
+
+// defaultHandler handles the messages matched neither a handler nor a transition: a plain text is the search query,
+// an unknown command gets the help hint.
+func (t *TGBot) defaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil || update.Message.Text == "" {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "defaultHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	if strings.HasPrefix(update.Message.Text, "/") {
+		t.sendUnknownCommand(ctx, chatID)
+		return
+	}
+
+	t.searchByTitles(ctx, chatID, update.Message.Text)
+}
+
+func (t *TGBot) sendUnknownCommand(ctx context.Context, chatID int64) {
+	t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Неизвестная команда. Список команд: /help",
+	})
+}
+
+// searchByTitles searches the comma separated titles and shows the results as a slider.
+func (t *TGBot) searchByTitles(ctx context.Context, chatID int64, query string) {
+	log := t.log.With("fn", "searchByTitles", "chat_id", chatID)
+
+	titles := make([]string, 0)
+	for _, title := range strings.Split(query, ",") {
+		if title = strings.TrimSpace(title); title != "" {
+			titles = append(titles, title)
+		}
+	}
+	if len(titles) == 0 {
+		return
+	}
+
+	res, source, err := t.search(ctx, titles)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to search content", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	// The search is run in the private chat, so the chat id is the user id.
+	t.events.Record(types.Event{
+		UserID:  chatID,
+		Type:    types.EventSearch,
+		Payload: map[string]any{"query": query, "results": len(res), "source": source},
+	})
+
+	if len(res) == 0 {
+		t.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Ничего не найдено",
+		})
+		return
+	}
+
+	if source == searchSourceDegraded {
+		t.sendDegradedSearchResults(ctx, chatID, res)
+		return
+	}
+
+	slides := t.generateSlider(ctx, res, nil)
+	_, err = slides.Show(ctx, t.bot, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to show slider", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
+
+// search answers from the local catalog first and enriches the hits with the details from TMDb.
+// Each title without a good catalog hit, e.g. a misspelled or a not loaded one, is searched in TMDb,
+// the TMDb results follow the catalog ones. The catalog hits are returned as is if TMDb is unreachable.
+func (t *TGBot) search(ctx context.Context, titles []string) (types.Content, string, error) {
+	log := t.log.With("fn", "search")
+
+	local, missing, err := t.searchCatalog(ctx, titles)
+	if err != nil {
+		log.WarnContext(ctx, "failed to search catalog", "error", err.Error())
+		local, missing = nil, titles
+	}
+
+	if len(local) == 0 {
+		res, err := t.api.SearchByTitles(ctx, missing)
+		return res, searchSourceTMDb, err
+	}
+
+	res, err := t.getContentDetails(ctx, local)
+	if err != nil {
+		log.WarnContext(ctx, "failed to get content details, showing catalog hits", "error", err.Error())
+		return local, searchSourceDegraded, nil
+	}
+	if len(missing) == 0 {
+		return res, searchSourceCatalog, nil
+	}
+
+	found, err := t.api.SearchByTitles(ctx, missing)
+	if err != nil {
+		log.WarnContext(ctx, "failed to search titles in TMDb, showing catalog results", "titles", missing, "error", err.Error())
+		return res, searchSourceCatalog, nil
+	}
+
+	seen := make(map[string]struct{}, len(res))
+	for _, item := range res {
+		seen[contentRef(item)] = struct{}{}
+	}
+	for _, item := range found {
+		if _, ok := seen[contentRef(item)]; !ok {
+			res = append(res, item)
+		}
+	}
+	return res, searchSourceMixed, nil
+}
+
+// searchCatalog searches each title in the loaded catalog, the good hits of the titles in the order of the titles.
+// The titles without a good hit are returned as missing.
+func (t *TGBot) searchCatalog(ctx context.Context, titles []string) (types.Content, []string, error) {
+	result := make(types.Content, 0)
+	missing := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, title := range titles {
+		content, err := t.storer.SearchContent(ctx, title, 0, catalogMinSimilarity, catalogSearchLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(content) == 0 {
+			missing = append(missing, title)
+			continue
+		}
+		for _, item := range content {
+			if _, ok := seen[contentRef(item)]; ok {
+				continue
+			}
+			seen[contentRef(item)] = struct{}{}
+			result = append(result, item)
+		}
+	}
+	return result, missing, nil
+}
+
+// getContentDetails fetches the details of the content by the type keeping its order.
+func (t *TGBot) getContentDetails(ctx context.Context, content types.Content) (types.Content, error) {
+	ids := make(map[types.ContentType][]int64)
+	order := make(map[string]int, len(content))
+	for i, item := range content {
+		ids[item.ContentType] = append(ids[item.ContentType], item.ID)
+		order[contentRef(item)] = i
+	}
+
+	result := make(types.Content, 0, len(content))
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		if len(ids[contentType]) == 0 {
+			continue
+		}
+		c, err := t.api.GetContent(ctx, contentType, ids[contentType])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c...)
+	}
+
+	slices.SortStableFunc(result, func(a, b types.ContentItem) int {
+		return order[contentRef(a)] - order[contentRef(b)]
+	})
+	return result, nil
+}
+
+// sendDegradedSearchResults lists the catalog hits without the details with the commands to open them.
+func (t *TGBot) sendDegradedSearchResults(ctx context.Context, chatID int64, content types.Content) {
+	sb := strings.Builder{}
+	sb.WriteString("Сервис TMDb сейчас недоступен, найдено в каталоге:\n\n")
+	for _, item := range content {
+		sign := "🎥"
+		if item.ContentType == types.TV {
+			sign = "📺"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s /%s\n", sign, item.Title, contentRef(item)))
+	}
+
+	_, err := t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   sb.String(),
+	})
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to send message", "fn", "sendDegradedSearchResults", "error", err.Error())
+	}
+}
@@ -0,0 +1,178 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type SettingsOption string
+
+const (
+	LanguageOption     SettingsOption = "lang"
+	RegionOption       SettingsOption = "region"
+	HideViewedOption   SettingsOption = "hide_viewed"
+	MinRatingOption    SettingsOption = "min_rating"
+	IncludeAdultOption SettingsOption = "adult"
+	CardLayoutOption   SettingsOption = "layout"
+)
+
+// settingsPrefix is a callback data prefix of the /settings menu buttons.
+const settingsPrefix = "st_"
+
+var (
+	languages = []string{"ru-RU", "en-US"}
+	regions   = []string{"RU", "US", "GB", "DE", "FR"}
+	ratings   = []float32{0, 5, 6, 7, 8}
+
+	languageNames = map[string]string{
+		"ru-RU": "Русский",
+		"en-US": "English",
+	}
+)
+
+// nextSettings returns a copy of the settings with the option switched to the next value.
+func nextSettings(s types.UserSettings, option SettingsOption) (types.UserSettings, error) {
+	switch option {
+	case LanguageOption:
+		s.Language = nextValue(languages, s.Language)
+	case RegionOption:
+		s.Region = nextValue(regions, s.Region)
+	case HideViewedOption:
+		s.HideViewed = !s.HideViewed
+	case MinRatingOption:
+		s.MinRating = nextValue(ratings, s.MinRating)
+	case IncludeAdultOption:
+		s.IncludeAdult = !s.IncludeAdult
+	case CardLayoutOption:
+		if s.CardLayout == types.CardLayoutCompact {
+			s.CardLayout = types.CardLayoutFull
+		} else {
+			s.CardLayout = types.CardLayoutCompact
+		}
+	default:
+		return s, fmt.Errorf("unknown settings option: %s", option)
+	}
+	return s, nil
+}
+
+func nextValue[T comparable](values []T, current T) T {
+	for i, v := range values {
+		if v == current {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return values[0]
+}
+
+func onOff(v bool) string {
+	if v {
+		return "вкл"
+	}
+	return "выкл"
+}
+
+func (t *TGBot) getSettingsKeyboard(s types.UserSettings) *models.InlineKeyboardMarkup {
+	layout := "полные"
+	if s.CardLayout == types.CardLayoutCompact {
+		layout = "компактные"
+	}
+
+	minRating := "любой"
+	if s.MinRating > 0 {
+		minRating = fmt.Sprintf("от %.0f", s.MinRating)
+	}
+
+	button := func(text string, option SettingsOption) models.InlineKeyboardButton {
+		return models.InlineKeyboardButton{Text: text, CallbackData: settingsPrefix + string(option)}
+	}
+
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				// The bot texts are in Russian, the language is of the titles and overviews from TMDb.
+				button("Язык описаний: "+languageNames[s.Language], LanguageOption),
+				button("Регион: "+s.Region, RegionOption),
+			},
+			{button("Скрывать просмотренные: "+onOff(s.HideViewed), HideViewedOption)},
+			{button("Минимальный рейтинг: "+minRating, MinRatingOption)},
+			{button("Контент 18+: "+onOff(s.IncludeAdult), IncludeAdultOption)},
+			{button("Карточки в подборках: "+layout, CardLayoutOption)},
+		},
+	}
+}
+
+func (t *TGBot) settingsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "settingsHandler", "user_id", userID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	settings, ok := types.UserSettingsFromContext(ctx)
+	if !ok {
+		settings = types.DefaultUserSettings(userID)
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        "Настройки",
+		ReplyMarkup: t.getSettingsKeyboard(settings),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
+
+func (t *TGBot) onSettingsEvent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	userID := query.From.ID
+
+	log := t.log.With("fn", "onSettingsEvent", "user_id", userID, "data", query.Data)
+	log.Debug("handler func start log")
+
+	settings, ok := types.UserSettingsFromContext(ctx)
+	if !ok {
+		settings = types.DefaultUserSettings(userID)
+	}
+
+	settings, err := nextSettings(settings, SettingsOption(strings.TrimPrefix(query.Data, settingsPrefix)))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to change settings", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Произошла ошибка")
+		return
+	}
+
+	err = t.storer.UpdateUserSettings(ctx, settings)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update settings", "error", err.Error())
+		t.answerCallbackQuery(ctx, query.ID, "Не удалось сохранить настройки. Выполните /start и попробуйте снова")
+		return
+	}
+
+	t.mu.Lock()
+	if userData, exists := t.userData[userID]; exists {
+		userData.settings = settings
+		t.userData[userID] = userData
+	}
+	t.mu.Unlock()
+
+	t.answerCallbackQuery(ctx, query.ID, "Сохранено")
+
+	if query.Message.Message == nil {
+		return
+	}
+	_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      query.Message.Message.Chat.ID,
+		MessageID:   query.Message.Message.ID,
+		ReplyMarkup: t.getSettingsKeyboard(settings),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to edit message", "error", err.Error())
+	}
+}
@@ -0,0 +1,71 @@
+package botkit
+
+import (
+	"context"
+	"time"
+	"whattowatch/internal/types"
+)
+
+const (
+	// neighborsMinUsers is the number of the common users the titles are considered similar from.
+	neighborsMinUsers = 2
+	// neighborsTopN is the number of the similar titles kept per title.
+	neighborsTopN = 20
+	// similarUsersLimit limits the titles fetched from the data provider for the recommendations of the similar users.
+	similarUsersLimit = 20
+
+	similarUsersLabel = "👥 Пользователи с похожим вкусом смотрели"
+)
+
+// runContentNeighborsRefresher recomputes the similar titles every interval until ctx is done.
+func (t *TGBot) runContentNeighborsRefresher(ctx context.Context) {
+	log := t.log.With("fn", "runContentNeighborsRefresher", "interval", t.cfg.ContentNeighborsInterval)
+
+	ticker := time.NewTicker(t.cfg.ContentNeighborsInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := t.storer.RefreshContentNeighbors(ctx, neighborsMinUsers, neighborsTopN)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to refresh content neighbors", "error", err.Error())
+		} else {
+			log.Info("content neighbors refreshed", "count", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getSimilarUsersContent returns the titles watched by the users with the similar taste in the order of the similarity.
+// It is empty on the cold start.
+func (t *TGBot) getSimilarUsersContent(ctx context.Context, userID int64, contentType types.ContentType) (types.Content, error) {
+	ids, err := t.storer.GetSimilarUsersContentIDs(ctx, userID, contentType, similarUsersLimit)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	content, err := t.api.GetContent(ctx, contentType, ids)
+	if err != nil {
+		return nil, err
+	}
+	return content.SortByIDs(ids), nil
+}
+
+// blendRecommendations interleaves the titles of the similar users with the TMDb ones keeping the order of each,
+// the duplicates are kept at the first place.
+func blendRecommendations(similar, tmdb types.Content) types.Content {
+	result := make(types.Content, 0, len(similar)+len(tmdb))
+	for i := 0; i < max(len(similar), len(tmdb)); i++ {
+		if i < len(similar) {
+			result = append(result, similar[i])
+		}
+		if i < len(tmdb) {
+			result = append(result, tmdb[i])
+		}
+	}
+	return result.RemoveDuplicates()
+}
@@ -0,0 +1,36 @@
+package botkit
+
+import (
+	"testing"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_blendRecommendations(t *testing.T) {
+	content := func(ids ...int64) types.Content {
+		c := make(types.Content, 0, len(ids))
+		for _, id := range ids {
+			c = append(c, types.ContentItem{ID: id})
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		similar types.Content
+		tmdb    types.Content
+		want    []int64
+	}{
+		{name: "cold start", similar: nil, tmdb: content(1, 2), want: []int64{1, 2}},
+		{name: "no tmdb", similar: content(3, 4), tmdb: nil, want: []int64{3, 4}},
+		{name: "interleaved", similar: content(1, 2, 3), tmdb: content(10, 20), want: []int64{1, 10, 2, 20, 3}},
+		{name: "duplicates", similar: content(1, 2), tmdb: content(2, 3), want: []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, blendRecommendations(tt.similar, tt.tmdb).IDs())
+		})
+	}
+}
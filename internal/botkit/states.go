@@ -0,0 +1,105 @@
+package botkit
+
+import (
+	"context"
+	"time"
+	"whattowatch/internal/botkit/fsm"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// The menu states show the reply keyboards, the input states wait for the user to send a text.
+const (
+	stateMain   fsm.StateID = "main"
+	stateMovies fsm.StateID = "movies"
+	stateTVs    fsm.StateID = "tvs"
+
+	stateSearchQuery fsm.StateID = "search_query"
+	stateNoteText    fsm.StateID = "note_text"
+	stateListName    fsm.StateID = "list_name"
+	stateWatchDate   fsm.StateID = "watch_date"
+
+	awaitInputTimeout = 5 * time.Minute
+)
+
+// newStateMachine builds the conversation of the menus and the input prompts.
+func (t *TGBot) newStateMachine(store fsm.Store) *fsm.Machine {
+	m := fsm.New(store, fsm.State{ID: stateMain}).
+		AddState(
+			fsm.State{ID: stateMovies},
+			fsm.State{ID: stateTVs},
+			fsm.State{ID: stateSearchQuery, Timeout: awaitInputTimeout, Transient: true},
+			fsm.State{ID: stateNoteText, Timeout: awaitInputTimeout, Transient: true},
+			fsm.State{ID: stateListName, Timeout: awaitInputTimeout, Transient: true},
+			fsm.State{ID: stateWatchDate, Timeout: awaitInputTimeout, Transient: true},
+		)
+
+	for _, mn := range t.menus {
+		for _, row := range mn.rows {
+			for _, button := range row {
+				switch {
+				case button.state != "":
+					m.On(mn.state, fsm.Text(button.text), button.state, t.showMenuAction(t.getMenu(button.state)))
+				case button.action != nil:
+					m.On(mn.state, fsm.Text(button.text), fsm.Stay, button.action)
+				default:
+					m.On(mn.state, fsm.Text(button.text), fsm.Stay, fsm.Handler(button.handler))
+				}
+			}
+		}
+	}
+
+	m.On(fsm.AnyState, fsm.Command("/menu"), fsm.Back, func(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+		t.showMenu(ctx, update.Message.Chat.ID, t.getMenu(s.Base()))
+	})
+
+	m.On(fsm.AnyState, fsm.Command("/search"), fsm.Stay, t.searchByTitleHandler).
+		On(fsm.AnyState, fsm.Callback(searchCancel), fsm.Back, fsm.Handler(t.onSearchCancelEvent)).
+		On(stateSearchQuery, fsm.AnyText(), fsm.Back, t.searchInputHandler)
+
+	m.On(fsm.AnyState, fsm.Callback(notePrefix), fsm.Stay, t.onNoteEvent).
+		On(stateNoteText, fsm.AnyText(), fsm.Back, t.noteInputHandler)
+
+	m.On(stateListName, fsm.AnyText(), fsm.Back, t.listNameInputHandler)
+	m.On(stateWatchDate, fsm.AnyText(), fsm.Back, t.watchDateInputHandler)
+
+	return m
+}
+
+// getMenu returns the menu of the state, the main menu is shown in the states without the menu.
+func (t *TGBot) getMenu(state fsm.StateID) menu {
+	for _, mn := range t.menus {
+		if mn.state == state {
+			return mn
+		}
+	}
+	return t.menus[0]
+}
+
+func (t *TGBot) showMenuAction(mn menu) fsm.Action {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update, s *fsm.Session) {
+		log := t.log.With("fn", "showMenuAction", "user_id", update.Message.From.ID, "chat_id", update.Message.Chat.ID, "state", mn.state)
+		log.Debug("handler func start log")
+
+		t.showMenu(ctx, update.Message.Chat.ID, mn)
+	}
+}
+
+func (t *TGBot) showMenu(ctx context.Context, chatID int64, mn menu) {
+	_, err := t.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        mn.title,
+		ReplyMarkup: mn.markup(),
+	})
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to send menu", "fn", "showMenu", "chat_id", chatID, "error", err.Error())
+	}
+}
+
+// enterState moves the user to the input state from the handlers outside the state machine.
+func (t *TGBot) enterState(ctx context.Context, userID int64, state fsm.StateID, data map[string]string) {
+	if err := t.fsm.Enter(ctx, userID, state, data); err != nil {
+		t.log.ErrorContext(ctx, "failed to enter state", "fn", "enterState", "user_id", userID, "state", state, "error", err.Error())
+	}
+}
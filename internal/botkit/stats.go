@@ -0,0 +1,55 @@
+package botkit
+
+import (
+	"context"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const statsMonths = 12
+
+func (t *TGBot) myStatsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "myStatsHandler", "user_id", userID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	stats, err := t.storer.GetUserStats(ctx, userID, statsMonths)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get user stats", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if stats.Views == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Статистика пока пуста. Отмечайте просмотренное кнопкой в карточке фильма или сериала",
+		})
+		return
+	}
+
+	genres := make(map[types.ContentType]types.Genres)
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		genres[contentType], err = t.api.GetGenres(ctx, contentType)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get genres", "content_type", contentType, "error", err.Error())
+			t.sendErrorMessage(ctx, chatID)
+			return
+		}
+	}
+	stats.SetGenreNames(genres)
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      stats.GetInfo(),
+		ParseMode: models.ParseModeMarkdown,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
@@ -2,15 +2,24 @@ package botkit
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log/slog"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
+	"whattowatch/internal/botkit/fsm"
 	"whattowatch/internal/config"
+	"whattowatch/internal/events"
 	"whattowatch/internal/types"
 	"whattowatch/internal/utils"
+	"whattowatch/internal/yearreview"
 
 	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 	"github.com/go-telegram/ui/slider"
 )
 
@@ -19,6 +28,9 @@ type (
 		GetMovie(ctx context.Context, id int) (types.ContentItem, error)
 		GetMoviePopular(ctx context.Context, page int) (types.Content, error)
 		GetMovieTop(ctx context.Context, page int) (types.Content, error)
+		GetMovieTrending(ctx context.Context, window types.TrendingWindow, page int) (types.Content, error)
+		GetMovieNowPlaying(ctx context.Context, page int) (types.Content, error)
+		GetMovieUpcoming(ctx context.Context, page int) (types.Content, error)
 		GetMoviesByGenre(ctx context.Context, genreIDs []int, page int) (types.Content, error)
 	}
 
@@ -26,9 +38,16 @@ type (
 		GetTV(ctx context.Context, id int) (types.ContentItem, error)
 		GetTVPopular(ctx context.Context, page int) (types.Content, error)
 		GetTVTop(ctx context.Context, page int) (types.Content, error)
+		GetTVTrending(ctx context.Context, window types.TrendingWindow, page int) (types.Content, error)
+		GetTVAiringToday(ctx context.Context, page int) (types.Content, error)
+		GetTVOnTheAir(ctx context.Context, page int) (types.Content, error)
 		GetTVsByGenre(ctx context.Context, genreIDs []int, page int) (types.Content, error)
 	}
 
+	CollectionProvider interface {
+		GetCollection(ctx context.Context, id int) (types.Collection, error)
+	}
+
 	GenreProvider interface {
 		GetGenres(ctx context.Context, contentType types.ContentType) (types.Genres, error)
 	}
@@ -37,6 +56,7 @@ type (
 		MovieProvider
 		TVProvider
 		GenreProvider
+		CollectionProvider
 
 		GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error)
 		GetRecommendations(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error)
@@ -45,6 +65,9 @@ type (
 
 	UserStorer interface {
 		InsertUser(ctx context.Context, user types.User) error
+		GetUserTakeout(ctx context.Context, userID int64) (types.UserTakeout, error)
+		DeleteUser(ctx context.Context, userID int64) error
+		PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
 	}
 
 	FavoriteStorer interface {
@@ -57,10 +80,87 @@ type (
 		GetViewedContentIDs(ctx context.Context, userID int64, contentType types.ContentType) ([]int64, error)
 		AddContentItemToViewed(ctx context.Context, userID int64, item types.ContentItem) error
 		RemoveContentItemFromViewed(ctx context.Context, userID int64, item types.ContentItem) error
+		SetLastWatchDate(ctx context.Context, userID int64, item types.ContentItem, watchedAt time.Time) error
+		SetRating(ctx context.Context, userID int64, item types.ContentItem, rating int) error
+		GetWatchHistory(ctx context.Context, userID int64, limit, offset int) (types.WatchHistory, error)
+		GetUserStats(ctx context.Context, userID int64, months int) (types.UserStats, error)
+		GetYearWatchedItems(ctx context.Context, userID int64, year int, limit int) ([]types.WatchedItem, error)
+	}
+
+	SettingsStorer interface {
+		GetUserSettings(ctx context.Context, userID int64) (types.UserSettings, error)
+		UpdateUserSettings(ctx context.Context, settings types.UserSettings) error
+	}
+
+	NoteStorer interface {
+		SetNote(ctx context.Context, userID int64, item types.ContentItem, text string) error
+		DeleteNote(ctx context.Context, userID int64, item types.ContentItem) error
+		GetNotes(ctx context.Context, userID int64, limit, offset int) (types.UserNotes, error)
+	}
+
+	ListStorer interface {
+		CreateList(ctx context.Context, userID int64, name string) (types.UserList, error)
+		RenameList(ctx context.Context, userID int64, listID int64, name string) error
+		DeleteList(ctx context.Context, userID int64, listID int64) error
+		SetListShareToken(ctx context.Context, userID int64, listID int64, token string) error
+		GetLists(ctx context.Context, userID int64) (types.UserLists, error)
+		GetList(ctx context.Context, userID int64, listID int64) (types.UserList, error)
+		GetSharedList(ctx context.Context, token string) (types.UserList, error)
+		AddListItem(ctx context.Context, userID int64, listID int64, item types.ContentItem) error
+		RemoveListItem(ctx context.Context, userID int64, listID int64, item types.ContentItem) error
+		GetListContentIDs(ctx context.Context, listID int64, contentType types.ContentType) ([]int64, error)
+		GetItemListIDs(ctx context.Context, userID int64, item types.ContentItem) ([]int64, error)
+	}
+
+	APITokenStorer interface {
+		SetAPIToken(ctx context.Context, userID int64, tokenHash string) error
+		GetAPITokenUserID(ctx context.Context, tokenHash string) (int64, error)
+	}
+
+	EventStorer interface {
+		InsertEvents(ctx context.Context, events []types.Event) error
+		GetFunnel(ctx context.Context, steps []types.EventType, since time.Time) (types.Funnel, error)
+		GetRetentionCohorts(ctx context.Context, since time.Time) (types.RetentionCohorts, error)
+		GetEventsUsage(ctx context.Context, eventTypes []types.EventType, since time.Time, limit int) (types.EventsUsage, error)
+	}
+
+	CommunityStorer interface {
+		RefreshCommunityFeed(ctx context.Context) error
+		GetCommunityFeedIDs(ctx context.Context, userID int64, contentType types.ContentType, limit int) ([]int64, error)
+	}
+
+	NeighborStorer interface {
+		RefreshContentNeighbors(ctx context.Context, minUsers int, topN int) (int64, error)
+		GetSimilarUsersContentIDs(ctx context.Context, userID int64, contentType types.ContentType, limit int) ([]int64, error)
+	}
+
+	SearchStorer interface {
+		SearchContent(ctx context.Context, query string, contentType types.ContentType, minSimilarity float64, limit int) (types.Content, error)
+	}
+
+	FSMStorer interface {
+		GetFSMSession(ctx context.Context, userID int64) ([]byte, error)
+		SaveFSMSession(ctx context.Context, userID int64, data []byte, expiresAt sql.NullTime) error
+		DeleteFSMSession(ctx context.Context, userID int64) error
+	}
+
+	RemovedStorer interface {
+		GetRemovedContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error)
+		GetRemovedListItems(ctx context.Context, limit int) (types.RemovedListItems, error)
 	}
 
 	Storer interface {
 		UserStorer
+		SettingsStorer
+		NoteStorer
+		ListStorer
+		APITokenStorer
+		EventStorer
+		CommunityStorer
+		NeighborStorer
+		SearchStorer
+		RemovedStorer
+		FSMStorer
 
 		FavoriteStorer
 		ViewedStorer
@@ -68,21 +168,31 @@ type (
 		GetContentStatus(ctx context.Context, userID int64, item types.ContentItem) (types.ContentStatus, error)
 	}
 
+	YearReviewRenderer interface {
+		Render(ctx context.Context, review types.YearReview) ([]byte, error)
+	}
+
 	TGBot struct {
-		storer Storer
-		api    DataProvider
+		storer   Storer
+		api      DataProvider
+		renderer YearReviewRenderer
 
 		bot *bot.Bot
 
 		log *slog.Logger
 		cfg *config.Config
 
+		fsm    *fsm.Machine
+		menus  []menu
+		events *events.Writer
+
 		userData map[int64]UserData
 		mu       sync.RWMutex
 	}
 )
 
-func New(cfg *config.Config, log *slog.Logger, storer Storer, api DataProvider) (*TGBot, error) {
+// New creates the bot, the middlewares are run before the bot ones, e.g. to collect the metrics.
+func New(cfg *config.Config, log *slog.Logger, storer Storer, api DataProvider, middlewares ...bot.Middleware) (*TGBot, error) {
 	tgbot := &TGBot{
 		storer: storer,
 		api:    api,
@@ -92,10 +202,21 @@ func New(cfg *config.Config, log *slog.Logger, storer Storer, api DataProvider)
 
 		userData: make(map[int64]UserData),
 	}
+	tgbot.events = events.NewWriter(tgbot.log, storer)
+
+	renderer, err := yearreview.New(yearreview.NewHTTPPosterFetcher(posterFetchTimeout))
+	if err != nil {
+		return nil, err
+	}
+	tgbot.renderer = renderer
+
+	tgbot.menus = tgbot.getMenus()
+	tgbot.fsm = tgbot.newStateMachine(fsm.NewStorerStore(storer))
 
 	opts := []bot.Option{
 		// bot.WithDebug(),
-		bot.WithMiddlewares(tgbot.userDataMiddleware),
+		bot.WithMiddlewares(append(middlewares, tgbot.eventsMiddleware, tgbot.userDataMiddleware)...),
+		bot.WithDefaultHandler(tgbot.defaultHandler),
 	}
 	b, err := bot.New(cfg.Tokens.TGBot, opts...)
 	if err != nil {
@@ -108,30 +229,68 @@ func New(cfg *config.Config, log *slog.Logger, storer Storer, api DataProvider)
 	return tgbot, nil
 }
 
+// Ping checks the bot token works with the Telegram Bot API.
+func (t *TGBot) Ping(ctx context.Context) error {
+	_, err := t.bot.GetMe(ctx)
+	return err
+}
+
 func (t *TGBot) Start() {
 	log := t.log.With("fn", "Start")
 	ctx := context.Background()
 	bot, err := t.bot.GetMe(ctx)
 	if err != nil {
-		log.Error("failed to get bot info", "error", err.Error())
+		log.ErrorContext(ctx, "failed to get bot info", "error", err.Error())
 		return
 	}
 	log.Info("starting bot", "bot_id", bot.ID)
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
 	defer cancel()
+	go t.runUserPurger(ctx)
+	go t.runCommunityFeedRefresher(ctx)
+	go t.runContentNeighborsRefresher(ctx)
+
+	// The events left in the buffer are flushed after the bot is stopped.
+	eventsDone := make(chan struct{})
+	go func() {
+		t.events.Run(ctx)
+		close(eventsDone)
+	}()
+
 	t.bot.Start(ctx)
+	<-eventsDone
 }
 
+// useHandlers registers the handlers of the commands and the callbacks. The menus, /menu, /search
+// and the input prompts are the transitions of the state machine run by userDataMiddleware.
 func (t *TGBot) useHandlers() {
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact, t.helpHandler)
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact, t.registerHandler)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/menu", bot.MatchTypeExact, t.handlerReplyKeyboard)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/search", bot.MatchTypePrefix, t.searchByTitleHandler)
-
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/f", bot.MatchTypePrefix, t.searchByIDHandler)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/t", bot.MatchTypePrefix, t.searchByIDHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/settings", bot.MatchTypeExact, t.settingsHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/forget", bot.MatchTypeExact, t.forgetHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/notes", bot.MatchTypeExact, t.notesHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/lists", bot.MatchTypeExact, t.listsHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/history", bot.MatchTypeExact, t.historyHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/mystats", bot.MatchTypeExact, t.myStatsHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/app", bot.MatchTypeExact, t.webAppHandler)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/token", bot.MatchTypeExact, t.tokenHandler)
+	t.bot.RegisterHandlerRegexp(bot.HandlerTypeMessageText, regexp.MustCompile(`^/year( \d{4})?$`), t.yearHandler)
+	t.bot.RegisterHandlerRegexp(bot.HandlerTypeMessageText, regexp.MustCompile(`^/admin_(funnel|retention|usage|removed)( \d+)?$`), t.adminHandler)
+
+	// Handlers are matched in random order, so the id commands are matched by regexp to not intercept /forget and the like.
+	t.bot.RegisterHandlerRegexp(bot.HandlerTypeMessageText, regexp.MustCompile(`^/[ft]\d+$`), t.searchByIDHandler)
+	t.bot.RegisterHandlerRegexp(bot.HandlerTypeMessageText, regexp.MustCompile(`^/c\d+$`), t.collectionHandler)
+	t.bot.RegisterHandlerRegexp(bot.HandlerTypeMessageText, regexp.MustCompile(`^/start `+sharedListPayload+`\w+$`), t.sharedListHandler)
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/gf", bot.MatchTypePrefix, t.onContentByGenreHandler(t.showMovieByGenre, MovieByGenre))
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/gt", bot.MatchTypePrefix, t.onContentByGenreHandler(t.showTVByGenre, TVByGenre))
+
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, contentActionPrefix, bot.MatchTypePrefix, t.onContentActionEvent)
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, settingsPrefix, bot.MatchTypePrefix, t.onSettingsEvent)
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, forgetPrefix, bot.MatchTypePrefix, t.onForgetEvent)
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, collectionPrefix, bot.MatchTypePrefix, t.onCollectionEvent)
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, listPrefix, bot.MatchTypePrefix, t.onListEvent)
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, historyPrefix, bot.MatchTypePrefix, t.onHistoryPageEvent)
+	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, notesPagePrefix, bot.MatchTypePrefix, t.onNotesPageEvent)
 }
 
 func (t *TGBot) sendErrorMessage(ctx context.Context, chatID int64) {
@@ -141,23 +300,97 @@ func (t *TGBot) sendErrorMessage(ctx context.Context, chatID int64) {
 	})
 }
 
-func (t *TGBot) generateSlider(content types.Content, opts []slider.Option) *slider.Slider {
+func (t *TGBot) answerCallbackQuery(ctx context.Context, queryID string, text string) {
+	_, err := t.bot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: queryID,
+		Text:            text,
+	})
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to answer callback query", "fn", "answerCallbackQuery", "error", err.Error())
+	}
+}
+
+func (t *TGBot) getContentItem(ctx context.Context, contentType types.ContentType, id int) (types.ContentItem, error) {
+	switch contentType {
+	case types.Movie:
+		return t.api.GetMovie(ctx, id)
+	case types.TV:
+		return t.api.GetTV(ctx, id)
+	}
+	return types.ContentItem{}, errors.New("unknown content type")
+}
+
+// sendContentItem sends the content card with the favorite/viewed buttons.
+func (t *TGBot) sendContentItem(ctx context.Context, chatID int64, item types.ContentItem, cs types.ContentStatus) error {
+	_, err := t.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:      chatID,
+		Photo:       &models.InputFileString{Data: item.BackdropPath},
+		Caption:     contentItemCaption(item, cs),
+		ParseMode:   models.ParseModeMarkdown,
+		ReplyMarkup: t.getContentActionKeyboard(item, cs),
+	})
+	return err
+}
+
+func contentItemCaption(item types.ContentItem, cs types.ContentStatus) string {
+	status := cs.GetInfo()
+	if status == "" {
+		return item.GetInfo()
+	}
+	return strings.TrimRight(item.GetInfo(), "\n") + "\n\n" + status
+}
+
+// applyUserSettings filters the feed content by the rating, adult and viewed user settings.
+func (t *TGBot) applyUserSettings(ctx context.Context, userID int64, contentType types.ContentType, content types.Content, settings types.UserSettings) (types.Content, error) {
+	content = settings.Filter(content)
+	if !settings.HideViewed {
+		return content, nil
+	}
+
+	viewedIDs, err := t.storer.GetViewedContentIDs(ctx, userID, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return content.RemoveByIDs(viewedIDs), nil
+}
+
+// maxSlides is the limit of the slider size, the rest of the content is not shown.
+const maxSlides = 100
+
+func (t *TGBot) generateSlider(ctx context.Context, content types.Content, opts []slider.Option) *slider.Slider {
+	return t.generateLabeledSlider(ctx, content, nil, opts)
+}
+
+// generateLabeledSlider generates the slider with the labels above the info of the titles by their ids, e.g. the source.
+func (t *TGBot) generateLabeledSlider(ctx context.Context, content types.Content, labels map[int64]string, opts []slider.Option) *slider.Slider {
 	log := t.log.With("fn", "generateSlider")
 	log.Debug("generating slides", "count", len(content))
 
-	limit := 100
+	limit := maxSlides
 	if len(content) > limit {
 		log.Info("too many slides.", "limit", limit, "count", len(content))
 		content = content[:limit]
 	}
 
+	layout := types.CardLayoutFull
+	if settings, ok := types.UserSettingsFromContext(ctx); ok {
+		layout = settings.CardLayout
+	}
+
 	slides := make([]slider.Slide, 0, limit)
 
 	for _, r := range content {
 		// log.Debug("generating slide", "title", r.Title, "short string", r.ShortString())
+		text := r.GetShortInfo()
+		if layout == types.CardLayoutCompact {
+			text = r.GetCompactInfo()
+		}
+		if label, ok := labels[r.ID]; ok {
+			text = label + "\n\n" + text
+		}
 		slides = append(slides, slider.Slide{
 			Photo: r.PosterPath,
-			Text:  utils.EscapeString(r.GetShortInfo()),
+			Text:  utils.EscapeString(text),
 		})
 	}
 
@@ -0,0 +1,46 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"whattowatch/internal/types"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// tokenHandler issues a new REST API token, the previous one is revoked.
+// Only the token hash is stored, so the token is shown once.
+func (t *TGBot) tokenHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "tokenHandler", "user_id", userID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	token, hash, err := types.NewAPIToken()
+	if err != nil {
+		log.ErrorContext(ctx, "failed to generate api token", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if err = t.storer.SetAPIToken(ctx, userID, hash); err != nil {
+		log.ErrorContext(ctx, "failed to set api token", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: fmt.Sprintf("Ваш токен для REST API:\n`%s`\n\n"+
+			"Передавайте его в заголовке `Authorization: Bearer <токен>`. "+
+			"Токен показывается только один раз, предыдущий токен больше не действует. "+
+			"Если токен утек, просто выпустите новый командой /token", token),
+		ParseMode: models.ParseModeMarkdownV1,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
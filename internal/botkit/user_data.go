@@ -2,8 +2,7 @@ package botkit
 
 import (
 	"whattowatch/internal/types"
-
-	"github.com/go-telegram/ui/keyboard/reply"
+	"whattowatch/internal/utils"
 )
 
 type Page int
@@ -15,16 +14,23 @@ const (
 	TVTop
 	MovieByGenre
 	TVByGenre
+	MovieTrending
+	MovieNowPlaying
+	MovieUpcoming
+	TVTrending
+	TVAiringToday
+	TVOnTheAir
 )
 
 type UserData struct {
-	replyKeyboard *reply.ReplyKeyboard
-
 	pagesMap      map[Page]int
 	selectedGenre map[types.ContentType]int
+	trendWindow   map[types.ContentType]types.TrendingWindow
+
+	settings types.UserSettings
 }
 
-func initUserData(kbFunc keyboardFunc) UserData {
+func initUserData(userID int64) UserData {
 	pagesMap := make(map[Page]int)
 	pagesMap[MoviePopular] = 1
 	pagesMap[MovieTop] = 1
@@ -32,12 +38,41 @@ func initUserData(kbFunc keyboardFunc) UserData {
 	pagesMap[TVTop] = 1
 	pagesMap[MovieByGenre] = 1
 	pagesMap[TVByGenre] = 1
+	pagesMap[MovieTrending] = 1
+	pagesMap[MovieNowPlaying] = 1
+	pagesMap[MovieUpcoming] = 1
+	pagesMap[TVTrending] = 1
+	pagesMap[TVAiringToday] = 1
+	pagesMap[TVOnTheAir] = 1
 
 	selectedGenre := make(map[types.ContentType]int)
 
+	trendWindow := make(map[types.ContentType]types.TrendingWindow)
+	trendWindow[types.Movie] = types.TrendingDay
+	trendWindow[types.TV] = types.TrendingDay
+
 	return UserData{
-		replyKeyboard: kbFunc(),
 		pagesMap:      pagesMap,
 		selectedGenre: selectedGenre,
+		trendWindow:   trendWindow,
+		settings:      types.DefaultUserSettings(userID),
+	}
+}
+
+// turnPage moves the user to the next page of the feed or back to the first one and returns the updated user data.
+func (t *TGBot) turnPage(userID int64, page Page, next bool) (UserData, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	userData, exists := t.userData[userID]
+	if !exists {
+		return UserData{}, false
+	}
+
+	if next {
+		userData.pagesMap[page] = utils.HandlePage(userData.pagesMap[page], "next")
+	} else {
+		userData.pagesMap[page] = 1
 	}
+	return userData, true
 }
@@ -0,0 +1,38 @@
+package botkit
+
+import (
+	"context"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// webAppHandler sends the button opening the Mini App with the user collections.
+func (t *TGBot) webAppHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "webAppHandler", "user_id", update.Message.From.ID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	if t.cfg.WebApp.URL == "" {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Приложение пока недоступно",
+		})
+		return
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Избранное, просмотренное и ваши списки в виде сетки постеров с поиском и сортировкой",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: "📱 Открыть приложение", WebApp: &models.WebAppInfo{URL: t.cfg.WebApp.URL}}},
+			},
+		},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send message", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
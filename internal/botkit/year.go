@@ -0,0 +1,87 @@
+package botkit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"whattowatch/internal/types"
+	"whattowatch/internal/yearreview"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	posterFetchTimeout = 10 * time.Second
+	// yearItemsLimit limits the titles fetched from the data provider for the year review.
+	yearItemsLimit = 200
+)
+
+func (t *TGBot) yearHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+
+	log := t.log.With("fn", "yearHandler", "user_id", userID, "chat_id", chatID)
+	log.Debug("handler func start log")
+
+	year := time.Now().Year()
+	if arg := strings.TrimSpace(strings.TrimPrefix(update.Message.Text, "/year")); arg != "" {
+		var err error
+		if year, err = strconv.Atoi(arg); err != nil {
+			log.ErrorContext(ctx, "failed to parse year", "error", err.Error())
+			t.sendErrorMessage(ctx, chatID)
+			return
+		}
+	}
+
+	items, err := t.storer.GetYearWatchedItems(ctx, userID, year, yearItemsLimit)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get year watched items", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	if len(items) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("В %d году вы еще ничего не отметили просмотренным", year),
+		})
+		return
+	}
+
+	ids := make(map[types.ContentType][]int64)
+	for _, item := range items {
+		ids[item.ContentType] = append(ids[item.ContentType], item.ContentID)
+	}
+
+	content := make(types.Content, 0, len(items))
+	for contentType, contentIDs := range ids {
+		c, err := t.api.GetContent(ctx, contentType, contentIDs)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to get content", "content_type", contentType, "error", err.Error())
+			t.sendErrorMessage(ctx, chatID)
+			return
+		}
+		content = append(content, c...)
+	}
+
+	data, err := t.renderer.Render(ctx, types.NewYearReview(year, items, content, yearreview.PostersLimit))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to render year review", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+		return
+	}
+
+	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:  chatID,
+		Photo:   &models.InputFileUpload{Filename: fmt.Sprintf("year_%d.png", year), Data: bytes.NewReader(data)},
+		Caption: fmt.Sprintf("Ваш %d год в кино. Подробнее: /mystats", year),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send photo", "error", err.Error())
+		t.sendErrorMessage(ctx, chatID)
+	}
+}
@@ -0,0 +1,115 @@
+// Package catalog serves the title details from the local catalog while they are fresh.
+// The details of the titles the users interact with are kept filled by the enrichment worker,
+// so the list views don't re-fetch them from TMDb one by one.
+package catalog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/config"
+	"whattowatch/internal/types"
+)
+
+type Storer interface {
+	GetContentDetails(ctx context.Context, contentType types.ContentType, ids []int64, language string, updatedAfter time.Time) (types.Content, error)
+	UpsertContentDetails(ctx context.Context, content types.Content, language string) error
+	GetStaleContentIDs(ctx context.Context, contentType types.ContentType, language string, updatedBefore time.Time, limit int) ([]int64, error)
+}
+
+// DataProvider is the botkit.DataProvider decorator serving GetContent from the local catalog.
+// The catalog keeps the details in the default language only, the other languages are always fetched.
+type DataProvider struct {
+	botkit.DataProvider
+
+	storer Storer
+	cfg    config.Catalog
+	log    *slog.Logger
+
+	now func() time.Time
+}
+
+var _ botkit.DataProvider = (*DataProvider)(nil)
+
+func NewDataProvider(log *slog.Logger, api botkit.DataProvider, storer Storer, cfg config.Catalog) *DataProvider {
+	return &DataProvider{
+		DataProvider: api,
+
+		storer: storer,
+		cfg:    cfg,
+		log:    log.With("pkg", "catalog"),
+
+		now: time.Now,
+	}
+}
+
+// GetContent returns the fresh titles from the catalog and fetches the rest, the fetched ones are stored.
+// The catalog errors are logged and the titles are fetched as if there was no catalog.
+func (p *DataProvider) GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error) {
+	log := p.log.With("fn", "GetContent", "content_type", contentType)
+
+	language := types.DefaultLanguage
+	if settings, ok := types.UserSettingsFromContext(ctx); ok && settings.Language != "" {
+		language = settings.Language
+	}
+	if language != types.DefaultLanguage {
+		return p.DataProvider.GetContent(ctx, contentType, ids)
+	}
+
+	local, err := p.storer.GetContentDetails(ctx, contentType, ids, language, p.now().Add(-p.cfg.MaxAge))
+	if err != nil {
+		log.WarnContext(ctx, "failed to get content details", "error", err.Error())
+		local = nil
+	}
+	if err = p.fillGenreNames(ctx, contentType, local); err != nil {
+		log.WarnContext(ctx, "failed to get genres", "error", err.Error())
+	}
+
+	fresh := make(map[int64]struct{}, len(local))
+	for _, item := range local {
+		fresh[item.ID] = struct{}{}
+	}
+	missingIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := fresh[id]; !ok {
+			missingIDs = append(missingIDs, id)
+		}
+	}
+	if len(missingIDs) == 0 {
+		return local, nil
+	}
+
+	fetched, err := p.DataProvider.GetContent(ctx, contentType, missingIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.storer.UpsertContentDetails(ctx, fetched, language); err != nil {
+		log.WarnContext(ctx, "failed to store content details", "error", err.Error())
+	}
+
+	return append(local, fetched...), nil
+}
+
+// fillGenreNames sets the names of the genres, the catalog keeps their ids only.
+func (p *DataProvider) fillGenreNames(ctx context.Context, contentType types.ContentType, content types.Content) error {
+	if len(content) == 0 {
+		return nil
+	}
+
+	genres, err := p.DataProvider.GetGenres(ctx, contentType)
+	if err != nil {
+		return err
+	}
+	names := make(map[int64]string, len(genres))
+	for _, g := range genres {
+		names[g.ID] = g.Name
+	}
+
+	for i := range content {
+		for j := range content[i].Genres {
+			content[i].Genres[j].Name = names[content[i].Genres[j].ID]
+		}
+	}
+	return nil
+}
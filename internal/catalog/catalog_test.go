@@ -0,0 +1,173 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/config"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPI struct {
+	botkit.DataProvider
+
+	fetched [][]int64
+	err     error
+	// failID fails any batch with the id, like a title removed from TMDb.
+	failID int64
+}
+
+func (a *fakeAPI) GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error) {
+	a.fetched = append(a.fetched, ids)
+	if a.err != nil {
+		return nil, a.err
+	}
+	if a.failID != 0 && slices.Contains(ids, a.failID) {
+		return nil, errors.New("not found")
+	}
+	content := make(types.Content, 0, len(ids))
+	for _, id := range ids {
+		content = append(content, types.ContentItem{ID: id, ContentType: contentType, Title: "tmdb"})
+	}
+	return content, nil
+}
+
+func (a *fakeAPI) GetGenres(ctx context.Context, contentType types.ContentType) (types.Genres, error) {
+	return types.Genres{{ID: 18, Name: "драма"}}, nil
+}
+
+type fakeStorer struct {
+	Storer
+
+	local  types.Content
+	err    error
+	stored types.Content
+}
+
+func (s *fakeStorer) GetContentDetails(ctx context.Context, contentType types.ContentType, ids []int64, language string, updatedAfter time.Time) (types.Content, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	content := make(types.Content, 0)
+	for _, item := range s.local {
+		if slices.Contains(ids, item.ID) {
+			content = append(content, item)
+		}
+	}
+	return content, nil
+}
+
+func (s *fakeStorer) UpsertContentDetails(ctx context.Context, content types.Content, language string) error {
+	s.stored = append(s.stored, content...)
+	return nil
+}
+
+func Test_GetContent(t *testing.T) {
+	local := types.Content{{ID: 1, Title: "local", Genres: types.Genres{{ID: 18}}}}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		storer      *fakeStorer
+		ids         []int64
+		wantTitles  map[int64]string
+		wantFetched [][]int64
+		wantStored  []int64
+	}{
+		{
+			name:        "all fresh",
+			ctx:         context.Background(),
+			storer:      &fakeStorer{local: local},
+			ids:         []int64{1},
+			wantTitles:  map[int64]string{1: "local"},
+			wantFetched: nil,
+		},
+		{
+			name:        "missing fetched and stored",
+			ctx:         context.Background(),
+			storer:      &fakeStorer{local: local},
+			ids:         []int64{1, 2, 3},
+			wantTitles:  map[int64]string{1: "local", 2: "tmdb", 3: "tmdb"},
+			wantFetched: [][]int64{{2, 3}},
+			wantStored:  []int64{2, 3},
+		},
+		{
+			name:        "catalog error",
+			ctx:         context.Background(),
+			storer:      &fakeStorer{local: local, err: errors.New("connection refused")},
+			ids:         []int64{1},
+			wantTitles:  map[int64]string{1: "tmdb"},
+			wantFetched: [][]int64{{1}},
+			wantStored:  []int64{1},
+		},
+		{
+			name:        "other language",
+			ctx:         types.ContextWithUserSettings(context.Background(), types.UserSettings{Language: "en-US"}),
+			storer:      &fakeStorer{local: local},
+			ids:         []int64{1},
+			wantTitles:  map[int64]string{1: "tmdb"},
+			wantFetched: [][]int64{{1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{}
+			p := NewDataProvider(slog.New(slog.NewTextHandler(io.Discard, nil)), api, tt.storer, config.Catalog{MaxAge: time.Hour})
+
+			content, err := p.GetContent(tt.ctx, types.Movie, tt.ids)
+			assert.NoError(t, err)
+
+			titles := make(map[int64]string, len(content))
+			for _, item := range content {
+				titles[item.ID] = item.Title
+			}
+			assert.Equal(t, tt.wantTitles, titles)
+			assert.Equal(t, tt.wantFetched, api.fetched)
+			if tt.wantStored == nil {
+				assert.Empty(t, tt.storer.stored)
+			} else {
+				assert.Equal(t, tt.wantStored, tt.storer.stored.IDs())
+			}
+		})
+	}
+}
+
+func Test_GetContent_GenreNames(t *testing.T) {
+	storer := &fakeStorer{local: types.Content{{ID: 1, Genres: types.Genres{{ID: 18}}}}}
+	p := NewDataProvider(slog.New(slog.NewTextHandler(io.Discard, nil)), &fakeAPI{}, storer, config.Catalog{MaxAge: time.Hour})
+
+	content, err := p.GetContent(context.Background(), types.Movie, []int64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, "драма", content[0].Genres.String())
+}
+
+func Test_Enrich(t *testing.T) {
+	api := &fakeAPI{err: errors.New("not found")}
+	e := NewEnricher(slog.New(slog.NewTextHandler(io.Discard, nil)), api, &fakeStorer{}, config.Catalog{MaxAge: time.Hour})
+
+	// The failed batch is fetched one by one, all of them fail here.
+	content := e.fetch(context.Background(), types.Movie, []int64{1, 2})
+	assert.Empty(t, content)
+	assert.Equal(t, [][]int64{{1, 2}, {1}, {2}}, api.fetched)
+}
+
+func Test_EnrichFailedTitle(t *testing.T) {
+	api := &fakeAPI{failID: 2}
+	e := NewEnricher(slog.New(slog.NewTextHandler(io.Discard, nil)), api, &fakeStorer{}, config.Catalog{MaxAge: time.Hour})
+
+	content := e.fetch(context.Background(), types.Movie, []int64{1, 2, 3})
+	ids := make([]int64, 0, len(content))
+	for _, item := range content {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int64{1, 3}, ids)
+	assert.Equal(t, [][]int64{{1, 2, 3}, {1}, {2}, {3}}, api.fetched)
+}
@@ -0,0 +1,104 @@
+package catalog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"whattowatch/internal/config"
+	"whattowatch/internal/types"
+)
+
+// enrichBatchSize limits the titles of each type enriched per run, they are fetched from TMDb one by one.
+const enrichBatchSize = 100
+
+type ContentProvider interface {
+	GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error)
+}
+
+// Enricher fills the missing and refreshes the stale details of the titles the users interact with.
+type Enricher struct {
+	api    ContentProvider
+	storer Storer
+	cfg    config.Catalog
+	log    *slog.Logger
+
+	now func() time.Time
+}
+
+func NewEnricher(log *slog.Logger, api ContentProvider, storer Storer, cfg config.Catalog) *Enricher {
+	return &Enricher{
+		api:    api,
+		storer: storer,
+		cfg:    cfg,
+		log:    log.With("pkg", "catalog"),
+
+		now: time.Now,
+	}
+}
+
+// Run enriches the catalog every interval until the context is done.
+func (e *Enricher) Run(ctx context.Context) {
+	log := e.log.With("fn", "Run", "interval", e.cfg.EnrichInterval)
+
+	ticker := time.NewTicker(e.cfg.EnrichInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := e.Enrich(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to enrich catalog", "error", err.Error())
+		} else if count > 0 {
+			log.Info("catalog enriched", "count", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enrich stores the details of a batch of the stale titles of each type and returns their number.
+func (e *Enricher) Enrich(ctx context.Context) (int, error) {
+	count := 0
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		ids, err := e.storer.GetStaleContentIDs(ctx, contentType, types.DefaultLanguage, e.now().Add(-e.cfg.MaxAge), enrichBatchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		content := e.fetch(ctx, contentType, ids)
+		if err = e.storer.UpsertContentDetails(ctx, content, types.DefaultLanguage); err != nil {
+			return count, err
+		}
+		count += len(content)
+	}
+	return count, nil
+}
+
+// fetch gets the details of the titles by a single batch. A failed batch is fetched title by title,
+// so a title removed from TMDb doesn't block the rest.
+func (e *Enricher) fetch(ctx context.Context, contentType types.ContentType, ids []int64) types.Content {
+	log := e.log.With("fn", "fetch", "content_type", contentType)
+
+	content, err := e.api.GetContent(ctx, contentType, ids)
+	if err == nil {
+		return content
+	}
+	log.WarnContext(ctx, "failed to get content batch, fetching one by one", "error", err.Error())
+
+	content = make(types.Content, 0, len(ids))
+	for _, id := range ids {
+		c, err := e.api.GetContent(ctx, contentType, []int64{id})
+		if err != nil {
+			log.WarnContext(ctx, "failed to get content", "id", id, "error", err.Error())
+			continue
+		}
+		content = append(content, c...)
+	}
+	return content
+}
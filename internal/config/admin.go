@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Admin struct {
+	// UserIDs are the Telegram users allowed to run the /admin_* commands.
+	UserIDs []int64
+}
+
+func NewAdmin() (Admin, error) {
+	var ids []int64
+	for _, s := range strings.Split(os.Getenv("ADMIN_IDS"), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return Admin{}, fmt.Errorf("failed to parse ADMIN_IDS: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	return Admin{UserIDs: ids}, nil
+}
+
+func (a Admin) IsAdmin(userID int64) bool {
+	return slices.Contains(a.UserIDs, userID)
+}
@@ -0,0 +1,14 @@
+package config
+
+import "os"
+
+type API struct {
+	// Addr is the address the REST API server listens on, e.g. ":8081".
+	Addr string
+}
+
+func NewAPI() API {
+	return API{
+		Addr: os.Getenv("API_ADDR"),
+	}
+}
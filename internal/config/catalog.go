@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+type Catalog struct {
+	// MaxAge is the age of the title details the local catalog serves them until.
+	MaxAge time.Duration
+	// EnrichInterval is how often the bot fills the missing and refreshes the stale details of the titles users interact with.
+	EnrichInterval time.Duration
+}
+
+const (
+	defaultCatalogMaxAge         = 7 * 24 * time.Hour
+	defaultCatalogEnrichInterval = 10 * time.Minute
+)
+
+func NewCatalog() (Catalog, error) {
+	maxAge, err := getDuration("CATALOG_MAX_AGE", defaultCatalogMaxAge)
+	if err != nil {
+		return Catalog{}, err
+	}
+
+	enrichInterval, err := getDuration("CATALOG_ENRICH_INTERVAL", defaultCatalogEnrichInterval)
+	if err != nil {
+		return Catalog{}, err
+	}
+
+	return Catalog{
+		MaxAge:         maxAge,
+		EnrichInterval: enrichInterval,
+	}, nil
+}
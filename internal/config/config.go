@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,8 +16,29 @@ type Config struct {
 	DB      DBConfig
 	Tokens  Tokens
 	Urls    Urls
+	WebApp  WebApp
+	API     API
+	Metrics Metrics
+	Tracing Tracing
+	Health  Health
+	Loader  Loader
+	Admin   Admin
+	Catalog Catalog
+
+	// UserRetention is how long soft-deleted users are kept before the hard deletion.
+	UserRetention time.Duration
+	// CommunityFeedInterval is how often the bot refreshes the feed of the titles popular with its users.
+	CommunityFeedInterval time.Duration
+	// ContentNeighborsInterval is how often the bot recomputes the similar titles by the co-occurrence in the users data.
+	ContentNeighborsInterval time.Duration
 }
 
+const (
+	defaultUserRetention            = 30 * 24 * time.Hour
+	defaultCommunityFeedInterval    = time.Hour
+	defaultContentNeighborsInterval = 6 * time.Hour
+)
+
 // MustLoad load configuration.
 func MustLoad(filenames ...string) (*Config, error) {
 	err := godotenv.Load(filenames...)
@@ -22,6 +46,36 @@ func MustLoad(filenames ...string) (*Config, error) {
 		return nil, err
 	}
 
+	userRetention, err := getDuration("USER_RETENTION", defaultUserRetention)
+	if err != nil {
+		return nil, err
+	}
+
+	communityFeedInterval, err := getDuration("COMMUNITY_FEED_INTERVAL", defaultCommunityFeedInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	contentNeighborsInterval, err := getDuration("CONTENT_NEIGHBORS_INTERVAL", defaultContentNeighborsInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	loader, err := NewLoader()
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := NewAdmin()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := NewCatalog()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		BotName: os.Getenv("BOT_NAME"),
 		Env:     os.Getenv("ENV"),
@@ -29,7 +83,58 @@ func MustLoad(filenames ...string) (*Config, error) {
 		DB:      NewDBConfig(),
 		Tokens:  NewTokens(),
 		Urls:    NewUrls(),
+		WebApp:  NewWebApp(),
+		API:     NewAPI(),
+		Metrics: NewMetrics(),
+		Tracing: NewTracing(),
+		Health:  NewHealth(),
+		Loader:  loader,
+		Admin:   admin,
+		Catalog: catalog,
+
+		UserRetention:            userRetention,
+		CommunityFeedInterval:    communityFeedInterval,
+		ContentNeighborsInterval: contentNeighborsInterval,
 	}
 
 	return cfg, nil
 }
+
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %s", key, err.Error())
+	}
+	return d, nil
+}
+
+func getFloat(key string, defaultValue float64) (float64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %s", key, err.Error())
+	}
+	return f, nil
+}
+
+func getInt(key string, defaultValue int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %s", key, err.Error())
+	}
+	return i, nil
+}
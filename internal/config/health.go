@@ -0,0 +1,14 @@
+package config
+
+import "os"
+
+type Health struct {
+	// Addr is the address of the /healthz and /readyz endpoints, e.g. ":8082". Disabled if empty.
+	Addr string
+}
+
+func NewHealth() Health {
+	return Health{
+		Addr: os.Getenv("HEALTH_ADDR"),
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+type Loader struct {
+	// Interval is how often the loader runs as a service, it runs once and exits if zero.
+	Interval time.Duration
+	// MaxRunAge is the age of the last successful run the loader is still ready with.
+	MaxRunAge time.Duration
+	// FullInterval is how often the daily export is loaded, the runs between load the changes only.
+	// Every run is full if zero.
+	FullInterval time.Duration
+	// MaxRemovedPercent is the share of the titles of a type missing from the full export
+	// the loader still marks removed, the run fails above it.
+	MaxRemovedPercent float64
+	// Workers is the number of the parallel workers copying the export batches of each type.
+	Workers int
+}
+
+const (
+	defaultLoaderMaxRunAge    = 48 * time.Hour
+	defaultLoaderFullInterval = 7 * 24 * time.Hour
+	defaultLoaderMaxRemoved   = 5.0
+	defaultLoaderWorkers      = 4
+)
+
+func NewLoader() (Loader, error) {
+	interval, err := getDuration("LOADER_INTERVAL", 0)
+	if err != nil {
+		return Loader{}, err
+	}
+
+	maxRunAge, err := getDuration("LOADER_MAX_RUN_AGE", defaultLoaderMaxRunAge)
+	if err != nil {
+		return Loader{}, err
+	}
+
+	fullInterval, err := getDuration("LOADER_FULL_INTERVAL", defaultLoaderFullInterval)
+	if err != nil {
+		return Loader{}, err
+	}
+
+	maxRemovedPercent, err := getFloat("LOADER_MAX_REMOVED_PERCENT", defaultLoaderMaxRemoved)
+	if err != nil {
+		return Loader{}, err
+	}
+
+	workers, err := getInt("LOADER_WORKERS", defaultLoaderWorkers)
+	if err != nil {
+		return Loader{}, err
+	}
+	if workers < 1 {
+		return Loader{}, fmt.Errorf("LOADER_WORKERS must be positive, got %d", workers)
+	}
+
+	return Loader{
+		Interval:          interval,
+		MaxRunAge:         maxRunAge,
+		FullInterval:      fullInterval,
+		MaxRemovedPercent: maxRemovedPercent,
+		Workers:           workers,
+	}, nil
+}
@@ -0,0 +1,17 @@
+package config
+
+import "os"
+
+type Metrics struct {
+	// Addr is the address the Prometheus /metrics endpoint listens on, e.g. ":9090". Disabled if empty.
+	Addr string
+	// PushgatewayURL is the Pushgateway the loader pushes its metrics to after the run. Disabled if empty.
+	PushgatewayURL string
+}
+
+func NewMetrics() Metrics {
+	return Metrics{
+		Addr:           os.Getenv("METRICS_ADDR"),
+		PushgatewayURL: os.Getenv("METRICS_PUSHGATEWAY_URL"),
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "os"
+
+const (
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+type Tracing struct {
+	// Exporter is where the spans are exported: "otlp", "stdout" or nowhere if empty.
+	Exporter string
+	// OTLPEndpoint is the OTLP/HTTP collector URL, e.g. "http://localhost:4318".
+	// The standard OTEL_EXPORTER_OTLP_* variables are used if empty.
+	OTLPEndpoint string
+}
+
+func NewTracing() Tracing {
+	return Tracing{
+		Exporter:     os.Getenv("TRACING_EXPORTER"),
+		OTLPEndpoint: os.Getenv("TRACING_OTLP_ENDPOINT"),
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+import "os"
+
+type WebApp struct {
+	// Addr is the address the Mini App server listens on, e.g. ":8080". The server is disabled if empty.
+	Addr string
+	// URL is the public HTTPS address of the Mini App opened by the bot.
+	URL string
+}
+
+func NewWebApp() WebApp {
+	return WebApp{
+		Addr: os.Getenv("WEBAPP_ADDR"),
+		URL:  os.Getenv("WEBAPP_URL"),
+	}
+}
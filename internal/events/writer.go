@@ -0,0 +1,147 @@
+// Package events writes the user actions to the append-only event log asynchronously,
+// so the bot handlers never wait for the storage.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	"whattowatch/internal/types"
+)
+
+const (
+	defaultBufferSize    = 1000
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+
+	flushTimeout = 10 * time.Second
+	// forgetTTL is how long the forgotten users are kept, the queued events are flushed long before.
+	forgetTTL = time.Minute
+)
+
+type Storer interface {
+	InsertEvents(ctx context.Context, events []types.Event) error
+}
+
+// Writer buffers the events and inserts them by batches. The events are dropped if the buffer is full.
+type Writer struct {
+	storer Storer
+	log    *slog.Logger
+
+	events        chan types.Event
+	batchSize     int
+	flushInterval time.Duration
+
+	// forgotten are the times the users were forgotten at, their events recorded before are dropped.
+	forgotten map[int64]time.Time
+	mu        sync.Mutex
+
+	now func() time.Time
+}
+
+func NewWriter(log *slog.Logger, storer Storer) *Writer {
+	return &Writer{
+		storer: storer,
+		log:    log.With("pkg", "events"),
+
+		events:        make(chan types.Event, defaultBufferSize),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+
+		forgotten: make(map[int64]time.Time),
+
+		now: time.Now,
+	}
+}
+
+// Record queues the event without blocking, the creation time is set if it is zero.
+func (w *Writer) Record(event types.Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = w.now()
+	}
+
+	select {
+	case w.events <- event:
+	default:
+		w.log.Warn("events buffer is full, event dropped", "fn", "Record", "type", event.Type, "user_id", event.UserID)
+	}
+}
+
+// Forget drops the queued events of the user recorded until now, so they are not inserted after the account is deleted.
+func (w *Writer) Forget(userID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.forgotten[userID] = w.now()
+}
+
+// Run inserts the queued events by batches or every flush interval until the context is done,
+// then the events left are flushed.
+func (w *Writer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]types.Event, 0, w.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-w.events:
+					batch = append(batch, event)
+				default:
+					w.flush(context.WithoutCancel(ctx), batch)
+					return
+				}
+			}
+		case event := <-w.events:
+			batch = append(batch, event)
+			if len(batch) >= w.batchSize {
+				batch = w.flush(ctx, batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(ctx, batch)
+		}
+	}
+}
+
+// flush inserts the batch and returns it emptied. The failed batch is dropped to not grow the memory.
+func (w *Writer) flush(ctx context.Context, batch []types.Event) []types.Event {
+	batch = w.dropForgotten(batch)
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	if err := w.storer.InsertEvents(ctx, batch); err != nil {
+		w.log.ErrorContext(ctx, "failed to insert events", "fn", "flush", "count", len(batch), "error", err.Error())
+	}
+	return batch[:0]
+}
+
+// dropForgotten removes the events of the forgotten users recorded before they were forgotten.
+func (w *Writer) dropForgotten(batch []types.Event) []types.Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for userID, at := range w.forgotten {
+		if w.now().Sub(at) > forgetTTL {
+			delete(w.forgotten, userID)
+		}
+	}
+	if len(w.forgotten) == 0 {
+		return batch
+	}
+
+	kept := batch[:0]
+	for _, event := range batch {
+		if at, ok := w.forgotten[event.UserID]; ok && !event.CreatedAt.After(at) {
+			continue
+		}
+		kept = append(kept, event)
+	}
+	return kept
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStorer struct {
+	mu      sync.Mutex
+	batches [][]types.Event
+}
+
+func (s *fakeStorer) InsertEvents(ctx context.Context, events []types.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]types.Event(nil), events...))
+	return nil
+}
+
+func (s *fakeStorer) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, 0, len(s.batches))
+	for _, batch := range s.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func newTestWriter(storer Storer, bufferSize int) *Writer {
+	w := NewWriter(slog.New(slog.NewTextHandler(io.Discard, nil)), storer)
+	w.events = make(chan types.Event, bufferSize)
+	w.batchSize = 2
+	w.flushInterval = time.Hour
+	return w
+}
+
+func Test_Writer(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+		events     int
+		want       []int
+	}{
+		{name: "batches and the rest on shutdown", bufferSize: 10, events: 5, want: []int{2, 2, 1}},
+		{name: "full buffer drops", bufferSize: 3, events: 5, want: []int{2, 1}},
+		{name: "no events", bufferSize: 3, events: 0, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storer := &fakeStorer{}
+			w := newTestWriter(storer, tt.bufferSize)
+
+			// The events are recorded before the writer runs, so the buffer overflows deterministically.
+			for i := range tt.events {
+				w.Record(types.Event{UserID: int64(i), Type: types.EventCommand})
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				w.Run(ctx)
+				close(done)
+			}()
+
+			assert.Eventually(t, func() bool { return len(w.events) == 0 }, time.Second, time.Millisecond)
+			cancel()
+			<-done
+
+			assert.Equal(t, tt.want, storer.sizes())
+		})
+	}
+}
+
+func Test_Writer_Record(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	w := newTestWriter(&fakeStorer{}, 2)
+	w.now = func() time.Time { return now }
+
+	createdAt := now.Add(-time.Minute)
+	w.Record(types.Event{Type: types.EventSearch})
+	w.Record(types.Event{Type: types.EventCardOpen, CreatedAt: createdAt})
+
+	assert.Equal(t, now, (<-w.events).CreatedAt)
+	assert.Equal(t, createdAt, (<-w.events).CreatedAt)
+}
+
+func Test_Writer_Forget(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	storer := &fakeStorer{}
+	w := newTestWriter(storer, 10)
+	w.now = func() time.Time { return now }
+
+	batch := []types.Event{
+		{UserID: 1, Type: types.EventCommand, Name: "/forget", CreatedAt: now.Add(-time.Second)},
+		{UserID: 2, Type: types.EventCommand, Name: "/menu", CreatedAt: now.Add(-time.Second)},
+		{UserID: 1, Type: types.EventCommand, Name: "/start", CreatedAt: now.Add(time.Second)},
+	}
+	w.Forget(1)
+	w.flush(context.Background(), batch)
+
+	names := make([]string, 0)
+	for _, event := range storer.batches[0] {
+		names = append(names, event.Name)
+	}
+	assert.Equal(t, []string{"/menu", "/start"}, names)
+
+	// The forgotten user is kept for a while only.
+	now = now.Add(forgetTTL + time.Second)
+	w.flush(context.Background(), nil)
+	assert.Empty(t, w.forgotten)
+}
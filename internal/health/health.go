@@ -0,0 +1,154 @@
+// Package health serves the liveness /healthz and the readiness /readyz endpoints.
+// The readiness runs the dependency checks and reports each of them.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	checkTimeout    = 5 * time.Second
+	shutdownTimeout = 5 * time.Second
+)
+
+// CheckFunc checks the dependency, the detail is reported along with the status, e.g. the age of the last run.
+type CheckFunc func(ctx context.Context) (detail string, err error)
+
+// Result is the dependency check result.
+type Result struct {
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the readiness response.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+type Checker struct {
+	checks []check
+	log    *slog.Logger
+}
+
+func New(log *slog.Logger) *Checker {
+	return &Checker{log: log.With("pkg", "health")}
+}
+
+// Add adds the named readiness check.
+func (c *Checker) Add(name string, fn CheckFunc) *Checker {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+	return c
+}
+
+// Check runs all the checks concurrently, the report is ok if all of them passed.
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			detail, err := ch.fn(ctx)
+			res := Result{Status: StatusOK, Detail: detail, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				res.Status = StatusFail
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[ch.name] = res
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+			c.log.Warn("not ready", "fn", "readyz", "checks", report.Checks)
+		}
+		writeJSON(w, status, report)
+	})
+
+	return mux
+}
+
+// Serve serves the endpoints until the context is done.
+func (c *Checker) Serve(ctx context.Context, addr string) error {
+	log := c.log.With("fn", "Serve")
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           c.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to shutdown server", "error", err.Error())
+		}
+	}()
+
+	log.Info("starting health server", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Ping adapts the dependency ping to the check.
+func Ping(ping func(ctx context.Context) error) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		return "", ping(ctx)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Handler(t *testing.T) {
+	ok := func(ctx context.Context) (string, error) { return "fine", nil }
+	fail := func(ctx context.Context) (string, error) { return "", errors.New("connection refused") }
+
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		path       string
+		wantStatus int
+		wantReport Report
+	}{
+		{
+			name:       "alive",
+			checks:     map[string]CheckFunc{"postgres": fail},
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "ready",
+			checks:     map[string]CheckFunc{"postgres": ok, "telegram": ok},
+			path:       "/readyz",
+			wantStatus: http.StatusOK,
+			wantReport: Report{Status: StatusOK, Checks: map[string]Result{
+				"postgres": {Status: StatusOK, Detail: "fine"},
+				"telegram": {Status: StatusOK, Detail: "fine"},
+			}},
+		},
+		{
+			name:       "not ready",
+			checks:     map[string]CheckFunc{"postgres": ok, "telegram": fail},
+			path:       "/readyz",
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: Report{Status: StatusFail, Checks: map[string]Result{
+				"postgres": {Status: StatusOK, Detail: "fine"},
+				"telegram": {Status: StatusFail, Error: "connection refused"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+			for name, fn := range tt.checks {
+				c.Add(name, fn)
+			}
+
+			w := httptest.NewRecorder()
+			c.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantReport.Status == "" {
+				return
+			}
+			var report Report
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+			for name, res := range report.Checks {
+				res.DurationMS = 0
+				report.Checks[name] = res
+			}
+			assert.Equal(t, tt.wantReport, report)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package loader
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"testing"
+	"time"
+	"whattowatch/internal/config"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/types"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// benchDSNEnv is the DSN of a scratch migrated database the benchmark writes the generated titles to.
+	benchDSNEnv = "BENCH_POSTGRES_DSN"
+	benchItems  = 100_000
+	// benchIDOffset keeps the generated ids away from the real ones.
+	benchIDOffset = 1_000_000_000
+)
+
+// BenchmarkLoadExport compares the serial multi-VALUES insert of the export batches
+// with the parallel copy to the staging and the single merge on a generated export file.
+//
+//	BENCH_POSTGRES_DSN=postgres://... go test ./internal/loader -run '^$' -bench LoadExport -benchtime 3x
+func BenchmarkLoadExport(b *testing.B) {
+	dsn := os.Getenv(benchDSNEnv)
+	if dsn == "" {
+		b.Skipf("%s is not set", benchDSNEnv)
+	}
+
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pg, err := postgresql.New(&config.Config{DB: config.DBConfig{PostgresDSN: dsn}}, log)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Close()
+
+	filepath := path.Join(b.TempDir(), "movie_ids.json.gz")
+	if err = writeExport(filepath, benchItems); err != nil {
+		b.Fatal(err)
+	}
+
+	cleanup := func(b *testing.B) {
+		b.StopTimer()
+		defer b.StartTimer()
+		_, err := pool.Exec(ctx, "DELETE FROM content WHERE content_type_id = $1 AND id >= $2", types.Movie.ID(), benchIDOffset)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("insert", func(b *testing.B) {
+		l := &TMDbLoader{log: log}
+		for range b.N {
+			cleanup(b)
+			if err := insertSerial(ctx, pg, l.readData(filepath, types.Movie), time.Now()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("copy/workers=%d", workers), func(b *testing.B) {
+			l := &TMDbLoader{log: log, storer: pg, workers: workers}
+			for range b.N {
+				cleanup(b)
+				if err := l.insertData(ctx, types.Movie, l.readData(filepath, types.Movie), batchSize, time.Now()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	cleanup(b)
+}
+
+// insertSerial is the previous load path inserting the batches one by one.
+func insertSerial(ctx context.Context, pg *postgresql.PostgreSQL, data *exportData, seenAt time.Time) error {
+	batch := make(types.Content, 0, batchSize)
+	for item := range data.items {
+		batch = append(batch, item)
+		if len(batch) == batchSize {
+			if err := pg.InsertContent(ctx, batch, seenAt); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := pg.InsertContent(ctx, batch, seenAt); err != nil {
+			return err
+		}
+	}
+	return data.err
+}
+
+// writeExport writes the gzipped export file of the generated movies in the TMDb daily export format.
+func writeExport(filepath string, n int) error {
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	for i := range n {
+		item := movie{
+			ID:            benchIDOffset + i,
+			OriginalTitle: fmt.Sprintf("Generated movie %d", i),
+			Popularity:    float32(i%1000) / 10,
+		}
+		if err = enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return gz.Close()
+}
@@ -0,0 +1,163 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"whattowatch/internal/types"
+
+	tmdbLib "github.com/cyruzin/golang-tmdb"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// MaxChangesWindow is the longest window of the TMDb changes feed, the longer gaps need the full export.
+	MaxChangesWindow = 14 * 24 * time.Hour
+
+	changesDateFormat = "2006-01-02"
+	// detailsWorkers limits the concurrent details requests of the changed ids.
+	detailsWorkers = 8
+	// tmdbNotFoundCode is the TMDb status code of the missing resource, e.g. the deleted title.
+	tmdbNotFoundCode = 34
+)
+
+// LoadChanges upserts the titles changed in TMDb from the start to the end of the window.
+// The window is rounded to the days by TMDb, so the adjacent windows overlap.
+func (l *TMDbLoader) LoadChanges(ctx context.Context, from, to time.Time) error {
+	g, gCtx := errgroup.WithContext(ctx)
+
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		g.Go(func() error {
+			return l.loadChanges(gCtx, contentType, from, to)
+		})
+	}
+
+	return g.Wait()
+}
+
+func (l *TMDbLoader) loadChanges(ctx context.Context, contentType types.ContentType, from, to time.Time) error {
+	log := l.log.With("fn", "loadChanges", "content_type", contentType, "from", from, "to", to)
+
+	ids, err := l.getChangedIDs(contentType, from, to)
+	if err != nil {
+		return err
+	}
+	log.Info("changed ids loaded", "count", len(ids))
+
+	content, err := l.getDetails(ctx, contentType, ids)
+	if err != nil {
+		return err
+	}
+
+	for batch := range slices.Chunk(content, batchSize) {
+		if err = l.storer.UpsertContent(ctx, batch); err != nil {
+			return err
+		}
+		log.Info("upserted batch", "items", len(batch))
+	}
+	return nil
+}
+
+// getChangedIDs pages through the changes feed, the ids are unique.
+func (l *TMDbLoader) getChangedIDs(contentType types.ContentType, from, to time.Time) ([]int64, error) {
+	opts := map[string]string{
+		"start_date": from.UTC().Format(changesDateFormat),
+		"end_date":   to.UTC().Format(changesDateFormat),
+	}
+
+	ids := make([]int64, 0)
+	seen := make(map[int64]struct{})
+	for page, totalPages := int64(1), int64(1); page <= totalPages; page++ {
+		opts["page"] = fmt.Sprint(page)
+
+		var (
+			changes *tmdbLib.ChangesMovie
+			err     error
+		)
+		switch contentType {
+		case types.Movie:
+			changes, err = l.client.GetChangesMovie(opts)
+		case types.TV:
+			var tvChanges *tmdbLib.ChangesTV
+			tvChanges, err = l.client.GetChangesTV(opts)
+			if err == nil {
+				changes = tvChanges.ChangesMovie
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s changes: %s", contentType, err.Error())
+		}
+		if changes == nil || changes.ChangesMovieResults == nil {
+			break
+		}
+
+		totalPages = changes.TotalPages
+		for _, r := range changes.Results {
+			if _, ok := seen[r.ID]; !ok {
+				seen[r.ID] = struct{}{}
+				ids = append(ids, r.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// getDetails gets the original titles and the popularity of the ids, the deleted titles are skipped.
+func (l *TMDbLoader) getDetails(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error) {
+	log := l.log.With("fn", "getDetails", "content_type", contentType)
+
+	items := make([]*types.ContentItem, len(ids))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(detailsWorkers)
+	for i, id := range ids {
+		g.Go(func() error {
+			if gCtx.Err() != nil {
+				return gCtx.Err()
+			}
+
+			item, err := l.getItem(contentType, id)
+			var tmdbErr tmdbLib.Error
+			if errors.As(err, &tmdbErr) && tmdbErr.StatusCode == tmdbNotFoundCode {
+				log.Debug("changed title not found", "id", id)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get %s %d: %s", contentType, id, err.Error())
+			}
+			items[i] = &item
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	content := make(types.Content, 0, len(ids))
+	for _, item := range items {
+		if item != nil {
+			content = append(content, *item)
+		}
+	}
+	return content, nil
+}
+
+func (l *TMDbLoader) getItem(contentType types.ContentType, id int64) (types.ContentItem, error) {
+	switch contentType {
+	case types.Movie:
+		m, err := l.client.GetMovieDetails(int(id), nil)
+		if err != nil {
+			return types.ContentItem{}, err
+		}
+		return types.ContentItem{ID: id, ContentType: contentType, Title: m.OriginalTitle, Popularity: m.Popularity, Adult: m.Adult}, nil
+	case types.TV:
+		tv, err := l.client.GetTVDetails(int(id), nil)
+		if err != nil {
+			return types.ContentItem{}, err
+		}
+		return types.ContentItem{ID: id, ContentType: contentType, Title: tv.OriginalName, Popularity: tv.Popularity}, nil
+	}
+	return types.ContentItem{}, errors.New("unknown content type")
+}
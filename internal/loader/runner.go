@@ -0,0 +1,153 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"whattowatch/internal/health"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/tracing"
+	"whattowatch/internal/types"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type (
+	Loader interface {
+		// Load loads the daily export of all the ids.
+		Load(ctx context.Context) error
+		// LoadChanges loads the titles changed in the window.
+		LoadChanges(ctx context.Context, from, to time.Time) error
+	}
+
+	RunStorer interface {
+		StartLoaderRun(ctx context.Context, mode types.LoaderMode, watermark time.Time) (int64, error)
+		FinishLoaderRun(ctx context.Context, id int64, runErr error) error
+		GetLastSuccessfulLoaderRun(ctx context.Context) (time.Time, error)
+		GetLoaderWatermark(ctx context.Context) (types.LoaderWatermark, error)
+	}
+
+	// Runner runs the loader once or on schedule and records the runs,
+	// so the readiness knows the age of the last successful one.
+	// Between the full runs every fullInterval it loads the changes since the last successful run.
+	Runner struct {
+		loader       Loader
+		runs         RunStorer
+		fullInterval time.Duration
+		log          *slog.Logger
+
+		now func() time.Time
+	}
+)
+
+// exportLag is how old the content of the daily export may be, the full run watermark is moved back by it,
+// so the next changes run loads the titles changed after the export was made.
+const exportLag = 24 * time.Hour
+
+func NewRunner(logger *slog.Logger, loader Loader, runs RunStorer, fullInterval time.Duration) *Runner {
+	return &Runner{
+		loader:       loader,
+		runs:         runs,
+		fullInterval: fullInterval,
+		log:          logger.With("pkg", "loader"),
+
+		now: time.Now,
+	}
+}
+
+// Run loads the content once in the planned mode and records the run.
+func (r *Runner) Run(ctx context.Context) error {
+	log := r.log.With("fn", "Run")
+
+	ctx, span := tracing.Start(ctx, "loader run")
+
+	wm, err := r.runs.GetLoaderWatermark(ctx)
+	if err != nil && !errors.Is(err, postgresql.ErrRecordNotFound) {
+		tracing.End(span, err)
+		return err
+	}
+	now := r.now()
+	mode := r.plan(wm, errors.Is(err, postgresql.ErrRecordNotFound), now)
+	span.SetAttributes(attribute.String("loader.mode", string(mode)))
+	log = log.With("mode", mode)
+
+	watermark := now
+	if mode == types.LoaderModeFull {
+		watermark = now.Add(-exportLag)
+	}
+	id, err := r.runs.StartLoaderRun(ctx, mode, watermark)
+	if err != nil {
+		tracing.End(span, err)
+		return err
+	}
+	log.Info("loader run started", "run_id", id, "watermark", watermark)
+
+	var runErr error
+	switch mode {
+	case types.LoaderModeFull:
+		runErr = r.loader.Load(ctx)
+	case types.LoaderModeChanges:
+		runErr = r.loader.LoadChanges(ctx, wm.Last, now)
+	}
+	if err = r.runs.FinishLoaderRun(ctx, id, runErr); err != nil {
+		log.ErrorContext(ctx, "failed to finish loader run", "run_id", id, "error", err.Error())
+	}
+	tracing.End(span, runErr)
+	return runErr
+}
+
+// plan chooses the full run if there was no full run yet, the last one is older than the full interval,
+// or the changes since the last run don't fit the changes feed window. Otherwise it loads the changes.
+func (r *Runner) plan(wm types.LoaderWatermark, notFound bool, now time.Time) types.LoaderMode {
+	switch {
+	case notFound, r.fullInterval == 0:
+		return types.LoaderModeFull
+	case now.Sub(wm.Full) >= r.fullInterval:
+		return types.LoaderModeFull
+	case now.Sub(wm.Last) > MaxChangesWindow:
+		return types.LoaderModeFull
+	}
+	return types.LoaderModeChanges
+}
+
+// Schedule runs the loader now and then every interval until the context is done.
+func (r *Runner) Schedule(ctx context.Context, interval time.Duration) {
+	log := r.log.With("fn", "Schedule", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Run(ctx); err != nil {
+			log.ErrorContext(ctx, "load error", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckLastRun is the readiness check failing if the last successful run is older than maxAge.
+func (r *Runner) CheckLastRun(maxAge time.Duration) health.CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		finishedAt, err := r.runs.GetLastSuccessfulLoaderRun(ctx)
+		if errors.Is(err, postgresql.ErrRecordNotFound) {
+			return "", errors.New("no successful runs yet")
+		}
+		if err != nil {
+			return "", err
+		}
+
+		age := r.now().Sub(finishedAt).Truncate(time.Second)
+		detail := fmt.Sprintf("last successful run %s ago", age)
+		if age > maxAge {
+			return detail, fmt.Errorf("last successful run is older than %s", maxAge)
+		}
+		return detail, nil
+	}
+}
@@ -0,0 +1,97 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRunStorer struct {
+	RunStorer
+
+	lastRun time.Time
+	err     error
+}
+
+func (s fakeRunStorer) GetLastSuccessfulLoaderRun(ctx context.Context) (time.Time, error) {
+	return s.lastRun, s.err
+}
+
+func Test_CheckLastRun(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		runs       fakeRunStorer
+		wantDetail string
+		wantErr    bool
+	}{
+		{name: "fresh", runs: fakeRunStorer{lastRun: now.Add(-3 * time.Hour)}, wantDetail: "last successful run 3h0m0s ago"},
+		{name: "stale", runs: fakeRunStorer{lastRun: now.Add(-50 * time.Hour)}, wantDetail: "last successful run 50h0m0s ago", wantErr: true},
+		{name: "never", runs: fakeRunStorer{err: postgresql.ErrRecordNotFound}, wantErr: true},
+		{name: "storage error", runs: fakeRunStorer{err: errors.New("connection refused")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRunner(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, tt.runs, 0)
+			r.now = func() time.Time { return now }
+
+			detail, err := r.CheckLastRun(48 * time.Hour)(context.Background())
+			assert.Equal(t, tt.wantDetail, detail)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_plan(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		fullInterval time.Duration
+		wm           types.LoaderWatermark
+		notFound     bool
+		want         types.LoaderMode
+	}{
+		{name: "no full run", fullInterval: 7 * 24 * time.Hour, notFound: true, want: types.LoaderModeFull},
+		{name: "always full", wm: types.LoaderWatermark{Last: now.Add(-time.Hour), Full: now.Add(-time.Hour)}, want: types.LoaderModeFull},
+		{
+			name:         "full is due",
+			fullInterval: 7 * 24 * time.Hour,
+			wm:           types.LoaderWatermark{Last: now.Add(-time.Hour), Full: now.Add(-8 * 24 * time.Hour)},
+			want:         types.LoaderModeFull,
+		},
+		{
+			name:         "gap longer than changes window",
+			fullInterval: 30 * 24 * time.Hour,
+			wm:           types.LoaderWatermark{Last: now.Add(-15 * 24 * time.Hour), Full: now.Add(-20 * 24 * time.Hour)},
+			want:         types.LoaderModeFull,
+		},
+		{
+			name:         "changes",
+			fullInterval: 7 * 24 * time.Hour,
+			wm:           types.LoaderWatermark{Last: now.Add(-24 * time.Hour), Full: now.Add(-3 * 24 * time.Hour)},
+			want:         types.LoaderModeChanges,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRunner(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, tt.fullInterval)
+
+			assert.Equal(t, tt.want, r.plan(tt.wm, tt.notFound, now))
+		})
+	}
+}
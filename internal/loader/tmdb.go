@@ -20,14 +20,21 @@ import (
 
 type (
 	Storer interface {
-		InsertContent(ctx context.Context, content types.Content) error
+		ClearContentStaging(ctx context.Context, contentType types.ContentType) error
+		CopyContentStaging(ctx context.Context, content types.Content) error
+		MergeContentStaging(ctx context.Context, contentType types.ContentType, seenAt time.Time) (int64, error)
+		UpsertContent(ctx context.Context, content types.Content) error
+		CountMissingContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int, int, error)
+		MarkRemovedContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int64, error)
 	}
 
 	TMDbLoader struct {
-		log      *slog.Logger
-		storer   Storer
-		client   *tmdbLib.Client
-		filesURL string
+		log               *slog.Logger
+		storer            Storer
+		client            *tmdbLib.Client
+		filesURL          string
+		maxRemovedPercent float64
+		workers           int
 	}
 
 	movie struct {
@@ -61,6 +68,9 @@ func NewTMDbLoader(cfg *config.Config, logger *slog.Logger, storer Storer) (*TMD
 		storer:   storer,
 		client:   c,
 		filesURL: cfg.Urls.TMDbFilesUrl,
+
+		maxRemovedPercent: cfg.Loader.MaxRemovedPercent,
+		workers:           cfg.Loader.Workers,
 	}
 
 	loader.log.Info("loader initialized", "url", cfg.Urls.TMDbApiUrl)
@@ -95,13 +105,13 @@ func (l *TMDbLoader) loadMovies(ctx context.Context) error {
 		return err
 	}
 
-	data, err := l.readData(filepath, types.Movie)
+	seenAt := time.Now()
+	err = l.insertData(ctx, types.Movie, l.readData(filepath, types.Movie), batchSize, seenAt)
 	if err != nil {
 		return err
 	}
 
-	err = l.insertData(ctx, data, batchSize)
-	if err != nil {
+	if err = l.markRemoved(ctx, types.Movie, seenAt); err != nil {
 		return err
 	}
 
@@ -116,13 +126,13 @@ func (l *TMDbLoader) loadTVs(ctx context.Context) error {
 		return err
 	}
 
-	data, err := l.readData(filepath, types.TV)
+	seenAt := time.Now()
+	err = l.insertData(ctx, types.TV, l.readData(filepath, types.TV), batchSize, seenAt)
 	if err != nil {
 		return err
 	}
 
-	err = l.insertData(ctx, data, batchSize)
-	if err != nil {
+	if err = l.markRemoved(ctx, types.TV, seenAt); err != nil {
 		return err
 	}
 
@@ -158,21 +168,31 @@ func downloadFile(url string, filepath string) error {
 	return nil
 }
 
-func (l *TMDbLoader) readData(filepath string, ct types.ContentType) (chan types.ContentItem, error) {
-	ch := make(chan types.ContentItem, batchSize)
+// exportData is the stream of the export items, err is set once items is closed.
+type exportData struct {
+	items chan types.ContentItem
+	err   error
+}
+
+// readData streams the items of the gzipped export file. A read error stops the stream,
+// so a partially read export never marks the rest of the titles removed.
+func (l *TMDbLoader) readData(filepath string, ct types.ContentType) *exportData {
+	data := &exportData{items: make(chan types.ContentItem, batchSize)}
 
 	go func() {
-		defer close(ch)
+		defer close(data.items)
 
 		rawf, err := os.Open(filepath)
 		if err != nil {
-			l.log.Error("failed to open file", "error", err.Error())
+			data.err = fmt.Errorf("failed to open file: %s", err.Error())
+			return
 		}
 		defer rawf.Close()
 
 		rawContents, err := gzip.NewReader(rawf)
 		if err != nil {
-			l.log.Error("failed to read gzip", "error", err.Error())
+			data.err = fmt.Errorf("failed to read gzip: %s", err.Error())
+			return
 		}
 		bufferedContents := bufio.NewReader(rawContents)
 
@@ -182,15 +202,15 @@ func (l *TMDbLoader) readData(filepath string, ct types.ContentType) (chan types
 				break
 			}
 			if err != nil {
-				l.log.Error("failed to read line", "error", err.Error())
+				data.err = fmt.Errorf("failed to read line: %s", err.Error())
+				return
 			}
 
 			switch ct {
 			case types.Movie:
 				var item movie
 				json.Unmarshal(line[:len(line)-1], &item)
-				// l.log.Info("processing line", "item", item)
-				ch <- types.ContentItem{
+				data.items <- types.ContentItem{
 					ID:          int64(item.ID),
 					ContentType: ct,
 					Title:       item.OriginalTitle,
@@ -199,8 +219,7 @@ func (l *TMDbLoader) readData(filepath string, ct types.ContentType) (chan types
 			case types.TV:
 				var item tv
 				json.Unmarshal(line[:len(line)-1], &item)
-				// l.log.Info("processing line", "item", item)
-				ch <- types.ContentItem{
+				data.items <- types.ContentItem{
 					ID:          int64(item.ID),
 					ContentType: ct,
 					Title:       item.OriginalName,
@@ -211,29 +230,109 @@ func (l *TMDbLoader) readData(filepath string, ct types.ContentType) (chan types
 		}
 	}()
 
-	return ch, nil
+	return data
 }
 
-func (l *TMDbLoader) insertData(ctx context.Context, data chan types.ContentItem, batchSize int) error {
+// insertData copies the export items to the staging by batches with the parallel workers
+// and merges them into the content by a single statement. The content is left intact if any batch fails,
+// so the titles of the failed batches aren't marked removed.
+func (l *TMDbLoader) insertData(ctx context.Context, ct types.ContentType, data *exportData, batchSize int, seenAt time.Time) error {
+	log := l.log.With("fn", "insertData", "content_type", ct)
+
+	// The staging of the type may be left by a failed load.
+	if err := l.storer.ClearContentStaging(ctx, ct); err != nil {
+		return err
+	}
+
+	batches := make(chan types.Content)
+	g, gCtx := errgroup.WithContext(ctx)
+	for range max(l.workers, 1) {
+		g.Go(func() error {
+			for batch := range batches {
+				if err := l.storer.CopyContentStaging(gCtx, batch); err != nil {
+					return err
+				}
+				log.Info("copied batch", "items", len(batch))
+			}
+			return nil
+		})
+	}
+
+	send := func(batch types.Content) bool {
+		select {
+		case batches <- batch:
+			return true
+		case <-gCtx.Done():
+			return false
+		}
+	}
+
 	var batch types.Content
-	for item := range data {
+	for item := range data.items {
 		batch = append(batch, item)
 		if len(batch) == batchSize {
-			err := l.storer.InsertContent(ctx, batch)
-			if err != nil {
-				l.log.Error("failed to insert content batch", "error", err.Error())
+			if !send(batch) {
+				break
 			}
-			l.log.Info("inserted batch", "items", len(batch))
-			batch = types.Content{}
+			batch = make(types.Content, 0, batchSize)
 		}
 	}
-	if len(batch) > 0 {
-		err := l.storer.InsertContent(ctx, batch)
-		if err != nil {
-			l.log.Error("failed to insert content batch", "error", err.Error())
-		}
-		l.log.Info("inserted batch", "items", len(batch))
+	if len(batch) > 0 && gCtx.Err() == nil {
+		send(batch)
+	}
+	close(batches)
+	// The reader stops once the items are drained.
+	for range data.items {
 	}
 
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if data.err != nil {
+		return data.err
+	}
+
+	merged, err := l.storer.MergeContentStaging(ctx, ct, seenAt)
+	if err != nil {
+		return err
+	}
+	log.Info("merged content", "items", merged)
 	return nil
 }
+
+// markRemoved marks the titles of the type missing from the full export removed.
+// The titles added after the export was made are kept, the export may be exportLag old.
+// It fails without marking if more than the max percent of the titles would disappear,
+// e.g. the export is truncated.
+func (l *TMDbLoader) markRemoved(ctx context.Context, ct types.ContentType, seenAt time.Time) error {
+	log := l.log.With("fn", "markRemoved", "content_type", ct)
+
+	exportedAt := seenAt.Add(-exportLag)
+	missing, total, err := l.storer.CountMissingContent(ctx, ct, seenAt, exportedAt)
+	if err != nil {
+		return err
+	}
+	if exceedsRemovedThreshold(missing, total, l.maxRemovedPercent) {
+		return fmt.Errorf("%d of %d %s titles are missing from the export, over %.1f%%", missing, total, ct, l.maxRemovedPercent)
+	}
+	if missing == 0 {
+		return nil
+	}
+
+	removed, err := l.storer.MarkRemovedContent(ctx, ct, seenAt, exportedAt)
+	if err != nil {
+		return err
+	}
+	log.Info("titles marked removed", "count", removed, "total", total)
+	return nil
+}
+
+func exceedsRemovedThreshold(missing, total int, maxPercent float64) bool {
+	if total == 0 {
+		return false
+	}
+	return float64(missing)*100/float64(total) > maxPercent
+}
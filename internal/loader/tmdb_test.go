@@ -0,0 +1,145 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStorer struct {
+	Storer
+
+	mu      sync.Mutex
+	copyErr error
+	copied  int
+	merged  bool
+	missing int
+	total   int
+	removed bool
+
+	seenBefore    time.Time
+	createdBefore time.Time
+}
+
+func (s *fakeStorer) ClearContentStaging(ctx context.Context, contentType types.ContentType) error {
+	return nil
+}
+
+func (s *fakeStorer) CopyContentStaging(ctx context.Context, content types.Content) error {
+	if s.copyErr != nil {
+		return s.copyErr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.copied += len(content)
+	return nil
+}
+
+func (s *fakeStorer) MergeContentStaging(ctx context.Context, contentType types.ContentType, seenAt time.Time) (int64, error) {
+	s.merged = true
+	return int64(s.copied), nil
+}
+
+func (s *fakeStorer) CountMissingContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int, int, error) {
+	s.seenBefore, s.createdBefore = seenBefore, createdBefore
+	return s.missing, s.total, nil
+}
+
+func (s *fakeStorer) MarkRemovedContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int64, error) {
+	if !seenBefore.Equal(s.seenBefore) || !createdBefore.Equal(s.createdBefore) {
+		return 0, errors.New("marked other titles than counted")
+	}
+	s.removed = true
+	return int64(s.missing), nil
+}
+
+func newTestLoader(storer Storer) *TMDbLoader {
+	return &TMDbLoader{
+		log:               slog.New(slog.NewTextHandler(io.Discard, nil)),
+		storer:            storer,
+		maxRemovedPercent: 5,
+		workers:           3,
+	}
+}
+
+func Test_markRemoved(t *testing.T) {
+	tests := []struct {
+		name        string
+		storer      *fakeStorer
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "nothing missing", storer: &fakeStorer{missing: 0, total: 100}},
+		{name: "under threshold", storer: &fakeStorer{missing: 5, total: 100}, wantRemoved: true},
+		{name: "over threshold", storer: &fakeStorer{missing: 6, total: 100}, wantErr: true},
+		{name: "empty catalog", storer: &fakeStorer{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestLoader(tt.storer).markRemoved(context.Background(), types.Movie, time.Now())
+			assert.Equal(t, tt.wantRemoved, tt.storer.removed)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// The titles added by the changes feed after the export was made are not in it, only the older ones may be missing.
+func Test_markRemovedKeepsNewTitles(t *testing.T) {
+	storer := &fakeStorer{missing: 1, total: 100}
+	seenAt := time.Date(2024, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	err := newTestLoader(storer).markRemoved(context.Background(), types.TV, seenAt)
+	assert.NoError(t, err)
+	assert.True(t, storer.removed)
+	assert.Equal(t, seenAt, storer.seenBefore)
+	assert.Equal(t, seenAt.Add(-exportLag), storer.createdBefore)
+}
+
+func Test_insertData(t *testing.T) {
+	newData := func(n int, err error) *exportData {
+		data := &exportData{items: make(chan types.ContentItem, n), err: err}
+		for i := range n {
+			data.items <- types.ContentItem{ID: int64(i + 1), ContentType: types.Movie}
+		}
+		close(data.items)
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		storer     *fakeStorer
+		data       *exportData
+		wantCopied int
+		wantMerged bool
+		wantErr    bool
+	}{
+		{name: "all batches", storer: &fakeStorer{}, data: newData(5, nil), wantCopied: 5, wantMerged: true},
+		{name: "read error", storer: &fakeStorer{}, data: newData(3, errors.New("unexpected EOF")), wantCopied: 3, wantErr: true},
+		{name: "failed batches", storer: &fakeStorer{copyErr: errors.New("connection refused")}, data: newData(5, nil), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestLoader(tt.storer).insertData(context.Background(), types.Movie, tt.data, 2, time.Now())
+			assert.Equal(t, tt.wantCopied, tt.storer.copied)
+			assert.Equal(t, tt.wantMerged, tt.storer.merged)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
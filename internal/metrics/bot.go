@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"whattowatch/internal/botkit"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// BotMiddleware counts the updates and records their handling duration by the handler.
+func BotMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		handler := botkit.HandlerName(update)
+		start := time.Now()
+
+		next(ctx, b, update)
+
+		updatesTotal.WithLabelValues(handler).Inc()
+		updateDuration.WithLabelValues(handler).Observe(time.Since(start).Seconds())
+	}
+}
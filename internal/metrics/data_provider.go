@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DataProvider is the botkit.DataProvider decorator counting the TMDb calls, their errors and duration by the method.
+type DataProvider struct {
+	api botkit.DataProvider
+}
+
+var _ botkit.DataProvider = (*DataProvider)(nil)
+
+func NewDataProvider(api botkit.DataProvider) *DataProvider {
+	return &DataProvider{api: api}
+}
+
+// RegisterGenreCache exposes the number of the cached genres of the provider by the content type.
+func RegisterGenreCache(api botkit.GenreProvider) {
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "tmdb",
+			Name:        "genre_cache_size",
+			Help:        "Genres in the TMDb client cache.",
+			ConstLabels: prometheus.Labels{"content_type": contentType.String()},
+		}, func() float64 {
+			genres, _ := api.GetGenres(context.Background(), contentType)
+			return float64(len(genres))
+		})
+	}
+}
+
+func observeTMDb(method string, start time.Time, err *error) {
+	observe(tmdbRequestsTotal, tmdbRequestDuration, method, start, err)
+}
+
+func (p *DataProvider) GetMovie(ctx context.Context, id int) (res types.ContentItem, err error) {
+	defer observeTMDb("GetMovie", time.Now(), &err)
+	return p.api.GetMovie(ctx, id)
+}
+
+func (p *DataProvider) GetMoviePopular(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetMoviePopular", time.Now(), &err)
+	return p.api.GetMoviePopular(ctx, page)
+}
+
+func (p *DataProvider) GetMovieTop(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetMovieTop", time.Now(), &err)
+	return p.api.GetMovieTop(ctx, page)
+}
+
+func (p *DataProvider) GetMovieTrending(ctx context.Context, window types.TrendingWindow, page int) (res types.Content, err error) {
+	defer observeTMDb("GetMovieTrending", time.Now(), &err)
+	return p.api.GetMovieTrending(ctx, window, page)
+}
+
+func (p *DataProvider) GetMovieNowPlaying(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetMovieNowPlaying", time.Now(), &err)
+	return p.api.GetMovieNowPlaying(ctx, page)
+}
+
+func (p *DataProvider) GetMovieUpcoming(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetMovieUpcoming", time.Now(), &err)
+	return p.api.GetMovieUpcoming(ctx, page)
+}
+
+func (p *DataProvider) GetMoviesByGenre(ctx context.Context, genreIDs []int, page int) (res types.Content, err error) {
+	defer observeTMDb("GetMoviesByGenre", time.Now(), &err)
+	return p.api.GetMoviesByGenre(ctx, genreIDs, page)
+}
+
+func (p *DataProvider) GetTV(ctx context.Context, id int) (res types.ContentItem, err error) {
+	defer observeTMDb("GetTV", time.Now(), &err)
+	return p.api.GetTV(ctx, id)
+}
+
+func (p *DataProvider) GetTVPopular(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetTVPopular", time.Now(), &err)
+	return p.api.GetTVPopular(ctx, page)
+}
+
+func (p *DataProvider) GetTVTop(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetTVTop", time.Now(), &err)
+	return p.api.GetTVTop(ctx, page)
+}
+
+func (p *DataProvider) GetTVTrending(ctx context.Context, window types.TrendingWindow, page int) (res types.Content, err error) {
+	defer observeTMDb("GetTVTrending", time.Now(), &err)
+	return p.api.GetTVTrending(ctx, window, page)
+}
+
+func (p *DataProvider) GetTVAiringToday(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetTVAiringToday", time.Now(), &err)
+	return p.api.GetTVAiringToday(ctx, page)
+}
+
+func (p *DataProvider) GetTVOnTheAir(ctx context.Context, page int) (res types.Content, err error) {
+	defer observeTMDb("GetTVOnTheAir", time.Now(), &err)
+	return p.api.GetTVOnTheAir(ctx, page)
+}
+
+func (p *DataProvider) GetTVsByGenre(ctx context.Context, genreIDs []int, page int) (res types.Content, err error) {
+	defer observeTMDb("GetTVsByGenre", time.Now(), &err)
+	return p.api.GetTVsByGenre(ctx, genreIDs, page)
+}
+
+func (p *DataProvider) GetGenres(ctx context.Context, contentType types.ContentType) (res types.Genres, err error) {
+	defer observeTMDb("GetGenres", time.Now(), &err)
+	return p.api.GetGenres(ctx, contentType)
+}
+
+func (p *DataProvider) GetCollection(ctx context.Context, id int) (res types.Collection, err error) {
+	defer observeTMDb("GetCollection", time.Now(), &err)
+	return p.api.GetCollection(ctx, id)
+}
+
+func (p *DataProvider) GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (res types.Content, err error) {
+	defer observeTMDb("GetContent", time.Now(), &err)
+	return p.api.GetContent(ctx, contentType, ids)
+}
+
+func (p *DataProvider) GetRecommendations(ctx context.Context, contentType types.ContentType, ids []int64) (res types.Content, err error) {
+	defer observeTMDb("GetRecommendations", time.Now(), &err)
+	return p.api.GetRecommendations(ctx, contentType, ids)
+}
+
+func (p *DataProvider) SearchByTitles(ctx context.Context, titles []string) (res types.Content, err error) {
+	defer observeTMDb("SearchByTitles", time.Now(), &err)
+	return p.api.SearchByTitles(ctx, titles)
+}
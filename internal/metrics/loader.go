@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"whattowatch/internal/loader"
+	"whattowatch/internal/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	loaderBatchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "loader",
+		Name:      "batches_total",
+		Help:      "Content batches copied or upserted by the loader by the status.",
+	}, []string{"status"})
+
+	loaderItemsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "loader",
+		Name:      "items_total",
+		Help:      "Content items in the copied or upserted batches.",
+	})
+
+	loaderRemovedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "loader",
+		Name:      "removed_total",
+		Help:      "Titles marked removed as missing from the full export by the content type.",
+	}, []string{"content_type"})
+
+	loaderBatchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "loader",
+		Name:      "batch_duration_seconds",
+		Help:      "Content batch copy or upsert duration.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	})
+
+	loaderMergeDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "loader",
+		Name:      "merge_duration_seconds",
+		Help:      "Duration of the merge of the staged full export into the content.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
+)
+
+// LoaderStorer is the loader.Storer decorator counting the copied batches and recording the batch and the merge durations.
+type LoaderStorer struct {
+	storer loader.Storer
+}
+
+var _ loader.Storer = (*LoaderStorer)(nil)
+
+func NewLoaderStorer(storer loader.Storer) *LoaderStorer {
+	return &LoaderStorer{storer: storer}
+}
+
+func (s *LoaderStorer) ClearContentStaging(ctx context.Context, contentType types.ContentType) error {
+	return s.storer.ClearContentStaging(ctx, contentType)
+}
+
+func (s *LoaderStorer) CopyContentStaging(ctx context.Context, content types.Content) error {
+	start := time.Now()
+	err := s.storer.CopyContentStaging(ctx, content)
+	observeBatch(start, content, err)
+	return err
+}
+
+func (s *LoaderStorer) MergeContentStaging(ctx context.Context, contentType types.ContentType, seenAt time.Time) (int64, error) {
+	start := time.Now()
+	merged, err := s.storer.MergeContentStaging(ctx, contentType, seenAt)
+	loaderMergeDuration.Observe(time.Since(start).Seconds())
+	return merged, err
+}
+
+func (s *LoaderStorer) UpsertContent(ctx context.Context, content types.Content) error {
+	start := time.Now()
+	err := s.storer.UpsertContent(ctx, content)
+	observeBatch(start, content, err)
+	return err
+}
+
+func (s *LoaderStorer) CountMissingContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int, int, error) {
+	return s.storer.CountMissingContent(ctx, contentType, seenBefore, createdBefore)
+}
+
+func (s *LoaderStorer) MarkRemovedContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int64, error) {
+	removed, err := s.storer.MarkRemovedContent(ctx, contentType, seenBefore, createdBefore)
+	if err == nil {
+		loaderRemovedTotal.WithLabelValues(contentType.String()).Add(float64(removed))
+	}
+	return removed, err
+}
+
+func observeBatch(start time.Time, content types.Content, err error) {
+	loaderBatchDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		loaderBatchesTotal.WithLabelValues(statusError).Inc()
+		return
+	}
+	loaderBatchesTotal.WithLabelValues(statusOK).Inc()
+	loaderItemsTotal.Add(float64(len(content)))
+}
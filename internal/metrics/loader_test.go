@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"whattowatch/internal/types"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLoaderStorer struct {
+	err error
+}
+
+func (s fakeLoaderStorer) ClearContentStaging(ctx context.Context, contentType types.ContentType) error {
+	return s.err
+}
+
+func (s fakeLoaderStorer) CopyContentStaging(ctx context.Context, content types.Content) error {
+	return s.err
+}
+
+func (s fakeLoaderStorer) MergeContentStaging(ctx context.Context, contentType types.ContentType, seenAt time.Time) (int64, error) {
+	return 0, s.err
+}
+
+func (s fakeLoaderStorer) UpsertContent(ctx context.Context, content types.Content) error {
+	return s.err
+}
+
+func (s fakeLoaderStorer) CountMissingContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int, int, error) {
+	return 0, 0, s.err
+}
+
+func (s fakeLoaderStorer) MarkRemovedContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int64, error) {
+	return 0, s.err
+}
+
+func Test_LoaderStorer(t *testing.T) {
+	ok := NewLoaderStorer(fakeLoaderStorer{})
+	failing := NewLoaderStorer(fakeLoaderStorer{err: errors.New("copy failed")})
+	batch := types.Content{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	assert.NoError(t, ok.CopyContentStaging(context.Background(), batch))
+	assert.NoError(t, ok.CopyContentStaging(context.Background(), batch[:1]))
+	assert.Error(t, failing.CopyContentStaging(context.Background(), batch))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(loaderBatchesTotal.WithLabelValues(statusOK)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(loaderBatchesTotal.WithLabelValues(statusError)))
+	assert.Equal(t, 4.0, testutil.ToFloat64(loaderItemsTotal))
+}
@@ -0,0 +1,117 @@
+// Package metrics exposes the Prometheus metrics of the bot, the TMDb client, the storage and the loader.
+// The business code is not aware of it: the metrics are collected by the bot middleware
+// and by the decorators of the data provider and the storers.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const (
+	namespace = "whattowatch"
+
+	statusOK    = "ok"
+	statusError = "error"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	updatesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "updates_total",
+		Help:      "Telegram updates handled by the bot.",
+	}, []string{"handler"})
+
+	updateDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "update_duration_seconds",
+		Help:      "Telegram update handling duration.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"handler"})
+
+	tmdbRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tmdb",
+		Name:      "requests_total",
+		Help:      "TMDb client calls by the method and the status.",
+	}, []string{"method", "status"})
+
+	tmdbRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tmdb",
+		Name:      "request_duration_seconds",
+		Help:      "TMDb client call duration.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method"})
+
+	storageQueriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "queries_total",
+		Help:      "Storage calls by the method and the status.",
+	}, []string{"method", "status"})
+
+	storageQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "query_duration_seconds",
+		Help:      "Storage call duration.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"method"})
+)
+
+// observe counts the call with its status and records its duration since the start.
+func observe(total *prometheus.CounterVec, duration *prometheus.HistogramVec, method string, start time.Time, err *error) {
+	status := statusOK
+	if *err != nil {
+		status = statusError
+	}
+	total.WithLabelValues(method, status).Inc()
+	duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// Serve serves the /metrics endpoint until the context is done.
+func Serve(ctx context.Context, addr string, log *slog.Logger) error {
+	log = log.With("pkg", "metrics", "fn", "Serve")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to shutdown server", "error", err.Error())
+		}
+	}()
+
+	log.Info("starting metrics server", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Push pushes all the metrics to the Pushgateway, it is for the short-lived jobs like the loader.
+func Push(url string, job string) error {
+	return push.New(url, job).Gatherer(prometheus.DefaultGatherer).Push()
+}
@@ -0,0 +1,264 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/types"
+)
+
+// Storer is the botkit.Storer decorator counting the storage calls, their errors and duration by the method.
+type Storer struct {
+	storer botkit.Storer
+}
+
+var _ botkit.Storer = (*Storer)(nil)
+
+func NewStorer(storer botkit.Storer) *Storer {
+	return &Storer{storer: storer}
+}
+
+func observeStorage(method string, start time.Time, err *error) {
+	observe(storageQueriesTotal, storageQueryDuration, method, start, err)
+}
+
+func (s *Storer) InsertUser(ctx context.Context, user types.User) (err error) {
+	defer observeStorage("InsertUser", time.Now(), &err)
+	return s.storer.InsertUser(ctx, user)
+}
+
+func (s *Storer) GetUserTakeout(ctx context.Context, userID int64) (res types.UserTakeout, err error) {
+	defer observeStorage("GetUserTakeout", time.Now(), &err)
+	return s.storer.GetUserTakeout(ctx, userID)
+}
+
+func (s *Storer) DeleteUser(ctx context.Context, userID int64) (err error) {
+	defer observeStorage("DeleteUser", time.Now(), &err)
+	return s.storer.DeleteUser(ctx, userID)
+}
+
+func (s *Storer) PurgeDeletedUsers(ctx context.Context, before time.Time) (res int64, err error) {
+	defer observeStorage("PurgeDeletedUsers", time.Now(), &err)
+	return s.storer.PurgeDeletedUsers(ctx, before)
+}
+
+func (s *Storer) GetUserSettings(ctx context.Context, userID int64) (res types.UserSettings, err error) {
+	defer observeStorage("GetUserSettings", time.Now(), &err)
+	return s.storer.GetUserSettings(ctx, userID)
+}
+
+func (s *Storer) UpdateUserSettings(ctx context.Context, settings types.UserSettings) (err error) {
+	defer observeStorage("UpdateUserSettings", time.Now(), &err)
+	return s.storer.UpdateUserSettings(ctx, settings)
+}
+
+func (s *Storer) SetNote(ctx context.Context, userID int64, item types.ContentItem, text string) (err error) {
+	defer observeStorage("SetNote", time.Now(), &err)
+	return s.storer.SetNote(ctx, userID, item, text)
+}
+
+func (s *Storer) DeleteNote(ctx context.Context, userID int64, item types.ContentItem) (err error) {
+	defer observeStorage("DeleteNote", time.Now(), &err)
+	return s.storer.DeleteNote(ctx, userID, item)
+}
+
+func (s *Storer) GetNotes(ctx context.Context, userID int64, limit, offset int) (res types.UserNotes, err error) {
+	defer observeStorage("GetNotes", time.Now(), &err)
+	return s.storer.GetNotes(ctx, userID, limit, offset)
+}
+
+func (s *Storer) CreateList(ctx context.Context, userID int64, name string) (res types.UserList, err error) {
+	defer observeStorage("CreateList", time.Now(), &err)
+	return s.storer.CreateList(ctx, userID, name)
+}
+
+func (s *Storer) RenameList(ctx context.Context, userID int64, listID int64, name string) (err error) {
+	defer observeStorage("RenameList", time.Now(), &err)
+	return s.storer.RenameList(ctx, userID, listID, name)
+}
+
+func (s *Storer) DeleteList(ctx context.Context, userID int64, listID int64) (err error) {
+	defer observeStorage("DeleteList", time.Now(), &err)
+	return s.storer.DeleteList(ctx, userID, listID)
+}
+
+func (s *Storer) SetListShareToken(ctx context.Context, userID int64, listID int64, token string) (err error) {
+	defer observeStorage("SetListShareToken", time.Now(), &err)
+	return s.storer.SetListShareToken(ctx, userID, listID, token)
+}
+
+func (s *Storer) GetLists(ctx context.Context, userID int64) (res types.UserLists, err error) {
+	defer observeStorage("GetLists", time.Now(), &err)
+	return s.storer.GetLists(ctx, userID)
+}
+
+func (s *Storer) GetList(ctx context.Context, userID int64, listID int64) (res types.UserList, err error) {
+	defer observeStorage("GetList", time.Now(), &err)
+	return s.storer.GetList(ctx, userID, listID)
+}
+
+func (s *Storer) GetSharedList(ctx context.Context, token string) (res types.UserList, err error) {
+	defer observeStorage("GetSharedList", time.Now(), &err)
+	return s.storer.GetSharedList(ctx, token)
+}
+
+func (s *Storer) AddListItem(ctx context.Context, userID int64, listID int64, item types.ContentItem) (err error) {
+	defer observeStorage("AddListItem", time.Now(), &err)
+	return s.storer.AddListItem(ctx, userID, listID, item)
+}
+
+func (s *Storer) RemoveListItem(ctx context.Context, userID int64, listID int64, item types.ContentItem) (err error) {
+	defer observeStorage("RemoveListItem", time.Now(), &err)
+	return s.storer.RemoveListItem(ctx, userID, listID, item)
+}
+
+func (s *Storer) GetListContentIDs(ctx context.Context, listID int64, contentType types.ContentType) (res []int64, err error) {
+	defer observeStorage("GetListContentIDs", time.Now(), &err)
+	return s.storer.GetListContentIDs(ctx, listID, contentType)
+}
+
+func (s *Storer) GetItemListIDs(ctx context.Context, userID int64, item types.ContentItem) (res []int64, err error) {
+	defer observeStorage("GetItemListIDs", time.Now(), &err)
+	return s.storer.GetItemListIDs(ctx, userID, item)
+}
+
+func (s *Storer) SetAPIToken(ctx context.Context, userID int64, tokenHash string) (err error) {
+	defer observeStorage("SetAPIToken", time.Now(), &err)
+	return s.storer.SetAPIToken(ctx, userID, tokenHash)
+}
+
+func (s *Storer) GetAPITokenUserID(ctx context.Context, tokenHash string) (res int64, err error) {
+	defer observeStorage("GetAPITokenUserID", time.Now(), &err)
+	return s.storer.GetAPITokenUserID(ctx, tokenHash)
+}
+
+func (s *Storer) GetFavoriteContentIDs(ctx context.Context, userID int64, contentType types.ContentType) (res []int64, err error) {
+	defer observeStorage("GetFavoriteContentIDs", time.Now(), &err)
+	return s.storer.GetFavoriteContentIDs(ctx, userID, contentType)
+}
+
+func (s *Storer) AddContentItemToFavorite(ctx context.Context, userID int64, item types.ContentItem) (err error) {
+	defer observeStorage("AddContentItemToFavorite", time.Now(), &err)
+	return s.storer.AddContentItemToFavorite(ctx, userID, item)
+}
+
+func (s *Storer) RemoveContentItemFromFavorite(ctx context.Context, userID int64, item types.ContentItem) (err error) {
+	defer observeStorage("RemoveContentItemFromFavorite", time.Now(), &err)
+	return s.storer.RemoveContentItemFromFavorite(ctx, userID, item)
+}
+
+func (s *Storer) GetViewedContentIDs(ctx context.Context, userID int64, contentType types.ContentType) (res []int64, err error) {
+	defer observeStorage("GetViewedContentIDs", time.Now(), &err)
+	return s.storer.GetViewedContentIDs(ctx, userID, contentType)
+}
+
+func (s *Storer) AddContentItemToViewed(ctx context.Context, userID int64, item types.ContentItem) (err error) {
+	defer observeStorage("AddContentItemToViewed", time.Now(), &err)
+	return s.storer.AddContentItemToViewed(ctx, userID, item)
+}
+
+func (s *Storer) RemoveContentItemFromViewed(ctx context.Context, userID int64, item types.ContentItem) (err error) {
+	defer observeStorage("RemoveContentItemFromViewed", time.Now(), &err)
+	return s.storer.RemoveContentItemFromViewed(ctx, userID, item)
+}
+
+func (s *Storer) SetLastWatchDate(ctx context.Context, userID int64, item types.ContentItem, watchedAt time.Time) (err error) {
+	defer observeStorage("SetLastWatchDate", time.Now(), &err)
+	return s.storer.SetLastWatchDate(ctx, userID, item, watchedAt)
+}
+
+func (s *Storer) SetRating(ctx context.Context, userID int64, item types.ContentItem, rating int) (err error) {
+	defer observeStorage("SetRating", time.Now(), &err)
+	return s.storer.SetRating(ctx, userID, item, rating)
+}
+
+func (s *Storer) GetWatchHistory(ctx context.Context, userID int64, limit, offset int) (res types.WatchHistory, err error) {
+	defer observeStorage("GetWatchHistory", time.Now(), &err)
+	return s.storer.GetWatchHistory(ctx, userID, limit, offset)
+}
+
+func (s *Storer) GetUserStats(ctx context.Context, userID int64, months int) (res types.UserStats, err error) {
+	defer observeStorage("GetUserStats", time.Now(), &err)
+	return s.storer.GetUserStats(ctx, userID, months)
+}
+
+func (s *Storer) GetYearWatchedItems(ctx context.Context, userID int64, year int, limit int) (res []types.WatchedItem, err error) {
+	defer observeStorage("GetYearWatchedItems", time.Now(), &err)
+	return s.storer.GetYearWatchedItems(ctx, userID, year, limit)
+}
+
+func (s *Storer) GetContentStatus(ctx context.Context, userID int64, item types.ContentItem) (res types.ContentStatus, err error) {
+	defer observeStorage("GetContentStatus", time.Now(), &err)
+	return s.storer.GetContentStatus(ctx, userID, item)
+}
+
+func (s *Storer) InsertEvents(ctx context.Context, events []types.Event) (err error) {
+	defer observeStorage("InsertEvents", time.Now(), &err)
+	return s.storer.InsertEvents(ctx, events)
+}
+
+func (s *Storer) GetFunnel(ctx context.Context, steps []types.EventType, since time.Time) (res types.Funnel, err error) {
+	defer observeStorage("GetFunnel", time.Now(), &err)
+	return s.storer.GetFunnel(ctx, steps, since)
+}
+
+func (s *Storer) GetRetentionCohorts(ctx context.Context, since time.Time) (res types.RetentionCohorts, err error) {
+	defer observeStorage("GetRetentionCohorts", time.Now(), &err)
+	return s.storer.GetRetentionCohorts(ctx, since)
+}
+
+func (s *Storer) GetEventsUsage(ctx context.Context, eventTypes []types.EventType, since time.Time, limit int) (res types.EventsUsage, err error) {
+	defer observeStorage("GetEventsUsage", time.Now(), &err)
+	return s.storer.GetEventsUsage(ctx, eventTypes, since, limit)
+}
+
+func (s *Storer) RefreshCommunityFeed(ctx context.Context) (err error) {
+	defer observeStorage("RefreshCommunityFeed", time.Now(), &err)
+	return s.storer.RefreshCommunityFeed(ctx)
+}
+
+func (s *Storer) GetCommunityFeedIDs(ctx context.Context, userID int64, contentType types.ContentType, limit int) (res []int64, err error) {
+	defer observeStorage("GetCommunityFeedIDs", time.Now(), &err)
+	return s.storer.GetCommunityFeedIDs(ctx, userID, contentType, limit)
+}
+
+func (s *Storer) RefreshContentNeighbors(ctx context.Context, minUsers int, topN int) (res int64, err error) {
+	defer observeStorage("RefreshContentNeighbors", time.Now(), &err)
+	return s.storer.RefreshContentNeighbors(ctx, minUsers, topN)
+}
+
+func (s *Storer) GetSimilarUsersContentIDs(ctx context.Context, userID int64, contentType types.ContentType, limit int) (res []int64, err error) {
+	defer observeStorage("GetSimilarUsersContentIDs", time.Now(), &err)
+	return s.storer.GetSimilarUsersContentIDs(ctx, userID, contentType, limit)
+}
+
+func (s *Storer) SearchContent(ctx context.Context, query string, contentType types.ContentType, minSimilarity float64, limit int) (res types.Content, err error) {
+	defer observeStorage("SearchContent", time.Now(), &err)
+	return s.storer.SearchContent(ctx, query, contentType, minSimilarity, limit)
+}
+
+func (s *Storer) GetRemovedContent(ctx context.Context, contentType types.ContentType, ids []int64) (res types.Content, err error) {
+	defer observeStorage("GetRemovedContent", time.Now(), &err)
+	return s.storer.GetRemovedContent(ctx, contentType, ids)
+}
+
+func (s *Storer) GetRemovedListItems(ctx context.Context, limit int) (res types.RemovedListItems, err error) {
+	defer observeStorage("GetRemovedListItems", time.Now(), &err)
+	return s.storer.GetRemovedListItems(ctx, limit)
+}
+
+func (s *Storer) GetFSMSession(ctx context.Context, userID int64) (res []byte, err error) {
+	defer observeStorage("GetFSMSession", time.Now(), &err)
+	return s.storer.GetFSMSession(ctx, userID)
+}
+
+func (s *Storer) SaveFSMSession(ctx context.Context, userID int64, data []byte, expiresAt sql.NullTime) (err error) {
+	defer observeStorage("SaveFSMSession", time.Now(), &err)
+	return s.storer.SaveFSMSession(ctx, userID, data, expiresAt)
+}
+
+func (s *Storer) DeleteFSMSession(ctx context.Context, userID int64) (err error) {
+	defer observeStorage("DeleteFSMSession", time.Now(), &err)
+	return s.storer.DeleteFSMSession(ctx, userID)
+}
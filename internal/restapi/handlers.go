@@ -0,0 +1,287 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"whattowatch/internal/api/tmdb"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/types"
+)
+
+const searchQueryMaxLength = 200
+
+// collection is the user titles collection, the favorites or the viewed ones.
+type collection struct {
+	ids    func(ctx context.Context, userID int64, contentType types.ContentType) ([]int64, error)
+	add    func(ctx context.Context, userID int64, item types.ContentItem) error
+	remove func(ctx context.Context, userID int64, item types.ContentItem) error
+}
+
+type addRequest struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
+
+// listHandler returns the page of the collection titles, of the "type" query parameter or of all types.
+func (s *Server) listHandler(c collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := userIDFromContext(r.Context())
+
+		p, apiErr := parsePagination(r.URL.Query())
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+
+		contentTypes := []types.ContentType{types.Movie, types.TV}
+		if v := r.URL.Query().Get("type"); v != "" {
+			contentType, apiErr := parseContentType(v)
+			if apiErr != nil {
+				writeError(w, apiErr)
+				return
+			}
+			contentTypes = []types.ContentType{contentType}
+		}
+
+		refs := make([]types.ContentItem, 0)
+		for _, contentType := range contentTypes {
+			ids, err := c.ids(r.Context(), userID, contentType)
+			if err != nil {
+				s.internalError(w, "listHandler", err)
+				return
+			}
+			for _, id := range ids {
+				refs = append(refs, types.ContentItem{ID: id, ContentType: contentType})
+			}
+		}
+
+		// Only the titles of the page are requested from the catalog.
+		titles, err := s.getTitles(r.Context(), paginate(refs, p))
+		if err != nil {
+			s.upstreamError(w, "listHandler", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newPage(titles, p, len(refs)))
+	}
+}
+
+func (s *Server) addHandler(c collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body addRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, badRequest("invalid request body"))
+			return
+		}
+		if body.ID <= 0 {
+			writeError(w, badRequest("id must be a positive integer"))
+			return
+		}
+		contentType, apiErr := parseContentType(body.Type)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+
+		item := types.ContentItem{ID: body.ID, ContentType: contentType}
+		err := c.add(r.Context(), userIDFromContext(r.Context()), item)
+		switch {
+		case errors.Is(err, postgresql.ErrContentNotFound):
+			writeError(w, errTitleMissing)
+			return
+		case err != nil:
+			s.internalError(w, "addHandler", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) removeHandler(c collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item, apiErr := parseContentRef(r.PathValue("type"), r.PathValue("id"))
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+
+		if err := c.remove(r.Context(), userIDFromContext(r.Context()), item); err != nil {
+			s.internalError(w, "removeHandler", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// addFavorite adds the item to the favorites, adding it twice is not an error.
+func (s *Server) addFavorite(ctx context.Context, userID int64, item types.ContentItem) error {
+	cs, err := s.storer.GetContentStatus(ctx, userID, item)
+	if err != nil || cs.IsFavorite {
+		return err
+	}
+	return s.storer.AddContentItemToFavorite(ctx, userID, item)
+}
+
+// addViewed marks the item viewed, marking it twice is not an error.
+func (s *Server) addViewed(ctx context.Context, userID int64, item types.ContentItem) error {
+	cs, err := s.storer.GetContentStatus(ctx, userID, item)
+	if err != nil || cs.IsViewed {
+		return err
+	}
+	return s.storer.AddContentItemToViewed(ctx, userID, item)
+}
+
+// recommendationsHandler returns the recommendations based on the favorites like the bot does:
+// without the viewed titles, filtered by the user settings and sorted by popularity.
+func (s *Server) recommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromContext(r.Context())
+
+	p, apiErr := parsePagination(r.URL.Query())
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	contentType, apiErr := parseContentType(r.URL.Query().Get("type"))
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	favoriteIDs, err := s.storer.GetFavoriteContentIDs(r.Context(), userID, contentType)
+	if err != nil {
+		s.internalError(w, "recommendationsHandler", err)
+		return
+	}
+	viewedIDs, err := s.storer.GetViewedContentIDs(r.Context(), userID, contentType)
+	if err != nil {
+		s.internalError(w, "recommendationsHandler", err)
+		return
+	}
+
+	var recommendations types.Content
+	if len(favoriteIDs) > 0 {
+		recommendations, err = s.api.GetRecommendations(r.Context(), contentType, favoriteIDs)
+		if err != nil {
+			s.upstreamError(w, "recommendationsHandler", err)
+			return
+		}
+	}
+
+	settings, ok := types.UserSettingsFromContext(r.Context())
+	if !ok {
+		settings = types.DefaultUserSettings(userID)
+	}
+	recommendations = settings.Filter(recommendations.RemoveByIDs(viewedIDs).RemoveDuplicates())
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Popularity > recommendations[j].Popularity
+	})
+
+	writeJSON(w, http.StatusOK, newPage(newTitles(paginate(recommendations, p)), p, len(recommendations)))
+}
+
+// titleHandler returns the title details with the user status of the title.
+func (s *Server) titleHandler(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromContext(r.Context())
+
+	ref, apiErr := parseContentRef(r.PathValue("type"), r.PathValue("id"))
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	var (
+		item types.ContentItem
+		err  error
+	)
+	switch ref.ContentType {
+	case types.Movie:
+		item, err = s.api.GetMovie(r.Context(), int(ref.ID))
+	case types.TV:
+		item, err = s.api.GetTV(r.Context(), int(ref.ID))
+	}
+	if tmdb.IsNotFound(err) {
+		writeError(w, errNotFound)
+		return
+	}
+	if err != nil {
+		s.upstreamError(w, "titleHandler", err)
+		return
+	}
+
+	cs, err := s.storer.GetContentStatus(r.Context(), userID, item)
+	if err != nil {
+		s.internalError(w, "titleHandler", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, titleDetails{
+		title:  newTitle(item),
+		Status: titleStatus{Favorite: cs.IsFavorite, Viewed: cs.IsViewed, Note: cs.Note},
+	})
+}
+
+// searchHandler searches the movies and TV shows by the title.
+func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
+	p, apiErr := parsePagination(r.URL.Query())
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len([]rune(query)) > searchQueryMaxLength {
+		writeError(w, badRequest("q must be from 1 to %d characters", searchQueryMaxLength))
+		return
+	}
+
+	content, err := s.api.SearchByTitles(r.Context(), []string{query})
+	if err != nil {
+		s.upstreamError(w, "searchHandler", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPage(newTitles(paginate(content, p)), p, len(content)))
+}
+
+// getTitles returns the titles of the refs in the same order.
+func (s *Server) getTitles(ctx context.Context, refs []types.ContentItem) ([]title, error) {
+	ids := make(map[types.ContentType][]int64)
+	for _, ref := range refs {
+		ids[ref.ContentType] = append(ids[ref.ContentType], ref.ID)
+	}
+
+	type key struct {
+		contentType types.ContentType
+		id          int64
+	}
+	found := make(map[key]types.ContentItem, len(refs))
+	for contentType, contentIDs := range ids {
+		content, err := s.api.GetContent(ctx, contentType, contentIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range content {
+			found[key{contentType, c.ID}] = c
+		}
+	}
+
+	titles := make([]title, 0, len(refs))
+	for _, ref := range refs {
+		if c, ok := found[key{ref.ContentType, ref.ID}]; ok {
+			titles = append(titles, newTitle(c))
+		}
+	}
+	return titles, nil
+}
+
+func (s *Server) internalError(w http.ResponseWriter, fn string, err error) {
+	s.log.Error("request failed", "fn", fn, "error", err.Error())
+	writeError(w, errInternal)
+}
+
+func (s *Server) upstreamError(w http.ResponseWriter, fn string, err error) {
+	s.log.Error("catalog request failed", "fn", fn, "error", err.Error())
+	writeError(w, errUpstream)
+}
@@ -0,0 +1,192 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"whattowatch/internal/types"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// apiError is the error response, its code is stable and meant for the clients, the message is for humans.
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	errUnauthorized = &apiError{Status: http.StatusUnauthorized, Code: "unauthorized", Message: "missing or invalid api token"}
+	errNotFound     = &apiError{Status: http.StatusNotFound, Code: "not_found", Message: "not found"}
+	errTitleMissing = &apiError{Status: http.StatusNotFound, Code: "title_not_found", Message: "title is not in the catalog"}
+	errInternal     = &apiError{Status: http.StatusInternalServerError, Code: "internal", Message: "internal error"}
+	errUpstream     = &apiError{Status: http.StatusBadGateway, Code: "upstream", Message: "catalog is unavailable"}
+)
+
+func badRequest(format string, args ...any) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "bad_request", Message: fmt.Sprintf(format, args...)}
+}
+
+type title struct {
+	ID          int64    `json:"id"`
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Overview    string   `json:"overview,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	Genres      []string `json:"genres,omitempty"`
+	Countries   []string `json:"countries,omitempty"`
+	Runtime     int      `json:"runtime,omitempty"`
+	VoteAverage float32  `json:"vote_average"`
+	VoteCount   int64    `json:"vote_count"`
+	Popularity  float32  `json:"popularity"`
+	Adult       bool     `json:"adult"`
+	PosterURL   string   `json:"poster_url,omitempty"`
+	BackdropURL string   `json:"backdrop_url,omitempty"`
+	TrailerURL  string   `json:"trailer_url,omitempty"`
+}
+
+type titleStatus struct {
+	Favorite bool   `json:"favorite"`
+	Viewed   bool   `json:"viewed"`
+	Note     string `json:"note,omitempty"`
+}
+
+type titleDetails struct {
+	title
+	Status titleStatus `json:"status"`
+}
+
+func newTitle(c types.ContentItem) title {
+	t := title{
+		ID:          c.ID,
+		Type:        contentTypeName(c.ContentType),
+		Title:       c.Title,
+		Overview:    c.Overview,
+		Countries:   c.Counties,
+		Runtime:     c.Runtime,
+		VoteAverage: c.VoteAverage,
+		VoteCount:   c.VoteCount,
+		Popularity:  c.Popularity,
+		Adult:       c.Adult,
+		PosterURL:   c.PosterPath,
+		BackdropURL: c.BackdropPath,
+		TrailerURL:  c.TrailerURL,
+	}
+	if !c.ReleaseDate.IsZero() {
+		t.ReleaseDate = c.ReleaseDate.Format("2006-01-02")
+	}
+	for _, g := range c.Genres {
+		t.Genres = append(t.Genres, g.Name)
+	}
+	return t
+}
+
+func newTitles(content types.Content) []title {
+	titles := make([]title, 0, len(content))
+	for _, c := range content {
+		titles = append(titles, newTitle(c))
+	}
+	return titles
+}
+
+// pagination is the requested page, pages are numbered from 1.
+type pagination struct {
+	Page    int
+	PerPage int
+}
+
+func parsePagination(q url.Values) (pagination, *apiError) {
+	p := pagination{Page: 1, PerPage: defaultPerPage}
+
+	if v := q.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return pagination{}, badRequest("page must be a positive integer")
+		}
+		p.Page = page
+	}
+	if v := q.Get("per_page"); v != "" {
+		perPage, err := strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return pagination{}, badRequest("per_page must be from 1 to %d", maxPerPage)
+		}
+		p.PerPage = perPage
+	}
+	return p, nil
+}
+
+// paginate returns the items of the page, it is empty if the page is out of range.
+func paginate[T any](items []T, p pagination) []T {
+	start := (p.Page - 1) * p.PerPage
+	if start >= len(items) {
+		return items[:0]
+	}
+	end := min(start+p.PerPage, len(items))
+	return items[start:end]
+}
+
+type page[T any] struct {
+	Items      []T `json:"items"`
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+func newPage[T any](items []T, p pagination, total int) page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return page[T]{
+		Items:      items,
+		Page:       p.Page,
+		PerPage:    p.PerPage,
+		Total:      total,
+		TotalPages: (total + p.PerPage - 1) / p.PerPage,
+	}
+}
+
+// parseContentType parses the "movie" or "tv" content type.
+func parseContentType(s string) (types.ContentType, *apiError) {
+	switch strings.ToLower(s) {
+	case "movie":
+		return types.Movie, nil
+	case "tv":
+		return types.TV, nil
+	}
+	return 0, badRequest("type must be movie or tv")
+}
+
+func contentTypeName(contentType types.ContentType) string {
+	return strings.ToLower(contentType.String())
+}
+
+// parseContentRef parses the content type and the positive id.
+func parseContentRef(contentType string, id string) (types.ContentItem, *apiError) {
+	ct, apiErr := parseContentType(contentType)
+	if apiErr != nil {
+		return types.ContentItem{}, apiErr
+	}
+	contentID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || contentID <= 0 {
+		return types.ContentItem{}, badRequest("id must be a positive integer")
+	}
+	return types.ContentItem{ID: contentID, ContentType: ct}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	writeJSON(w, err.Status, map[string]*apiError{"error": err})
+}
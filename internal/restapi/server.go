@@ -0,0 +1,144 @@
+// Package restapi is the REST API over the user favorites, viewed titles, recommendations and the catalog
+// for the third party clients. The users authenticate with the personal tokens issued by the /token bot command.
+package restapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/config"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/types"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+type Storer interface {
+	botkit.FavoriteStorer
+	botkit.ViewedStorer
+	botkit.SettingsStorer
+	botkit.APITokenStorer
+
+	GetContentStatus(ctx context.Context, userID int64, item types.ContentItem) (types.ContentStatus, error)
+}
+
+type Server struct {
+	storer Storer
+	api    botkit.DataProvider
+
+	log *slog.Logger
+	cfg *config.Config
+}
+
+func New(cfg *config.Config, log *slog.Logger, storer Storer, api botkit.DataProvider) *Server {
+	return &Server{
+		storer: storer,
+		api:    api,
+
+		log: log.With("pkg", "restapi"),
+		cfg: cfg,
+	}
+}
+
+// Start serves the API until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	log := s.log.With("fn", "Start")
+
+	srv := &http.Server{
+		Addr:              s.cfg.API.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to shutdown server", "error", err.Error())
+		}
+	}()
+
+	log.Info("starting rest api", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(openAPISpec)
+	})
+
+	favorites := collection{ids: s.storer.GetFavoriteContentIDs, add: s.addFavorite, remove: s.storer.RemoveContentItemFromFavorite}
+	mux.Handle("GET /v1/favorites", s.auth(s.listHandler(favorites)))
+	mux.Handle("POST /v1/favorites", s.auth(s.addHandler(favorites)))
+	mux.Handle("DELETE /v1/favorites/{type}/{id}", s.auth(s.removeHandler(favorites)))
+
+	viewed := collection{ids: s.storer.GetViewedContentIDs, add: s.addViewed, remove: s.storer.RemoveContentItemFromViewed}
+	mux.Handle("GET /v1/viewed", s.auth(s.listHandler(viewed)))
+	mux.Handle("POST /v1/viewed", s.auth(s.addHandler(viewed)))
+	mux.Handle("DELETE /v1/viewed/{type}/{id}", s.auth(s.removeHandler(viewed)))
+
+	mux.Handle("GET /v1/recommendations", s.auth(s.recommendationsHandler))
+	mux.Handle("GET /v1/titles/{type}/{id}", s.auth(s.titleHandler))
+	mux.Handle("GET /v1/search", s.auth(s.searchHandler))
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound)
+	})
+
+	return mux
+}
+
+type userIDKey struct{}
+
+// auth checks the "Authorization: Bearer <token>" header, puts the user id and settings into the request context.
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := s.log.With("fn", "auth")
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, errUnauthorized)
+			return
+		}
+
+		userID, err := s.storer.GetAPITokenUserID(r.Context(), types.HashAPIToken(token))
+		if err != nil {
+			if errors.Is(err, postgresql.ErrRecordNotFound) {
+				writeError(w, errUnauthorized)
+				return
+			}
+			s.internalError(w, "auth", err)
+			return
+		}
+
+		settings, err := s.storer.GetUserSettings(r.Context(), userID)
+		if err != nil {
+			log.Error("failed to get user settings", "user_id", userID, "error", err.Error())
+			settings = types.DefaultUserSettings(userID)
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey{}, userID)
+		ctx = types.ContextWithUserSettings(ctx, settings)
+		next(w, r.WithContext(ctx))
+	})
+}
+
+func userIDFromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(userIDKey{}).(int64)
+	return id
+}
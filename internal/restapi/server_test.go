@@ -0,0 +1,267 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/config"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/types"
+
+	tmdbLib "github.com/cyruzin/golang-tmdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "wtw_test"
+
+type fakeStorer struct {
+	Storer
+
+	favorites map[types.ContentType][]int64
+}
+
+func (s *fakeStorer) GetAPITokenUserID(ctx context.Context, tokenHash string) (int64, error) {
+	if tokenHash != types.HashAPIToken(testToken) {
+		return 0, postgresql.ErrRecordNotFound
+	}
+	return 42, nil
+}
+
+func (s *fakeStorer) GetUserSettings(ctx context.Context, userID int64) (types.UserSettings, error) {
+	return types.DefaultUserSettings(userID), nil
+}
+
+func (s *fakeStorer) GetContentStatus(ctx context.Context, userID int64, item types.ContentItem) (types.ContentStatus, error) {
+	return types.ContentStatus{IsFavorite: slices.Contains(s.favorites[item.ContentType], item.ID)}, nil
+}
+
+// AddContentItemToFavorite knows the titles with the ids below 100 only, like the content table.
+func (s *fakeStorer) AddContentItemToFavorite(ctx context.Context, userID int64, item types.ContentItem) error {
+	if item.ID >= 100 {
+		return fmt.Errorf("failed to insert favorites: %w", postgresql.ErrContentNotFound)
+	}
+	s.favorites[item.ContentType] = append(s.favorites[item.ContentType], item.ID)
+	return nil
+}
+
+func (s *fakeStorer) GetFavoriteContentIDs(ctx context.Context, userID int64, contentType types.ContentType) ([]int64, error) {
+	return s.favorites[contentType], nil
+}
+
+type fakeDataProvider struct {
+	botkit.DataProvider
+}
+
+// GetContent returns the items in the reversed order to check the titles are reordered.
+func (p *fakeDataProvider) GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error) {
+	content := make(types.Content, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		content = append(content, types.ContentItem{ID: ids[i], ContentType: contentType, Title: "title"})
+	}
+	return content, nil
+}
+
+// GetMovie knows the movies with the ids below 100 only, like TMDb answering the status code 34.
+func (p *fakeDataProvider) GetMovie(ctx context.Context, id int) (types.ContentItem, error) {
+	if id >= 100 {
+		return types.ContentItem{}, tmdbLib.Error{StatusCode: 34, StatusMessage: "The resource you requested could not be found."}
+	}
+	return types.ContentItem{ID: int64(id), ContentType: types.Movie, Title: "title"}, nil
+}
+
+// GetTV fails as if TMDb is unavailable.
+func (p *fakeDataProvider) GetTV(ctx context.Context, id int) (types.ContentItem, error) {
+	return types.ContentItem{}, errors.New("connection refused")
+}
+
+func newTestServer() *Server {
+	storer := &fakeStorer{favorites: map[types.ContentType][]int64{
+		types.Movie: {1, 2, 3},
+		types.TV:    {4, 5},
+	}}
+	return New(&config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)), storer, &fakeDataProvider{})
+}
+
+func Test_parsePagination(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    pagination
+		wantErr bool
+	}{
+		{name: "default", query: "", want: pagination{Page: 1, PerPage: defaultPerPage}},
+		{name: "set", query: "page=3&per_page=50", want: pagination{Page: 3, PerPage: 50}},
+		{name: "zero page", query: "page=0", wantErr: true},
+		{name: "not a number", query: "page=x", wantErr: true},
+		{name: "too large per page", query: "per_page=101", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			got, apiErr := parsePagination(q)
+			if tt.wantErr {
+				require.NotNil(t, apiErr)
+				assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+				return
+			}
+			require.Nil(t, apiErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_paginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name string
+		p    pagination
+		want []int
+	}{
+		{name: "first", p: pagination{Page: 1, PerPage: 2}, want: []int{1, 2}},
+		{name: "last", p: pagination{Page: 3, PerPage: 2}, want: []int{5}},
+		{name: "out of range", p: pagination{Page: 4, PerPage: 2}, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, paginate(items, tt.p))
+		})
+	}
+}
+
+func Test_Handler(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantCode   string
+		wantIDs    []int64
+		wantTotal  int
+	}{
+		{name: "no token", path: "/v1/favorites", wantStatus: http.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "invalid token", path: "/v1/favorites", token: "wtw_other", wantStatus: http.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "all types", path: "/v1/favorites?per_page=4", token: testToken, wantStatus: http.StatusOK, wantIDs: []int64{1, 2, 3, 4}, wantTotal: 5},
+		{name: "second page", path: "/v1/favorites?per_page=4&page=2", token: testToken, wantStatus: http.StatusOK, wantIDs: []int64{5}, wantTotal: 5},
+		{name: "one type", path: "/v1/favorites?type=tv", token: testToken, wantStatus: http.StatusOK, wantIDs: []int64{4, 5}, wantTotal: 2},
+		{name: "unknown type", path: "/v1/favorites?type=book", token: testToken, wantStatus: http.StatusBadRequest, wantCode: "bad_request"},
+		{name: "unknown path", path: "/v1/unknown", token: testToken, wantStatus: http.StatusNotFound, wantCode: "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantCode != "" {
+				var body struct {
+					Error apiError `json:"error"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+				return
+			}
+
+			var body page[title]
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			ids := make([]int64, 0, len(body.Items))
+			for _, item := range body.Items {
+				ids = append(ids, item.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantTotal, body.Total)
+		})
+	}
+}
+
+func Test_addHandler(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "added", body: `{"type": "movie", "id": 7}`, wantStatus: http.StatusNoContent},
+		{name: "added twice", body: `{"type": "movie", "id": 1}`, wantStatus: http.StatusNoContent},
+		{name: "unknown title", body: `{"type": "movie", "id": 100}`, wantStatus: http.StatusNotFound, wantCode: "title_not_found"},
+		{name: "invalid id", body: `{"type": "movie", "id": -1}`, wantStatus: http.StatusBadRequest, wantCode: "bad_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/favorites", strings.NewReader(tt.body))
+			r.Header.Set("Authorization", "Bearer "+testToken)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantCode != "" {
+				var body struct {
+					Error apiError `json:"error"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+		})
+	}
+}
+
+func Test_titleHandler(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "found", path: "/v1/titles/movie/1", wantStatus: http.StatusOK},
+		{name: "missing on TMDb", path: "/v1/titles/movie/100", wantStatus: http.StatusNotFound, wantCode: "not_found"},
+		{name: "TMDb is unavailable", path: "/v1/titles/tv/1", wantStatus: http.StatusBadGateway, wantCode: "upstream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer "+testToken)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantCode != "" {
+				var body struct {
+					Error apiError `json:"error"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// SetAPIToken stores the hash of the user API token, the previous token of the user is revoked.
+func (pg *PostgreSQL) SetAPIToken(ctx context.Context, userID int64, tokenHash string) error {
+	sql, args, err := sq.Insert("users_api_tokens").
+		Columns("user_id", "token_hash").
+		Values(userID, tokenHash).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = now(), last_used_at = NULL").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		if ErrorCode(err) == ForeignKeyViolation {
+			return fmt.Errorf("failed to insert api token (user with id %d not found): %s", userID, err.Error())
+		}
+		return fmt.Errorf("failed to insert api token: %s", err.Error())
+	}
+	return nil
+}
+
+// GetAPITokenUserID returns the owner of the token hash and marks the token used.
+// It returns ErrRecordNotFound if there is no such token or the user is deleted.
+func (pg *PostgreSQL) GetAPITokenUserID(ctx context.Context, tokenHash string) (int64, error) {
+	sql, args, err := sq.Update("users_api_tokens t").
+		Set("last_used_at", sq.Expr("now()")).
+		From("users u").
+		Where("u.id = t.user_id AND u.deleted_at IS NULL").
+		Where(sq.Eq{"t.token_hash": tokenHash}).
+		Suffix("RETURNING t.user_id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var userID int64
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, fmt.Errorf("failed to get api token: %s", err.Error())
+	}
+	return userID, nil
+}
@@ -0,0 +1,279 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetContentDetails returns the titles of the ids with the details in the language updated after the time.
+// The titles without such details are missing in the result. The genres have the ids only.
+func (pg *PostgreSQL) GetContentDetails(ctx context.Context, contentType types.ContentType, ids []int64, language string, updatedAfter time.Time) (types.Content, error) {
+	sql, args, err := sq.Select(
+		"id",
+		"coalesce(localized_title, title)",
+		"coalesce(overview, '')",
+		"coalesce(popularity, 0)",
+		"coalesce(poster_path, '')",
+		"coalesce(backdrop_path, '')",
+		"release_date",
+		"coalesce(vote_average, 0)",
+		"coalesce(vote_count, 0)",
+		"coalesce(runtime, 0)",
+		"coalesce(countries, '{}')",
+		"coalesce(adult, false)",
+		"coalesce(collection_id, 0)",
+	).
+		From("content").
+		Where(sq.Eq{"content_type_id": contentType.ID(), "id": ids, "details_language": language}).
+		Where(sq.GtOrEq{"details_updated_at": updatedAfter}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get content details: %s", err.Error())
+	}
+	defer rows.Close()
+
+	content := make(types.Content, 0, len(ids))
+	for rows.Next() {
+		item := types.ContentItem{ContentType: contentType}
+		var releaseDate *time.Time
+		err = rows.Scan(
+			&item.ID,
+			&item.Title,
+			&item.Overview,
+			&item.Popularity,
+			&item.PosterPath,
+			&item.BackdropPath,
+			&releaseDate,
+			&item.VoteAverage,
+			&item.VoteCount,
+			&item.Runtime,
+			&item.Counties,
+			&item.Adult,
+			&item.CollectionID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		if releaseDate != nil {
+			item.ReleaseDate = *releaseDate
+		}
+		content = append(content, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get content details: %s", err.Error())
+	}
+
+	if len(content) == 0 {
+		return content, nil
+	}
+
+	genres, err := pg.getContentGenres(ctx, contentType, content.IDs())
+	if err != nil {
+		return nil, err
+	}
+	for i := range content {
+		for _, g := range genres[content[i].ID] {
+			content[i].Genres = append(content[i].Genres, types.Genre{ID: g.GenreID})
+		}
+	}
+	return content, nil
+}
+
+func (pg *PostgreSQL) getContentGenres(ctx context.Context, contentType types.ContentType, ids []int64) (map[int64][]types.ContentGenre, error) {
+	sql, args, err := sq.Select("content_id", "genre_id").
+		From("content_genres").
+		Where(sq.Eq{"content_type_id": contentType.ID(), "content_id": ids}).
+		OrderBy("content_id", "genre_id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get content genres: %s", err.Error())
+	}
+	defer rows.Close()
+
+	genres := make(map[int64][]types.ContentGenre)
+	for rows.Next() {
+		g := types.ContentGenre{ContentType: contentType}
+		if err = rows.Scan(&g.ContentID, &g.GenreID); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		genres[g.ContentID] = append(genres[g.ContentID], g)
+	}
+	return genres, nil
+}
+
+// UpsertContentDetails stores the details of the titles in the language and replaces their genres.
+// The titles missing in the catalog, e.g. released after the last load, are added with the localized title
+// and marked seen now as TMDb has them.
+func (pg *PostgreSQL) UpsertContentDetails(ctx context.Context, content types.Content, language string) error {
+	if len(content) == 0 {
+		return nil
+	}
+
+	builder := sq.Insert("content").Columns(
+		"id",
+		"content_type_id",
+		"title",
+		"popularity",
+		"localized_title",
+		"overview",
+		"poster_path",
+		"backdrop_path",
+		"release_date",
+		"vote_average",
+		"vote_count",
+		"runtime",
+		"countries",
+		"adult",
+		"collection_id",
+		"details_language",
+		"details_updated_at",
+		"last_seen_at",
+	)
+	for _, c := range content {
+		var releaseDate *time.Time
+		if !c.ReleaseDate.IsZero() {
+			releaseDate = &c.ReleaseDate
+		}
+		builder = builder.Values(
+			c.ID,
+			c.ContentType.ID(),
+			c.Title,
+			c.Popularity,
+			c.Title,
+			c.Overview,
+			c.PosterPath,
+			c.BackdropPath,
+			releaseDate,
+			c.VoteAverage,
+			c.VoteCount,
+			c.Runtime,
+			c.Counties,
+			c.Adult,
+			c.CollectionID,
+			language,
+			sq.Expr("now()"),
+			sq.Expr("now()"),
+		)
+	}
+
+	sql, args, err := builder.Suffix(`ON CONFLICT (id, content_type_id) DO UPDATE SET
+		localized_title = excluded.localized_title,
+		overview = excluded.overview,
+		poster_path = excluded.poster_path,
+		backdrop_path = excluded.backdrop_path,
+		release_date = excluded.release_date,
+		vote_average = excluded.vote_average,
+		vote_count = excluded.vote_count,
+		runtime = excluded.runtime,
+		countries = excluded.countries,
+		adult = excluded.adult,
+		collection_id = excluded.collection_id,
+		details_language = excluded.details_language,
+		details_updated_at = excluded.details_updated_at`).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	tx, err := pg.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to upsert content details: %s", err.Error())
+	}
+
+	if err = replaceContentGenres(ctx, tx, content); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %s", err.Error())
+	}
+	return nil
+}
+
+func replaceContentGenres(ctx context.Context, tx pgx.Tx, content types.Content) error {
+	titles := sq.Or{}
+	genres := sq.Insert("content_genres").Columns("content_id", "content_type_id", "genre_id")
+	hasGenres := false
+	for _, c := range content {
+		titles = append(titles, sq.Eq{"content_id": c.ID, "content_type_id": c.ContentType.ID()})
+		for _, g := range c.Genres {
+			genres = genres.Values(c.ID, c.ContentType.ID(), g.ID)
+			hasGenres = true
+		}
+	}
+
+	sql, args, err := sq.Delete("content_genres").Where(titles).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to delete content genres: %s", err.Error())
+	}
+
+	if !hasGenres {
+		return nil
+	}
+
+	sql, args, err = genres.Suffix("ON CONFLICT DO NOTHING").PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to insert content genres: %s", err.Error())
+	}
+	return nil
+}
+
+// GetStaleContentIDs returns the ids of the titles of the type the users favorited, viewed or listed
+// which have no details in the language or the details updated before the time, the never enriched first.
+// The titles removed from TMDb are skipped as they can't be fetched anymore.
+func (pg *PostgreSQL) GetStaleContentIDs(ctx context.Context, contentType types.ContentType, language string, updatedBefore time.Time, limit int) ([]int64, error) {
+	sql := `SELECT c.id
+	FROM content c
+	WHERE c.content_type_id = $1
+		AND c.removed_at IS NULL
+		AND (c.details_updated_at IS NULL OR c.details_updated_at < $2 OR c.details_language IS DISTINCT FROM $3)
+		AND (
+			EXISTS (SELECT 1 FROM users_favorites f WHERE f.content_id = c.id AND f.content_type_id = c.content_type_id)
+			OR EXISTS (SELECT 1 FROM users_watch_log w WHERE w.content_id = c.id AND w.content_type_id = c.content_type_id)
+			OR EXISTS (SELECT 1 FROM user_list_items l WHERE l.content_id = c.id AND l.content_type_id = c.content_type_id)
+		)
+	ORDER BY c.details_updated_at NULLS FIRST, c.id
+	LIMIT $4`
+
+	rows, err := pg.conn.Query(ctx, sql, contentType.ID(), updatedBefore, language, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale content: %s", err.Error())
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// RefreshCommunityFeed recomputes the community feed materialized view without blocking the readers.
+func (pg *PostgreSQL) RefreshCommunityFeed(ctx context.Context) error {
+	if _, err := pg.conn.Exec(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY community_feed"); err != nil {
+		return fmt.Errorf("failed to refresh community feed: %s", err.Error())
+	}
+	return nil
+}
+
+// GetCommunityFeedIDs returns the ids of the titles popular with the bot users, the top first,
+// without the ones the user has already viewed and the removed ones.
+func (pg *PostgreSQL) GetCommunityFeedIDs(ctx context.Context, userID int64, contentType types.ContentType, limit int) ([]int64, error) {
+	viewedSQL, viewedArgs, err := sq.Select("1").
+		From("users_watch_log w").
+		Where("w.content_id = f.content_id AND w.content_type_id = f.content_type_id").
+		Where(sq.Eq{"w.user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build viewed subquery: %s", err.Error())
+	}
+
+	sql, args, err := sq.Select("f.content_id").
+		From("community_feed f").
+		Where(sq.Eq{"f.content_type_id": contentType.ID()}).
+		Where("NOT EXISTS ("+viewedSQL+")", viewedArgs...).
+		Where("NOT EXISTS (SELECT 1 FROM content c WHERE c.id = f.content_id AND c.content_type_id = f.content_type_id AND c.removed_at IS NOT NULL)").
+		OrderBy("f.score DESC", "f.users DESC", "f.content_id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get community feed: %s", err.Error())
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
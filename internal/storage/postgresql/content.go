@@ -3,17 +3,22 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"time"
 	"whattowatch/internal/types"
 
 	sq "github.com/Masterminds/squirrel"
 )
 
-func (pg *PostgreSQL) InsertContent(ctx context.Context, content types.Content) error {
+// InsertContent inserts the titles of the full export or updates their title and popularity.
+// The titles are marked seen at the time of the export run and no longer removed.
+// The loader copies the export to the staging and merges it instead, see MergeContentStaging.
+func (pg *PostgreSQL) InsertContent(ctx context.Context, content types.Content, seenAt time.Time) error {
 	contentBuilder := sq.Insert("content").Columns(
 		"id",
 		"content_type_id",
 		"title",
 		"popularity",
+		"last_seen_at",
 	).PlaceholderFormat(sq.Dollar)
 
 	for _, c := range content {
@@ -22,20 +27,65 @@ func (pg *PostgreSQL) InsertContent(ctx context.Context, content types.Content)
 			c.ContentType.ID(),
 			c.Title,
 			c.Popularity,
+			seenAt,
 		)
 	}
 
-	// Insert content and genres
-	contentSql, contentArgs, err := contentBuilder.Suffix("ON CONFLICT DO NOTHING").ToSql()
+	contentSql, contentArgs, err := contentBuilder.Suffix(`ON CONFLICT (id, content_type_id) DO UPDATE SET
+		title = excluded.title,
+		popularity = excluded.popularity,
+		last_seen_at = excluded.last_seen_at,
+		removed_at = NULL`).ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build insert content query: %s", err.Error())
 	}
 
 	_, err = pg.conn.Exec(ctx, contentSql, contentArgs...)
 	if err != nil {
-		return fmt.Errorf("failed to insert content: %s; data: %v", err.Error(), content)
+		return fmt.Errorf("failed to insert content: %s", err.Error())
+	}
+	return nil
+}
+
+// UpsertContent inserts the changed titles or updates their title and popularity.
+// The catalog details of the updated titles are marked stale, so the enrichment refreshes them,
+// and the titles are marked seen now and no longer removed as TMDb has them.
+func (pg *PostgreSQL) UpsertContent(ctx context.Context, content types.Content) error {
+	if len(content) == 0 {
+		return nil
+	}
+
+	builder := sq.Insert("content").Columns(
+		"id",
+		"content_type_id",
+		"title",
+		"popularity",
+		"last_seen_at",
+	)
+	for _, c := range content {
+		builder = builder.Values(
+			c.ID,
+			c.ContentType.ID(),
+			c.Title,
+			c.Popularity,
+			sq.Expr("now()"),
+		)
+	}
+
+	sql, args, err := builder.Suffix(`ON CONFLICT (id, content_type_id) DO UPDATE SET
+		title = excluded.title,
+		popularity = excluded.popularity,
+		last_seen_at = excluded.last_seen_at,
+		removed_at = NULL,
+		details_updated_at = NULL`).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build upsert content query: %s", err.Error())
+	}
+
+	if _, err = pg.conn.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to upsert content: %s", err.Error())
 	}
-	// pg.log.Debug("content inserted", "type", content[0].ContentType, "ids", content.GetIDsWithGenres())
 	return nil
 }
 
@@ -48,17 +98,41 @@ func (pg *PostgreSQL) GetContentStatus(ctx context.Context, userID int64, item t
 		return types.ContentStatus{}, fmt.Errorf("failed to build favorite subquery: %s", err.Error())
 	}
 
-	viewedSQL, viewArgs, err := sq.Select("*").
-		From("users_viewed t2").
-		Where(sq.Eq{"t2.user_id": userID, "t2.content_id": item.ID, "t2.content_type_id": item.ContentType.ID()}).ToSql()
+	viewedWhere := sq.Eq{"t2.user_id": userID, "t2.content_id": item.ID, "t2.content_type_id": item.ContentType.ID()}
+	viewedSQL, viewArgs, err := sq.Select("count(*)").From("users_watch_log t2").Where(viewedWhere).ToSql()
 
 	if err != nil {
 		return types.ContentStatus{}, fmt.Errorf("failed to build viewed subquery: %s", err.Error())
 	}
 
+	watchedSQL, watchedArgs, err := sq.Select("max(t2.watched_at)").From("users_watch_log t2").Where(viewedWhere).ToSql()
+
+	if err != nil {
+		return types.ContentStatus{}, fmt.Errorf("failed to build watched subquery: %s", err.Error())
+	}
+
+	noteSQL, noteArgs, err := sq.Select("t3.text").
+		From("users_notes t3").
+		Where(sq.Eq{"t3.user_id": userID, "t3.content_id": item.ID, "t3.content_type_id": item.ContentType.ID()}).ToSql()
+
+	if err != nil {
+		return types.ContentStatus{}, fmt.Errorf("failed to build note subquery: %s", err.Error())
+	}
+
+	ratingSQL, ratingArgs, err := sq.Select("t4.rating").
+		From("users_ratings t4").
+		Where(sq.Eq{"t4.user_id": userID, "t4.content_id": item.ID, "t4.content_type_id": item.ContentType.ID()}).ToSql()
+
+	if err != nil {
+		return types.ContentStatus{}, fmt.Errorf("failed to build rating subquery: %s", err.Error())
+	}
+
 	query := sq.Select(
 		fmt.Sprintf("EXISTS(%s) AS is_favorite", favoriteSQL),
-		fmt.Sprintf("EXISTS(%s) AS is_viewed", viewedSQL),
+		fmt.Sprintf("(%s) AS views_count", viewedSQL),
+		fmt.Sprintf("(%s) AS watched_at", watchedSQL),
+		fmt.Sprintf("COALESCE((%s), '') AS note", noteSQL),
+		fmt.Sprintf("COALESCE((%s), 0) AS rating", ratingSQL),
 	).PlaceholderFormat(sq.Dollar)
 
 	sql, _, err := query.ToSql()
@@ -73,12 +147,16 @@ func (pg *PostgreSQL) GetContentStatus(ctx context.Context, userID int64, item t
 	}
 
 	args := append(favArgs, viewArgs...)
+	args = append(args, watchedArgs...)
+	args = append(args, noteArgs...)
+	args = append(args, ratingArgs...)
 
-	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&cs.IsFavorite, &cs.IsViewed)
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&cs.IsFavorite, &cs.ViewsCount, &cs.WatchedAt, &cs.Note, &cs.Rating)
 	if err != nil {
 		pg.log.Error("failed to get content status", "error", err.Error(), "sql", sql, "args", args)
 		return types.ContentStatus{}, fmt.Errorf("failed to get content: %s", err.Error())
 	}
+	cs.IsViewed = cs.ViewsCount > 0
 
 	return cs, nil
 }
@@ -94,12 +172,13 @@ func (pg *PostgreSQL) AddContentItemToFavorite(ctx context.Context, userID int64
 
 	_, err = pg.conn.Exec(ctx, sql, args...)
 	if err != nil {
-		errCode := ErrorCode(err)
-		if errCode == ForeignKeyViolation || errCode == UniqueViolation {
-			return fmt.Errorf("failed to insert favorites (content with id %d and type %s not found): %s", item.ID, item.ContentType, err.Error())
-		} else {
-			return fmt.Errorf("failed to insert viewed: %s", err.Error())
+		switch ErrorCode(err) {
+		case ForeignKeyViolation:
+			return fmt.Errorf("failed to insert favorites (content with id %d and type %s): %w", item.ID, item.ContentType, ErrContentNotFound)
+		case UniqueViolation:
+			return fmt.Errorf("failed to insert favorites (content with id %d and type %s is already favorite): %s", item.ID, item.ContentType, err.Error())
 		}
+		return fmt.Errorf("failed to insert favorites: %s", err.Error())
 	}
 	return nil
 }
@@ -148,10 +227,11 @@ func (pg *PostgreSQL) GetFavoriteContentIDs(ctx context.Context, userID int64, c
 	return ids, nil
 }
 
+// AddContentItemToViewed adds the watch log entry dated now. Adding the viewed item again is a rewatch.
 func (pg *PostgreSQL) AddContentItemToViewed(ctx context.Context, userID int64, item types.ContentItem) error {
-	sql, args, err := sq.Insert("users_viewed").
-		Columns("user_id", "content_id", "content_type_id").
-		Values(userID, item.ID, item.ContentType.ID()).
+	sql, args, err := sq.Insert("users_watch_log").
+		Columns("user_id", "content_id", "content_type_id", "watched_at").
+		Values(userID, item.ID, item.ContentType.ID(), time.Now()).
 		PlaceholderFormat(sq.Dollar).ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build sql query: %s", err.Error())
@@ -159,9 +239,8 @@ func (pg *PostgreSQL) AddContentItemToViewed(ctx context.Context, userID int64,
 
 	_, err = pg.conn.Exec(ctx, sql, args...)
 	if err != nil {
-		errCode := ErrorCode(err)
-		if errCode == ForeignKeyViolation || errCode == UniqueViolation {
-			return fmt.Errorf("failed to insert viewed (content with id %d and type %s not found): %s", item.ID, item.ContentType, err.Error())
+		if ErrorCode(err) == ForeignKeyViolation {
+			return fmt.Errorf("failed to insert viewed (content with id %d and type %s): %w", item.ID, item.ContentType, ErrContentNotFound)
 		} else {
 			return fmt.Errorf("failed to insert viewed: %s", err.Error())
 		}
@@ -169,8 +248,9 @@ func (pg *PostgreSQL) AddContentItemToViewed(ctx context.Context, userID int64,
 	return nil
 }
 
+// RemoveContentItemFromViewed removes all the watch log entries of the item.
 func (pg *PostgreSQL) RemoveContentItemFromViewed(ctx context.Context, userID int64, item types.ContentItem) error {
-	sql, args, err := sq.Delete("users_viewed").
+	sql, args, err := sq.Delete("users_watch_log").
 		Where(sq.Eq{"user_id": userID, "content_id": item.ID, "content_type_id": item.ContentType.ID()}).
 		PlaceholderFormat(sq.Dollar).ToSql()
 	if err != nil {
@@ -184,11 +264,43 @@ func (pg *PostgreSQL) RemoveContentItemFromViewed(ctx context.Context, userID in
 	return nil
 }
 
+// SetLastWatchDate changes the date of the latest watch log entry of the item.
+// The latest entry is the one with the greatest date, the same as the watched_at of GetContentStatus.
+func (pg *PostgreSQL) SetLastWatchDate(ctx context.Context, userID int64, item types.ContentItem, watchedAt time.Time) error {
+	lastSQL, lastArgs, err := sq.Select("id").
+		From("users_watch_log").
+		Where(sq.Eq{"user_id": userID, "content_id": item.ID, "content_type_id": item.ContentType.ID()}).
+		OrderBy("watched_at DESC NULLS LAST", "id DESC").
+		Limit(1).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build last entry subquery: %s", err.Error())
+	}
+
+	sql, args, err := sq.Update("users_watch_log").
+		Set("watched_at", watchedAt).
+		Where(sq.Expr(fmt.Sprintf("id = (%s)", lastSQL), lastArgs...)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	tag, err := pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update watch date: %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to update watch date: content with id %d and type %s is not viewed", item.ID, item.ContentType)
+	}
+	return nil
+}
+
 func (pg *PostgreSQL) GetViewedContentIDs(ctx context.Context, userID int64, contentType types.ContentType) ([]int64, error) {
 	sql, args, err := sq.Select("t1.id").
 		From("content t1").
-		Join("users_viewed t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
+		Join("users_watch_log t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
 		Where(sq.Eq{"t2.user_id": userID, "t1.content_type_id": contentType.ID()}).
+		GroupBy("t1.id").
+		OrderBy("min(t2.id)").
 		PlaceholderFormat(sq.Dollar).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
@@ -211,3 +323,39 @@ func (pg *PostgreSQL) GetViewedContentIDs(ctx context.Context, userID int64, con
 	}
 	return ids, nil
 }
+
+// GetWatchHistory returns the page of the user watch log, the latest first. Entries with the unknown date go last.
+func (pg *PostgreSQL) GetWatchHistory(ctx context.Context, userID int64, limit, offset int) (types.WatchHistory, error) {
+	sql, args, err := sq.Select("t2.id", "t1.id", "t1.content_type_id", "coalesce(t1.localized_title, t1.title)", "t2.watched_at", "t2.view_number").
+		From("content t1").
+		JoinClause(`JOIN (
+			SELECT *, row_number() OVER (PARTITION BY content_id, content_type_id ORDER BY watched_at NULLS FIRST, id) AS view_number
+			FROM users_watch_log WHERE user_id = ?
+		) t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id`, userID).
+		OrderBy("t2.watched_at DESC NULLS LAST", "t2.id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watch history: %s", err.Error())
+	}
+	defer rows.Close()
+
+	history := make(types.WatchHistory, 0)
+	for rows.Next() {
+		var entry types.WatchEntry
+		var contentTypeID int
+		err = rows.Scan(&entry.ID, &entry.ContentID, &contentTypeID, &entry.Title, &entry.WatchedAt, &entry.ViewNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		entry.ContentType = types.ContentType(contentTypeID)
+		history = append(history, entry)
+	}
+	return history, nil
+}
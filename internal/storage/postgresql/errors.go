@@ -14,6 +14,9 @@ const (
 
 var ErrRecordNotFound = pgx.ErrNoRows
 
+// ErrContentNotFound is returned when a user row refers to a title missing from the content table.
+var ErrContentNotFound = errors.New("content not found")
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
@@ -0,0 +1,162 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// InsertEvents appends the events to the event log by a single query.
+func (pg *PostgreSQL) InsertEvents(ctx context.Context, events []types.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := sq.Insert("events").Columns("user_id", "type", "name", "content_id", "content_type_id", "payload", "created_at")
+	for _, e := range events {
+		var contentID, contentTypeID any
+		if e.ContentID != 0 {
+			contentID, contentTypeID = e.ContentID, e.ContentType.ID()
+		}
+		var payload any
+		if len(e.Payload) > 0 {
+			payload = e.Payload
+		}
+		query = query.Values(e.UserID, string(e.Type), e.Name, contentID, contentTypeID, payload, e.CreatedAt)
+	}
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	if _, err = pg.conn.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to insert events: %s", err.Error())
+	}
+	return nil
+}
+
+// GetFunnel counts the users passed the ordered funnel steps since the time: each step is counted
+// if the user did it after the first time they did the previous one.
+func (pg *PostgreSQL) GetFunnel(ctx context.Context, steps []types.EventType, since time.Time) (types.Funnel, error) {
+	if len(steps) == 0 {
+		return types.Funnel{}, nil
+	}
+
+	args := []any{since}
+	ctes := make([]string, 0, len(steps))
+	counts := make([]string, 0, len(steps))
+	for i, step := range steps {
+		args = append(args, string(step))
+		if i == 0 {
+			ctes = append(ctes, fmt.Sprintf(
+				"s0 AS (SELECT user_id, min(created_at) AS at FROM events WHERE type = $%d AND created_at >= $1 GROUP BY user_id)",
+				len(args),
+			))
+		} else {
+			ctes = append(ctes, fmt.Sprintf(
+				"s%d AS (SELECT e.user_id, min(e.created_at) AS at FROM events e JOIN s%d p ON p.user_id = e.user_id AND e.created_at >= p.at WHERE e.type = $%d GROUP BY e.user_id)",
+				i, i-1, len(args),
+			))
+		}
+		counts = append(counts, fmt.Sprintf("(SELECT count(*) FROM s%d)", i))
+	}
+	sql := fmt.Sprintf("WITH %s SELECT %s", strings.Join(ctes, ", "), strings.Join(counts, ", "))
+
+	funnel := make(types.Funnel, len(steps))
+	dest := make([]any, len(steps))
+	for i, step := range steps {
+		funnel[i].Type = step
+		dest[i] = &funnel[i].Users
+	}
+
+	if err := pg.conn.QueryRow(ctx, sql, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to get funnel: %s", err.Error())
+	}
+	return funnel, nil
+}
+
+// GetRetentionCohorts groups the users by the week of their first event since the time
+// and counts how many of them were active in each following week.
+func (pg *PostgreSQL) GetRetentionCohorts(ctx context.Context, since time.Time) (types.RetentionCohorts, error) {
+	sql := `WITH firsts AS (
+		SELECT user_id, date_trunc('week', min(created_at)) AS cohort FROM events GROUP BY user_id
+	), activity AS (
+		SELECT DISTINCT user_id, date_trunc('week', created_at) AS week FROM events WHERE created_at >= date_trunc('week', $1::timestamptz)
+	)
+	SELECT f.cohort, (a.week::date - f.cohort::date) / 7 AS week_offset, count(*)
+	FROM firsts f JOIN activity a ON a.user_id = f.user_id
+	WHERE f.cohort >= date_trunc('week', $1::timestamptz)
+	GROUP BY f.cohort, week_offset
+	ORDER BY f.cohort, week_offset`
+
+	rows, err := pg.conn.Query(ctx, sql, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention cohorts: %s", err.Error())
+	}
+	defer rows.Close()
+
+	cohorts := make(types.RetentionCohorts, 0)
+	for rows.Next() {
+		var (
+			week   time.Time
+			offset int
+			count  int
+		)
+		if err = rows.Scan(&week, &offset, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+
+		if len(cohorts) == 0 || !cohorts[len(cohorts)-1].Week.Equal(week) {
+			cohorts = append(cohorts, types.RetentionCohort{Week: week})
+		}
+		cohort := &cohorts[len(cohorts)-1]
+		// The weeks without the active users have no rows.
+		for len(cohort.Active) < offset {
+			cohort.Active = append(cohort.Active, 0)
+		}
+		cohort.Active = append(cohort.Active, count)
+	}
+	return cohorts, nil
+}
+
+// GetEventsUsage counts the events of the types and their users since the time by the type and the name,
+// the most used first.
+func (pg *PostgreSQL) GetEventsUsage(ctx context.Context, eventTypes []types.EventType, since time.Time, limit int) (types.EventsUsage, error) {
+	typeNames := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		typeNames = append(typeNames, string(t))
+	}
+
+	sql, args, err := sq.Select("type", "name", "count(*) AS events", "count(DISTINCT user_id)").
+		From("events").
+		Where(sq.Eq{"type": typeNames}).
+		Where(sq.GtOrEq{"created_at": since}).
+		GroupBy("type", "name").
+		OrderBy("events DESC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events usage: %s", err.Error())
+	}
+	defer rows.Close()
+
+	usage := make(types.EventsUsage, 0, limit)
+	for rows.Next() {
+		var u types.EventUsage
+		if err = rows.Scan(&u.Type, &u.Name, &u.Events, &u.Users); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
@@ -0,0 +1,68 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GetFSMSession returns the encoded conversation state of the user, nil if there is none.
+func (pg *PostgreSQL) GetFSMSession(ctx context.Context, userID int64) ([]byte, error) {
+	sql, args, err := sq.Select("data").
+		From("fsm_sessions").
+		Where(sq.Eq{"user_id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var data []byte
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&data)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get fsm session: %s", err.Error())
+	}
+	return data, nil
+}
+
+// SaveFSMSession replaces the encoded conversation state of the user.
+func (pg *PostgreSQL) SaveFSMSession(ctx context.Context, userID int64, data []byte, expiresAt sql.NullTime) error {
+	sql, args, err := sq.Insert("fsm_sessions").
+		Columns("user_id", "data", "expires_at", "updated_at").
+		Values(userID, data, expiresAt, time.Now()).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			data = EXCLUDED.data,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = EXCLUDED.updated_at`).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to save fsm session: %s", err.Error())
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) DeleteFSMSession(ctx context.Context, userID int64) error {
+	sql, args, err := sq.Delete("fsm_sessions").
+		Where(sq.Eq{"user_id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete fsm session: %s", err.Error())
+	}
+	return nil
+}
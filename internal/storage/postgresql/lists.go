@@ -0,0 +1,284 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+func (pg *PostgreSQL) CreateList(ctx context.Context, userID int64, name string) (types.UserList, error) {
+	list := types.UserList{UserID: userID, Name: name, CreatedAt: time.Now()}
+
+	sql, args, err := sq.Insert("user_lists").
+		Columns("user_id", "name", "created_at").
+		Values(list.UserID, list.Name, list.CreatedAt).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return types.UserList{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&list.ID)
+	if err != nil {
+		return types.UserList{}, fmt.Errorf("failed to insert list: %s", err.Error())
+	}
+	return list, nil
+}
+
+func (pg *PostgreSQL) RenameList(ctx context.Context, userID int64, listID int64, name string) error {
+	return pg.updateList(ctx, userID, listID, sq.Eq{"name": name})
+}
+
+// SetListShareToken opens the read-only access to the list by the token. An empty token closes the access.
+func (pg *PostgreSQL) SetListShareToken(ctx context.Context, userID int64, listID int64, token string) error {
+	var value any
+	if token != "" {
+		value = token
+	}
+	return pg.updateList(ctx, userID, listID, sq.Eq{"share_token": value})
+}
+
+func (pg *PostgreSQL) updateList(ctx context.Context, userID int64, listID int64, values sq.Eq) error {
+	sql, args, err := sq.Update("user_lists").
+		SetMap(values).
+		Set("updated_at", time.Now()).
+		Where(sq.Eq{"id": listID, "user_id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	tag, err := pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update list: %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to update list: %s", ErrRecordNotFound.Error())
+	}
+	return nil
+}
+
+// DeleteList deletes the list with all its items.
+func (pg *PostgreSQL) DeleteList(ctx context.Context, userID int64, listID int64) error {
+	sql, args, err := sq.Delete("user_lists").
+		Where(sq.Eq{"id": listID, "user_id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete list: %s", err.Error())
+	}
+	return nil
+}
+
+func listSelect() sq.SelectBuilder {
+	return sq.Select(
+		"t1.id",
+		"t1.user_id",
+		"t1.name",
+		"COALESCE(t1.share_token, '')",
+		"(SELECT count(*) FROM user_list_items t2 WHERE t2.list_id = t1.id)",
+		"t1.created_at",
+	).From("user_lists t1").PlaceholderFormat(sq.Dollar)
+}
+
+func scanList(row interface{ Scan(dest ...any) error }) (types.UserList, error) {
+	var list types.UserList
+	err := row.Scan(&list.ID, &list.UserID, &list.Name, &list.ShareToken, &list.ItemsCount, &list.CreatedAt)
+	return list, err
+}
+
+// GetLists returns the user lists in the creation order.
+func (pg *PostgreSQL) GetLists(ctx context.Context, userID int64) (types.UserLists, error) {
+	sql, args, err := listSelect().Where(sq.Eq{"t1.user_id": userID}).OrderBy("t1.id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lists: %s", err.Error())
+	}
+	defer rows.Close()
+
+	lists := make(types.UserLists, 0)
+	for rows.Next() {
+		list, err := scanList(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// GetList returns the user list by id.
+func (pg *PostgreSQL) GetList(ctx context.Context, userID int64, listID int64) (types.UserList, error) {
+	sql, args, err := listSelect().Where(sq.Eq{"t1.id": listID, "t1.user_id": userID}).ToSql()
+	if err != nil {
+		return types.UserList{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	list, err := scanList(pg.conn.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return types.UserList{}, fmt.Errorf("failed to get list: %s", err.Error())
+	}
+	return list, nil
+}
+
+// GetSharedList returns the list shared by the token.
+func (pg *PostgreSQL) GetSharedList(ctx context.Context, token string) (types.UserList, error) {
+	sql, args, err := listSelect().Where(sq.Eq{"t1.share_token": token}).ToSql()
+	if err != nil {
+		return types.UserList{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	list, err := scanList(pg.conn.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return types.UserList{}, fmt.Errorf("failed to get shared list: %s", err.Error())
+	}
+	return list, nil
+}
+
+// AddListItem adds the item to the list if the list belongs to the user.
+func (pg *PostgreSQL) AddListItem(ctx context.Context, userID int64, listID int64, item types.ContentItem) error {
+	sql, args, err := sq.Insert("user_list_items").
+		Columns("list_id", "content_id", "content_type_id").
+		Select(sq.Select("id", fmt.Sprint(item.ID), fmt.Sprint(item.ContentType.ID())).
+			From("user_lists").
+			Where(sq.Eq{"id": listID, "user_id": userID})).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		if ErrorCode(err) == ForeignKeyViolation {
+			return fmt.Errorf("failed to insert list item (content with id %d and type %s): %w", item.ID, item.ContentType, ErrContentNotFound)
+		}
+		return fmt.Errorf("failed to insert list item: %s", err.Error())
+	}
+	return nil
+}
+
+// RemoveListItem removes the item from the list if the list belongs to the user.
+func (pg *PostgreSQL) RemoveListItem(ctx context.Context, userID int64, listID int64, item types.ContentItem) error {
+	sql, args, err := sq.Delete("user_list_items").
+		Where(sq.Eq{"list_id": listID, "content_id": item.ID, "content_type_id": item.ContentType.ID()}).
+		Where(sq.Expr("EXISTS(SELECT 1 FROM user_lists WHERE id = ? AND user_id = ?)", listID, userID)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete list item: %s", err.Error())
+	}
+	return nil
+}
+
+// GetListContentIDs returns the ids of the list items of the content type in the order they were added.
+func (pg *PostgreSQL) GetListContentIDs(ctx context.Context, listID int64, contentType types.ContentType) ([]int64, error) {
+	sql, args, err := sq.Select("content_id").
+		From("user_list_items").
+		Where(sq.Eq{"list_id": listID, "content_type_id": contentType.ID()}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list content ids: %s", err.Error())
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetItemListIDs returns the ids of the user lists containing the item.
+func (pg *PostgreSQL) GetItemListIDs(ctx context.Context, userID int64, item types.ContentItem) ([]int64, error) {
+	sql, args, err := sq.Select("t1.id").
+		From("user_lists t1").
+		Join("user_list_items t2 ON t1.id = t2.list_id").
+		Where(sq.Eq{"t1.user_id": userID, "t2.content_id": item.ID, "t2.content_type_id": item.ContentType.ID()}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item lists: %s", err.Error())
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (pg *PostgreSQL) getTakeoutLists(ctx context.Context, userID int64) ([]types.TakeoutList, error) {
+	lists, err := pg.GetLists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	takeout := make([]types.TakeoutList, 0, len(lists))
+	for _, list := range lists {
+		sql, args, err := sq.Select("t1.id", "t1.content_type_id", "coalesce(t1.localized_title, t1.title)").
+			From("content t1").
+			Join("user_list_items t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
+			Where(sq.Eq{"t2.list_id": list.ID}).
+			OrderBy("t2.id").
+			PlaceholderFormat(sq.Dollar).ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+		}
+
+		rows, err := pg.conn.Query(ctx, sql, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get list items: %s", err.Error())
+		}
+
+		items := make([]types.TakeoutItem, 0, list.ItemsCount)
+		for rows.Next() {
+			var item types.TakeoutItem
+			var contentTypeID int
+			if err = rows.Scan(&item.ContentID, &contentTypeID, &item.Title); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+			}
+			item.ContentType = types.ContentType(contentTypeID).String()
+			items = append(items, item)
+		}
+		rows.Close()
+
+		takeout = append(takeout, types.TakeoutList{Name: list.Name, Items: items})
+	}
+	return takeout, nil
+}
@@ -0,0 +1,100 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// StartLoaderRun records the start of the loader run in the mode loading the content up to the watermark
+// and returns its id.
+func (pg *PostgreSQL) StartLoaderRun(ctx context.Context, mode types.LoaderMode, watermark time.Time) (int64, error) {
+	sql, args, err := sq.Insert("loader_runs").
+		Columns("started_at", "mode", "watermark").
+		Values(sq.Expr("now()"), string(mode), watermark).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var id int64
+	if err = pg.conn.QueryRow(ctx, sql, args...).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to insert loader run: %s", err.Error())
+	}
+	return id, nil
+}
+
+// FinishLoaderRun records the end of the loader run, the run succeeded if runErr is nil.
+func (pg *PostgreSQL) FinishLoaderRun(ctx context.Context, id int64, runErr error) error {
+	var errText *string
+	if runErr != nil {
+		s := runErr.Error()
+		errText = &s
+	}
+
+	sql, args, err := sq.Update("loader_runs").
+		Set("finished_at", sq.Expr("now()")).
+		Set("succeeded", runErr == nil).
+		Set("error", errText).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	if _, err = pg.conn.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to update loader run: %s", err.Error())
+	}
+	return nil
+}
+
+// GetLastSuccessfulLoaderRun returns the finish time of the last successful loader run.
+// It returns ErrRecordNotFound if there was none.
+func (pg *PostgreSQL) GetLastSuccessfulLoaderRun(ctx context.Context) (time.Time, error) {
+	sql, args, err := sq.Select("finished_at").
+		From("loader_runs").
+		Where(sq.Eq{"succeeded": true}).
+		OrderBy("finished_at DESC").
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var finishedAt time.Time
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&finishedAt)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return time.Time{}, ErrRecordNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to get last loader run: %s", err.Error())
+	}
+	return finishedAt, nil
+}
+
+// GetLoaderWatermark returns the watermarks of the last successful run and the last successful full run.
+// It returns ErrRecordNotFound if there was no successful full run, the changes can't be loaded without it.
+func (pg *PostgreSQL) GetLoaderWatermark(ctx context.Context) (types.LoaderWatermark, error) {
+	sql, args, err := sq.Select("max(watermark)").
+		Column(sq.Expr("max(watermark) FILTER (WHERE mode = ?)", string(types.LoaderModeFull))).
+		From("loader_runs").
+		Where(sq.Eq{"succeeded": true}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return types.LoaderWatermark{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var last, full *time.Time
+	if err = pg.conn.QueryRow(ctx, sql, args...).Scan(&last, &full); err != nil {
+		return types.LoaderWatermark{}, fmt.Errorf("failed to get loader watermark: %s", err.Error())
+	}
+	if last == nil || full == nil {
+		return types.LoaderWatermark{}, ErrRecordNotFound
+	}
+	return types.LoaderWatermark{Last: *last, Full: *full}, nil
+}
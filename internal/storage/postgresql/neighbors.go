@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"whattowatch/internal/types"
+)
+
+// userInteractionsLimit limits the latest titles of each user counted in the co-occurrence,
+// so the heavy users don't blow up the number of the pairs.
+const userInteractionsLimit = 200
+
+// refreshNeighborsSQL computes the cosine similarity of the titles by the users who favorited or viewed both:
+// co-occurrence / sqrt(users of the title * users of the neighbor). The pairs with less than $1 common users
+// are skipped as noise, the top $2 neighbors of each title are kept.
+var refreshNeighborsSQL = fmt.Sprintf(`INSERT INTO content_neighbors (content_id, content_type_id, neighbor_id, neighbor_type_id, score, users)
+WITH actions AS (
+	SELECT user_id, content_id, content_type_id, created_at FROM users_favorites
+	UNION ALL
+	SELECT user_id, content_id, content_type_id, created_at FROM users_watch_log
+), interactions AS (
+	SELECT user_id, content_id, content_type_id FROM (
+		SELECT user_id, content_id, content_type_id,
+			row_number() OVER (PARTITION BY user_id ORDER BY max(created_at) DESC) AS rn
+		FROM actions
+		GROUP BY user_id, content_id, content_type_id
+	) t
+	WHERE rn <= %d
+), counts AS (
+	SELECT content_id, content_type_id, count(*) AS users FROM interactions GROUP BY content_id, content_type_id
+), pairs AS (
+	SELECT a.content_id, a.content_type_id, b.content_id AS neighbor_id, b.content_type_id AS neighbor_type_id, count(*) AS users
+	FROM interactions a
+	JOIN interactions b ON b.user_id = a.user_id AND (b.content_id, b.content_type_id) <> (a.content_id, a.content_type_id)
+	GROUP BY a.content_id, a.content_type_id, b.content_id, b.content_type_id
+	HAVING count(*) >= $1
+), scored AS (
+	SELECT p.*, p.users / sqrt(ca.users * cb.users) AS score
+	FROM pairs p
+	JOIN counts ca ON ca.content_id = p.content_id AND ca.content_type_id = p.content_type_id
+	JOIN counts cb ON cb.content_id = p.neighbor_id AND cb.content_type_id = p.neighbor_type_id
+)
+SELECT content_id, content_type_id, neighbor_id, neighbor_type_id, score, users FROM (
+	SELECT s.*, row_number() OVER (PARTITION BY content_id, content_type_id ORDER BY score DESC, users DESC, neighbor_id) AS rn
+	FROM scored s
+) t
+WHERE rn <= $2`, userInteractionsLimit)
+
+// RefreshContentNeighbors recomputes the top neighbors of each title and replaces the old ones in a single transaction.
+// It returns the number of the stored neighbors.
+func (pg *PostgreSQL) RefreshContentNeighbors(ctx context.Context, minUsers int, topN int) (int64, error) {
+	tx, err := pg.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM content_neighbors"); err != nil {
+		return 0, fmt.Errorf("failed to delete content neighbors: %s", err.Error())
+	}
+
+	tag, err := tx.Exec(ctx, refreshNeighborsSQL, minUsers, topN)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert content neighbors: %s", err.Error())
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %s", err.Error())
+	}
+	return tag.RowsAffected(), nil
+}
+
+// similarContentSQL sums the scores of the neighbors of the titles the user favorited or viewed,
+// the titles already favorited or viewed by the user and the removed ones are skipped.
+const similarContentSQL = `WITH seeds AS (
+	SELECT content_id, content_type_id FROM users_favorites WHERE user_id = $1
+	UNION
+	SELECT content_id, content_type_id FROM users_watch_log WHERE user_id = $1
+)
+SELECT n.neighbor_id
+FROM content_neighbors n
+JOIN seeds s ON s.content_id = n.content_id AND s.content_type_id = n.content_type_id
+WHERE n.neighbor_type_id = $2
+	AND NOT EXISTS (SELECT 1 FROM seeds x WHERE x.content_id = n.neighbor_id AND x.content_type_id = n.neighbor_type_id)
+	AND NOT EXISTS (SELECT 1 FROM content c WHERE c.id = n.neighbor_id AND c.content_type_id = n.neighbor_type_id AND c.removed_at IS NOT NULL)
+GROUP BY n.neighbor_id
+ORDER BY sum(n.score) DESC, n.neighbor_id
+LIMIT $3`
+
+// GetSimilarUsersContentIDs returns the ids of the titles of the type watched by the users with the similar taste,
+// the most similar first. It is empty on the cold start: the user or the titles have no interactions yet.
+func (pg *PostgreSQL) GetSimilarUsersContentIDs(ctx context.Context, userID int64, contentType types.ContentType, limit int) ([]int64, error) {
+	rows, err := pg.conn.Query(ctx, similarContentSQL, userID, contentType.ID(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar users content: %s", err.Error())
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,82 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+func (pg *PostgreSQL) SetNote(ctx context.Context, userID int64, item types.ContentItem, text string) error {
+	sql, args, err := sq.Insert("users_notes").
+		Columns("user_id", "content_id", "content_type_id", "text", "created_at").
+		Values(userID, item.ID, item.ContentType.ID(), text, time.Now()).
+		Suffix("ON CONFLICT (user_id, content_id, content_type_id) DO UPDATE SET text = EXCLUDED.text, updated_at = EXCLUDED.created_at").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		if ErrorCode(err) == ForeignKeyViolation {
+			return fmt.Errorf("failed to insert note (content with id %d and type %s not found): %s", item.ID, item.ContentType, err.Error())
+		}
+		return fmt.Errorf("failed to insert note: %s", err.Error())
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) DeleteNote(ctx context.Context, userID int64, item types.ContentItem) error {
+	sql, args, err := sq.Delete("users_notes").
+		Where(sq.Eq{"user_id": userID, "content_id": item.ID, "content_type_id": item.ContentType.ID()}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %s", err.Error())
+	}
+	return nil
+}
+
+// GetNotes returns the page of the user notes with the content titles, the recently changed first.
+// All the notes are returned if the limit is zero.
+func (pg *PostgreSQL) GetNotes(ctx context.Context, userID int64, limit, offset int) (types.UserNotes, error) {
+	builder := sq.Select("t1.id", "t1.content_type_id", "coalesce(t1.localized_title, t1.title)", "t2.text", "COALESCE(t2.updated_at, t2.created_at)").
+		From("content t1").
+		Join("users_notes t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
+		Where(sq.Eq{"t2.user_id": userID}).
+		OrderBy("COALESCE(t2.updated_at, t2.created_at) DESC", "t2.content_type_id", "t2.content_id")
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit)).Offset(uint64(offset))
+	}
+
+	sql, args, err := builder.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %s", err.Error())
+	}
+	defer rows.Close()
+
+	notes := make(types.UserNotes, 0)
+	for rows.Next() {
+		note := types.UserNote{UserID: userID}
+		var contentTypeID int
+		err = rows.Scan(&note.ContentID, &contentTypeID, &note.Title, &note.Text, &note.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		note.ContentType = types.ContentType(contentTypeID)
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 	"whattowatch/internal/config"
+	"whattowatch/internal/tracing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -20,6 +21,8 @@ func New(cfg *config.Config, logger *slog.Logger) (*PostgreSQL, error) {
 		return nil, err
 	}
 
+	pgxConfig.ConnConfig.Tracer = tracing.PgxTracer{}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -38,3 +41,13 @@ func New(cfg *config.Config, logger *slog.Logger) (*PostgreSQL, error) {
 		conn: conn,
 	}, nil
 }
+
+// Stat returns the connection pool statistics.
+func (pg *PostgreSQL) Stat() *pgxpool.Stat {
+	return pg.conn.Stat()
+}
+
+// Ping checks the database is reachable.
+func (pg *PostgreSQL) Ping(ctx context.Context) error {
+	return pg.conn.Ping(ctx)
+}
@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// SetRating sets the personal rating of the title from 1 to 10.
+func (pg *PostgreSQL) SetRating(ctx context.Context, userID int64, item types.ContentItem, rating int) error {
+	sql, args, err := sq.Insert("users_ratings").
+		Columns("user_id", "content_id", "content_type_id", "rating", "created_at").
+		Values(userID, item.ID, item.ContentType.ID(), rating, time.Now()).
+		Suffix("ON CONFLICT (user_id, content_id, content_type_id) DO UPDATE SET rating = EXCLUDED.rating, updated_at = EXCLUDED.created_at").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		if ErrorCode(err) == ForeignKeyViolation {
+			return fmt.Errorf("failed to insert rating (content with id %d and type %s): %w", item.ID, item.ContentType, ErrContentNotFound)
+		}
+		return fmt.Errorf("failed to insert rating: %s", err.Error())
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) getTakeoutRatings(ctx context.Context, userID int64) ([]types.TakeoutRating, error) {
+	sql, args, err := sq.Select("t1.id", "t1.content_type_id", "coalesce(t1.localized_title, t1.title)", "t2.rating").
+		From("content t1").
+		Join("users_ratings t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
+		Where(sq.Eq{"t2.user_id": userID}).
+		OrderBy("t2.created_at").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ratings: %s", err.Error())
+	}
+	defer rows.Close()
+
+	ratings := make([]types.TakeoutRating, 0)
+	for rows.Next() {
+		var r types.TakeoutRating
+		var contentTypeID int
+		if err = rows.Scan(&r.ContentID, &contentTypeID, &r.Title, &r.Rating); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		r.ContentType = types.ContentType(contentTypeID).String()
+		ratings = append(ratings, r)
+	}
+	return ratings, nil
+}
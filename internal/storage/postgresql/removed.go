@@ -0,0 +1,134 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// missingContent is the condition of the titles not removed yet and not seen by the full export run.
+// The titles created after the export was made, e.g. by the changes feed, can't be in it and are not missing.
+func missingContent(contentType types.ContentType, seenBefore, createdBefore time.Time) sq.And {
+	return sq.And{
+		sq.Eq{"content_type_id": contentType.ID(), "removed_at": nil},
+		sq.Or{sq.Eq{"last_seen_at": nil}, sq.Lt{"last_seen_at": seenBefore}},
+		sq.Lt{"created_at": createdBefore},
+	}
+}
+
+// CountMissingContent returns the number of the titles of the type missing from the full export
+// seen at the time and made at createdBefore, and the number of all the titles not removed yet.
+func (pg *PostgreSQL) CountMissingContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int, int, error) {
+	filterSQL, filterArgs, err := missingContent(contentType, seenBefore, createdBefore).ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build filter: %s", err.Error())
+	}
+
+	sql, args, err := sq.Select().
+		Column("count(*) FILTER (WHERE "+filterSQL+")", filterArgs...).
+		Column("count(*)").
+		From("content").
+		Where(sq.Eq{"content_type_id": contentType.ID(), "removed_at": nil}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var missing, total int
+	if err = pg.conn.QueryRow(ctx, sql, args...).Scan(&missing, &total); err != nil {
+		return 0, 0, fmt.Errorf("failed to count missing content: %s", err.Error())
+	}
+	return missing, total, nil
+}
+
+// MarkRemovedContent marks the titles of the type missing from the full export seen at the time
+// and made at createdBefore removed and returns their number.
+func (pg *PostgreSQL) MarkRemovedContent(ctx context.Context, contentType types.ContentType, seenBefore, createdBefore time.Time) (int64, error) {
+	sql, args, err := sq.Update("content").
+		Set("removed_at", sq.Expr("now()")).
+		Where(missingContent(contentType, seenBefore, createdBefore)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	tag, err := pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark removed content: %s", err.Error())
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetRemovedContent returns the removed titles among the ids, only the id, type and title are known.
+func (pg *PostgreSQL) GetRemovedContent(ctx context.Context, contentType types.ContentType, ids []int64) (types.Content, error) {
+	sql, args, err := sq.Select("id", "coalesce(localized_title, title)").
+		From("content").
+		Where(sq.Eq{"content_type_id": contentType.ID(), "id": ids}).
+		Where(sq.NotEq{"removed_at": nil}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get removed content: %s", err.Error())
+	}
+	defer rows.Close()
+
+	content := make(types.Content, 0)
+	for rows.Next() {
+		item := types.ContentItem{ContentType: contentType}
+		if err = rows.Scan(&item.ID, &item.Title); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		content = append(content, item)
+	}
+	return content, nil
+}
+
+// GetRemovedListItems returns the removed titles kept in the user lists, the most listed first.
+func (pg *PostgreSQL) GetRemovedListItems(ctx context.Context, limit int) (types.RemovedListItems, error) {
+	sql, args, err := sq.Select(
+		"c.id",
+		"c.content_type_id",
+		"coalesce(c.localized_title, c.title)",
+		"c.removed_at",
+		"count(DISTINCT l.id)",
+		"count(DISTINCT l.user_id)",
+	).
+		From("content c").
+		Join("user_list_items i ON i.content_id = c.id AND i.content_type_id = c.content_type_id").
+		Join("user_lists l ON l.id = i.list_id").
+		Where(sq.NotEq{"c.removed_at": nil}).
+		GroupBy("c.id", "c.content_type_id").
+		OrderBy("count(DISTINCT l.id) DESC", "c.removed_at DESC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get removed list items: %s", err.Error())
+	}
+	defer rows.Close()
+
+	items := make(types.RemovedListItems, 0)
+	for rows.Next() {
+		var (
+			item          types.RemovedListItem
+			contentTypeID int
+		)
+		if err = rows.Scan(&item.ID, &contentTypeID, &item.Title, &item.RemovedAt, &item.Lists, &item.Users); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		item.ContentType = types.ContentType(contentTypeID)
+		items = append(items, item)
+	}
+	return items, nil
+}
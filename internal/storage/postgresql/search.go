@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// searchSimilarity is the trigram similarity of the better matching of the original and the localized title.
+const searchSimilarity = "greatest(similarity(title, ?), similarity(coalesce(localized_title, ''), ?))"
+
+// searchRank is the similarity with a small bonus for the popularity,
+// so the popular title wins among the equally similar ones but not over the better match.
+const searchRank = searchSimilarity + " + ln(1 + coalesce(popularity, 0)) / 50"
+
+// SearchContent searches the loaded catalog by the similarity of the original or the localized title to the query,
+// the best first. The hits less similar than minSimilarity are dropped, the removed titles are skipped.
+// The titles of any type are searched if the content type is zero. Only the id, type, title and popularity are known,
+// the title is the localized one if there is.
+func (pg *PostgreSQL) SearchContent(ctx context.Context, query string, contentType types.ContentType, minSimilarity float64, limit int) (types.Content, error) {
+	builder := sq.Select("id", "content_type_id", "coalesce(localized_title, title)", "coalesce(popularity, 0)").
+		From("content").
+		Where(sq.Or{sq.Expr("title % ?", query), sq.Expr("localized_title % ?", query)}).
+		Where(sq.Expr(searchSimilarity+" >= ?", query, query, minSimilarity)).
+		Where(sq.Eq{"removed_at": nil})
+	if contentType != 0 {
+		builder = builder.Where(sq.Eq{"content_type_id": contentType.ID()})
+	}
+
+	sql, args, err := builder.
+		OrderByClause(searchRank+" DESC", query, query).
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search content: %s", err.Error())
+	}
+	defer rows.Close()
+
+	content := make(types.Content, 0, limit)
+	for rows.Next() {
+		var (
+			item          types.ContentItem
+			contentTypeID int
+		)
+		if err = rows.Scan(&item.ID, &contentTypeID, &item.Title, &item.Popularity); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		item.ContentType = types.ContentType(contentTypeID)
+		content = append(content, item)
+	}
+	return content, nil
+}
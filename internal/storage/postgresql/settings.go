@@ -0,0 +1,94 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GetUserSettings returns the user settings or the default ones if the user has not changed them yet.
+func (pg *PostgreSQL) GetUserSettings(ctx context.Context, userID int64) (types.UserSettings, error) {
+	sql, args, err := sq.Select(
+		"user_id",
+		"language",
+		"region",
+		"hide_viewed",
+		"min_rating",
+		"include_adult",
+		"card_layout",
+	).
+		From("user_settings").
+		Where(sq.Eq{"user_id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return types.UserSettings{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	var s types.UserSettings
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(
+		&s.UserID,
+		&s.Language,
+		&s.Region,
+		&s.HideViewed,
+		&s.MinRating,
+		&s.IncludeAdult,
+		&s.CardLayout,
+	)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return types.DefaultUserSettings(userID), nil
+		}
+		return types.UserSettings{}, fmt.Errorf("failed to get user settings: %s", err.Error())
+	}
+
+	return s, nil
+}
+
+func (pg *PostgreSQL) UpdateUserSettings(ctx context.Context, s types.UserSettings) error {
+	sql, args, err := sq.Insert("user_settings").
+		Columns(
+			"user_id",
+			"language",
+			"region",
+			"hide_viewed",
+			"min_rating",
+			"include_adult",
+			"card_layout",
+			"updated_at",
+		).
+		Values(
+			s.UserID,
+			s.Language,
+			s.Region,
+			s.HideViewed,
+			s.MinRating,
+			s.IncludeAdult,
+			s.CardLayout,
+			time.Now(),
+		).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			language = EXCLUDED.language,
+			region = EXCLUDED.region,
+			hide_viewed = EXCLUDED.hide_viewed,
+			min_rating = EXCLUDED.min_rating,
+			include_adult = EXCLUDED.include_adult,
+			card_layout = EXCLUDED.card_layout,
+			updated_at = EXCLUDED.updated_at`).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	_, err = pg.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		if ErrorCode(err) == ForeignKeyViolation {
+			return fmt.Errorf("failed to update settings (user with id %d not found): %s", s.UserID, err.Error())
+		}
+		return fmt.Errorf("failed to update settings: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var stagingColumns = []string{"id", "content_type_id", "title", "popularity"}
+
+// ClearContentStaging deletes the staged titles of the type left by a failed load.
+func (pg *PostgreSQL) ClearContentStaging(ctx context.Context, contentType types.ContentType) error {
+	sql, args, err := sq.Delete("content_staging").
+		Where(sq.Eq{"content_type_id": contentType.ID()}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	if _, err = pg.conn.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to clear content staging: %s", err.Error())
+	}
+	return nil
+}
+
+// CopyContentStaging copies the batch of the export titles to the staging table, the batches may be copied in parallel.
+func (pg *PostgreSQL) CopyContentStaging(ctx context.Context, content types.Content) error {
+	_, err := pg.conn.CopyFrom(ctx, pgx.Identifier{"content_staging"}, stagingColumns,
+		pgx.CopyFromSlice(len(content), func(i int) ([]any, error) {
+			c := content[i]
+			return []any{c.ID, c.ContentType.ID(), c.Title, c.Popularity}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("failed to copy content: %s", err.Error())
+	}
+	return nil
+}
+
+// mergeStagingSQL upserts the staged titles of the type the same way InsertContent does.
+// The export may repeat an id, the conflicting update would fail on it, so the ids are made unique.
+const mergeStagingSQL = `INSERT INTO content (id, content_type_id, title, popularity, last_seen_at)
+SELECT DISTINCT ON (id) id, content_type_id, title, popularity, $2::timestamptz
+FROM content_staging
+WHERE content_type_id = $1
+ORDER BY id
+ON CONFLICT (id, content_type_id) DO UPDATE SET
+	title = excluded.title,
+	popularity = excluded.popularity,
+	last_seen_at = excluded.last_seen_at,
+	removed_at = NULL`
+
+// MergeContentStaging merges the staged titles of the type into the content by a single statement,
+// marks them seen at the time and clears the staging. It returns the number of the merged titles.
+func (pg *PostgreSQL) MergeContentStaging(ctx context.Context, contentType types.ContentType, seenAt time.Time) (int64, error) {
+	tx, err := pg.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, mergeStagingSQL, contentType.ID(), seenAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to merge content staging: %s", err.Error())
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM content_staging WHERE content_type_id = $1", contentType.ID()); err != nil {
+		return 0, fmt.Errorf("failed to clear content staging: %s", err.Error())
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %s", err.Error())
+	}
+	return tag.RowsAffected(), nil
+}
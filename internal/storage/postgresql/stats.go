@@ -0,0 +1,242 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"whattowatch/internal/types"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GetUserStats aggregates the user watch log: the totals, the views per month for the last months
+// and the runtime, rating, personal rating, genres, decades and countries stats from the catalog details of the viewed titles.
+// The titles without the details in the catalog are left out of the details stats.
+func (pg *PostgreSQL) GetUserStats(ctx context.Context, userID int64, months int) (types.UserStats, error) {
+	var stats types.UserStats
+
+	sql, args, err := sq.Select(
+		"count(*)",
+		fmt.Sprintf("count(DISTINCT content_id) FILTER (WHERE content_type_id = %d)", types.Movie.ID()),
+		fmt.Sprintf("count(DISTINCT content_id) FILTER (WHERE content_type_id = %d)", types.TV.ID()),
+	).
+		From("users_watch_log").
+		Where(sq.Eq{"user_id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return types.UserStats{}, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&stats.Views, &stats.Movies, &stats.TVs)
+	if err != nil {
+		return types.UserStats{}, fmt.Errorf("failed to get user stats: %s", err.Error())
+	}
+	stats.Rewatches = stats.Views - stats.Movies - stats.TVs
+
+	stats.PerMonth, err = pg.getViewsPerMonth(ctx, userID, months)
+	if err != nil {
+		return types.UserStats{}, err
+	}
+
+	if err = pg.getDetailsStats(ctx, userID, &stats); err != nil {
+		return types.UserStats{}, err
+	}
+
+	return stats, nil
+}
+
+// watchedContent selects the titles viewed by the user with the number of their views.
+func watchedContent(userID int64) sq.SelectBuilder {
+	return sq.Select("content_id", "content_type_id", "count(*) AS views").
+		From("users_watch_log").
+		Where(sq.Eq{"user_id": userID}).
+		GroupBy("content_id", "content_type_id")
+}
+
+func (pg *PostgreSQL) getDetailsStats(ctx context.Context, userID int64, stats *types.UserStats) error {
+	sql, args, err := sq.Select("COALESCE(sum(t2.runtime * t1.views), 0)", "COALESCE(avg(t2.vote_average) FILTER (WHERE t2.vote_count > 0), 0)::real").
+		FromSelect(watchedContent(userID), "t1").
+		Join("content t2 ON t2.id = t1.content_id AND t2.content_type_id = t1.content_type_id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&stats.Minutes, &stats.VoteAverage)
+	if err != nil {
+		return fmt.Errorf("failed to get details stats: %s", err.Error())
+	}
+
+	stats.Decades, err = pg.getStatCounts(ctx, sq.Select("(extract(year FROM t2.release_date)::int / 10 * 10)::text AS name", "count(*)").
+		FromSelect(watchedContent(userID), "t1").
+		Join("content t2 ON t2.id = t1.content_id AND t2.content_type_id = t1.content_type_id").
+		Where("t2.release_date IS NOT NULL"))
+	if err != nil {
+		return err
+	}
+	for i := range stats.Decades {
+		stats.Decades[i].Name += "-е"
+	}
+
+	stats.Countries, err = pg.getStatCounts(ctx, sq.Select("t3.name", "count(*)").
+		FromSelect(watchedContent(userID), "t1").
+		Join("content t2 ON t2.id = t1.content_id AND t2.content_type_id = t1.content_type_id").
+		CrossJoin("unnest(t2.countries) AS t3(name)"))
+	if err != nil {
+		return err
+	}
+
+	if err = pg.getRatingStats(ctx, userID, stats); err != nil {
+		return err
+	}
+
+	stats.GenreCounts, err = pg.getGenreCounts(ctx, userID)
+	return err
+}
+
+// getRatingStats compares the personal ratings with the TMDb ones of the same titles, the titles without
+// the TMDb rating in the catalog are left out.
+func (pg *PostgreSQL) getRatingStats(ctx context.Context, userID int64, stats *types.UserStats) error {
+	sql, args, err := sq.Select("count(*)", "COALESCE(avg(t1.rating), 0)::real", "COALESCE(avg(t2.vote_average), 0)::real").
+		From("users_ratings t1").
+		Join("content t2 ON t2.id = t1.content_id AND t2.content_type_id = t1.content_type_id").
+		Where(sq.Eq{"t1.user_id": userID}).
+		Where(sq.Gt{"t2.vote_count": 0}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&stats.Rated, &stats.PersonalRating, &stats.RatedVoteAverage)
+	if err != nil {
+		return fmt.Errorf("failed to get rating stats: %s", err.Error())
+	}
+	return nil
+}
+
+// getStatCounts runs the query selecting the name and the count, the top types.StatsTopLimit counts are returned.
+func (pg *PostgreSQL) getStatCounts(ctx context.Context, query sq.SelectBuilder) ([]types.StatCount, error) {
+	sql, args, err := query.
+		GroupBy("name").
+		OrderBy("count(*) DESC", "name").
+		Limit(types.StatsTopLimit).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stat counts: %s", err.Error())
+	}
+	defer rows.Close()
+
+	counts := make([]types.StatCount, 0, types.StatsTopLimit)
+	for rows.Next() {
+		var c types.StatCount
+		if err = rows.Scan(&c.Name, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		counts = append(counts, c)
+	}
+	return counts, nil
+}
+
+func (pg *PostgreSQL) getGenreCounts(ctx context.Context, userID int64) ([]types.GenreCount, error) {
+	sql, args, err := sq.Select("t2.content_type_id", "t2.genre_id", "count(*)").
+		FromSelect(watchedContent(userID), "t1").
+		Join("content_genres t2 ON t2.content_id = t1.content_id AND t2.content_type_id = t1.content_type_id").
+		GroupBy("t2.content_type_id", "t2.genre_id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get genre counts: %s", err.Error())
+	}
+	defer rows.Close()
+
+	counts := make([]types.GenreCount, 0)
+	for rows.Next() {
+		var c types.GenreCount
+		var contentTypeID int
+		if err = rows.Scan(&contentTypeID, &c.GenreID, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		c.ContentType = types.ContentType(contentTypeID)
+		counts = append(counts, c)
+	}
+	return counts, nil
+}
+
+func (pg *PostgreSQL) getViewsPerMonth(ctx context.Context, userID int64, months int) ([]types.MonthCount, error) {
+	sql, args, err := sq.Select("date_trunc('month', watched_at) AS month", "count(*)").
+		From("users_watch_log").
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Expr("watched_at >= date_trunc('month', now()) - make_interval(months => ?)", months-1)).
+		GroupBy("month").
+		OrderBy("month").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get views per month: %s", err.Error())
+	}
+	defer rows.Close()
+
+	perMonth := make([]types.MonthCount, 0, months)
+	for rows.Next() {
+		var m types.MonthCount
+		if err = rows.Scan(&m.Month, &m.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		perMonth = append(perMonth, m)
+	}
+	return perMonth, nil
+}
+
+// GetYearWatchedItems returns the titles viewed by the user in the year with the number of views in the year.
+func (pg *PostgreSQL) GetYearWatchedItems(ctx context.Context, userID int64, year int, limit int) ([]types.WatchedItem, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	return pg.getWatchedItems(ctx, sq.And{
+		sq.Eq{"user_id": userID},
+		sq.GtOrEq{"watched_at": from},
+		sq.Lt{"watched_at": from.AddDate(1, 0, 0)},
+	}, limit)
+}
+
+func (pg *PostgreSQL) getWatchedItems(ctx context.Context, where sq.Sqlizer, limit int) ([]types.WatchedItem, error) {
+	sql, args, err := sq.Select("content_id", "content_type_id", "count(*)").
+		From("users_watch_log").
+		Where(where).
+		GroupBy("content_id", "content_type_id").
+		OrderBy("max(id) DESC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watched items: %s", err.Error())
+	}
+	defer rows.Close()
+
+	items := make([]types.WatchedItem, 0)
+	for rows.Next() {
+		var item types.WatchedItem
+		var contentTypeID int
+		if err = rows.Scan(&item.ContentID, &contentTypeID, &item.Views); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		item.ContentType = types.ContentType(contentTypeID)
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -3,28 +3,69 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"time"
 	"whattowatch/internal/types"
 
 	sq "github.com/Masterminds/squirrel"
 )
 
+// userTables are the tables with the per-user rows which are purged on the account deletion.
+var userTables = []string{
+	"users_favorites",
+	"users_watch_log",
+	"users_notes",
+	"users_ratings",
+	"user_lists",
+	"user_settings",
+	"users_api_tokens",
+	"events",
+	"fsm_sessions",
+}
+
 func (pg *PostgreSQL) GetUser(ctx context.Context, id int) (types.User, error) {
-	sql, args, err := sq.Select("*").From("users").PlaceholderFormat(sq.Dollar).Where(sq.Eq{"id": id}).ToSql()
+	sql, args, err := sq.Select(
+		"id",
+		"first_name",
+		"last_name",
+		"username",
+		"language_code",
+		"created_at",
+		"updated_at",
+		"deleted_at",
+	).From("users").PlaceholderFormat(sq.Dollar).Where(sq.Eq{"id": id}).ToSql()
 	if err != nil {
 		return types.User{}, err
 	}
 	var user types.User
-	err = pg.conn.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.LanguageCode)
+	err = pg.conn.QueryRow(ctx, sql, args...).Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.Username,
+		&user.LanguageCode,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
 	if err != nil {
 		return types.User{}, err
 	}
 	return user, nil
 }
 
+// InsertUser inserts the user. A soft-deleted user is registered again as a new one.
 func (pg *PostgreSQL) InsertUser(ctx context.Context, user types.User) error {
 	builder := sq.Insert("users").Columns("id", "first_name", "last_name", "username", "language_code", "created_at")
 	builder = builder.Values(user.ID, user.FirstName, user.LastName, user.Username, user.LanguageCode, user.CreatedAt)
-	sql, args, err := builder.Suffix("ON CONFLICT DO NOTHING").PlaceholderFormat(sq.Dollar).ToSql()
+	sql, args, err := builder.Suffix(`ON CONFLICT (id) DO UPDATE SET
+			first_name = EXCLUDED.first_name,
+			last_name = EXCLUDED.last_name,
+			username = EXCLUDED.username,
+			language_code = EXCLUDED.language_code,
+			created_at = EXCLUDED.created_at,
+			updated_at = NULL,
+			deleted_at = NULL
+		WHERE users.deleted_at IS NOT NULL`).PlaceholderFormat(sq.Dollar).ToSql()
 	if err != nil {
 		return err
 	}
@@ -34,3 +75,221 @@ func (pg *PostgreSQL) InsertUser(ctx context.Context, user types.User) error {
 	}
 	return nil
 }
+
+// GetUserTakeout collects everything stored about the user.
+func (pg *PostgreSQL) GetUserTakeout(ctx context.Context, userID int64) (types.UserTakeout, error) {
+	user, err := pg.GetUser(ctx, int(userID))
+	if err != nil {
+		return types.UserTakeout{}, fmt.Errorf("failed to get user: %s", err.Error())
+	}
+
+	settings, err := pg.GetUserSettings(ctx, userID)
+	if err != nil {
+		return types.UserTakeout{}, err
+	}
+
+	favorites, err := pg.getTakeoutItems(ctx, "users_favorites", userID)
+	if err != nil {
+		return types.UserTakeout{}, err
+	}
+
+	history, err := pg.getTakeoutHistory(ctx, userID)
+	if err != nil {
+		return types.UserTakeout{}, err
+	}
+
+	notes, err := pg.GetNotes(ctx, userID, 0, 0)
+	if err != nil {
+		return types.UserTakeout{}, err
+	}
+
+	ratings, err := pg.getTakeoutRatings(ctx, userID)
+	if err != nil {
+		return types.UserTakeout{}, err
+	}
+
+	lists, err := pg.getTakeoutLists(ctx, userID)
+	if err != nil {
+		return types.UserTakeout{}, err
+	}
+
+	takeout := types.UserTakeout{
+		ID:           user.ID,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Username:     user.Username,
+		LanguageCode: user.LanguageCode,
+		Settings:     settings,
+		Favorites:    favorites,
+		Viewed:       make([]types.TakeoutItem, 0),
+		History:      history,
+		Notes:        make([]types.TakeoutNote, 0, len(notes)),
+		Ratings:      ratings,
+		Lists:        lists,
+		ExportedAt:   time.Now(),
+	}
+	if user.CreatedAt.Valid {
+		takeout.CreatedAt = &user.CreatedAt.Time
+	}
+	viewed := make(map[types.TakeoutItem]bool)
+	for _, h := range history {
+		if !viewed[h.TakeoutItem] {
+			viewed[h.TakeoutItem] = true
+			takeout.Viewed = append(takeout.Viewed, h.TakeoutItem)
+		}
+	}
+	for _, n := range notes {
+		takeout.Notes = append(takeout.Notes, types.TakeoutNote{
+			TakeoutItem: types.TakeoutItem{ContentID: n.ContentID, ContentType: n.ContentType.String(), Title: n.Title},
+			Text:        n.Text,
+		})
+	}
+
+	return takeout, nil
+}
+
+func (pg *PostgreSQL) getTakeoutItems(ctx context.Context, table string, userID int64) ([]types.TakeoutItem, error) {
+	sql, args, err := sq.Select("t1.id", "t1.content_type_id", "coalesce(t1.localized_title, t1.title)").
+		From("content t1").
+		Join(table + " t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
+		Where(sq.Eq{"t2.user_id": userID}).
+		OrderBy("t2.id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %s", table, err.Error())
+	}
+	defer rows.Close()
+
+	items := make([]types.TakeoutItem, 0)
+	for rows.Next() {
+		var item types.TakeoutItem
+		var contentTypeID int
+		err = rows.Scan(&item.ContentID, &contentTypeID, &item.Title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		item.ContentType = types.ContentType(contentTypeID).String()
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (pg *PostgreSQL) getTakeoutHistory(ctx context.Context, userID int64) ([]types.TakeoutWatch, error) {
+	sql, args, err := sq.Select("t1.id", "t1.content_type_id", "coalesce(t1.localized_title, t1.title)", "t2.watched_at").
+		From("content t1").
+		Join("users_watch_log t2 ON t1.id = t2.content_id and t1.content_type_id = t2.content_type_id").
+		Where(sq.Eq{"t2.user_id": userID}).
+		OrderBy("t2.id").
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	rows, err := pg.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watch history: %s", err.Error())
+	}
+	defer rows.Close()
+
+	history := make([]types.TakeoutWatch, 0)
+	for rows.Next() {
+		var item types.TakeoutWatch
+		var contentTypeID int
+		err = rows.Scan(&item.ContentID, &contentTypeID, &item.Title, &item.WatchedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %s", err.Error())
+		}
+		item.ContentType = types.ContentType(contentTypeID).String()
+		history = append(history, item)
+	}
+	return history, nil
+}
+
+// DeleteUser soft-deletes the user and purges all the per-user rows.
+func (pg *PostgreSQL) DeleteUser(ctx context.Context, userID int64) error {
+	tx, err := pg.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	for _, table := range userTables {
+		sql, args, err := sq.Delete(table).Where(sq.Eq{"user_id": userID}).PlaceholderFormat(sq.Dollar).ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build sql query: %s", err.Error())
+		}
+		_, err = tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("failed to purge %s: %s", table, err.Error())
+		}
+	}
+
+	sql, args, err := sq.Update("users").
+		Set("first_name", "").
+		Set("last_name", "").
+		Set("username", "").
+		Set("deleted_at", time.Now()).
+		Where(sq.Eq{"id": userID}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %s", err.Error())
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %s", err.Error())
+	}
+	return nil
+}
+
+// PurgeDeletedUsers hard-deletes the users soft-deleted before the given time with their events,
+// as the events may be inserted after the account deletion.
+func (pg *PostgreSQL) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	purgedSQL, purgedArgs, err := sq.Select("id").From("users").Where(sq.Lt{"deleted_at": before}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build purged users subquery: %s", err.Error())
+	}
+
+	eventsSQL, eventsArgs, err := sq.Delete("events").
+		Where(sq.Expr(fmt.Sprintf("user_id IN (%s)", purgedSQL), purgedArgs...)).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	sql, args, err := sq.Delete("users").
+		Where(sq.Lt{"deleted_at": before}).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build sql query: %s", err.Error())
+	}
+
+	tx, err := pg.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, eventsSQL, eventsArgs...); err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users events: %s", err.Error())
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %s", err.Error())
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %s", err.Error())
+	}
+	return tag.RowsAffected(), nil
+}
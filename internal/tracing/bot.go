@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"whattowatch/internal/botkit"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BotMiddleware starts the root span of the update, the handler calls are its children.
+func BotMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		handler := botkit.HandlerName(update)
+
+		attrs := []attribute.KeyValue{
+			attribute.Int64("telegram.update_id", update.ID),
+			attribute.String("telegram.handler", handler),
+		}
+		switch {
+		case update.CallbackQuery != nil:
+			attrs = append(attrs, attribute.Int64("telegram.user_id", update.CallbackQuery.From.ID))
+		case update.Message != nil && update.Message.From != nil:
+			attrs = append(attrs,
+				attribute.Int64("telegram.user_id", update.Message.From.ID),
+				attribute.Int64("telegram.chat_id", update.Message.Chat.ID),
+			)
+		}
+
+		ctx, span := Start(ctx, "update "+handler, attrs...)
+		defer span.End()
+
+		next(ctx, b, update)
+	}
+}
@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/types"
+)
+
+// DataProvider is the botkit.DataProvider decorator wrapping every call to the span.
+type DataProvider struct {
+	api botkit.DataProvider
+}
+
+var _ botkit.DataProvider = (*DataProvider)(nil)
+
+func NewDataProvider(api botkit.DataProvider) *DataProvider {
+	return &DataProvider{api: api}
+}
+
+func (p *DataProvider) GetMovie(ctx context.Context, id int) (res types.ContentItem, err error) {
+	ctx, span := Start(ctx, "tmdb GetMovie")
+	defer func() { End(span, err) }()
+	return p.api.GetMovie(ctx, id)
+}
+
+func (p *DataProvider) GetMoviePopular(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetMoviePopular")
+	defer func() { End(span, err) }()
+	return p.api.GetMoviePopular(ctx, page)
+}
+
+func (p *DataProvider) GetMovieTop(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetMovieTop")
+	defer func() { End(span, err) }()
+	return p.api.GetMovieTop(ctx, page)
+}
+
+func (p *DataProvider) GetMovieTrending(ctx context.Context, window types.TrendingWindow, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetMovieTrending")
+	defer func() { End(span, err) }()
+	return p.api.GetMovieTrending(ctx, window, page)
+}
+
+func (p *DataProvider) GetMovieNowPlaying(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetMovieNowPlaying")
+	defer func() { End(span, err) }()
+	return p.api.GetMovieNowPlaying(ctx, page)
+}
+
+func (p *DataProvider) GetMovieUpcoming(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetMovieUpcoming")
+	defer func() { End(span, err) }()
+	return p.api.GetMovieUpcoming(ctx, page)
+}
+
+func (p *DataProvider) GetMoviesByGenre(ctx context.Context, genreIDs []int, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetMoviesByGenre")
+	defer func() { End(span, err) }()
+	return p.api.GetMoviesByGenre(ctx, genreIDs, page)
+}
+
+func (p *DataProvider) GetTV(ctx context.Context, id int) (res types.ContentItem, err error) {
+	ctx, span := Start(ctx, "tmdb GetTV")
+	defer func() { End(span, err) }()
+	return p.api.GetTV(ctx, id)
+}
+
+func (p *DataProvider) GetTVPopular(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetTVPopular")
+	defer func() { End(span, err) }()
+	return p.api.GetTVPopular(ctx, page)
+}
+
+func (p *DataProvider) GetTVTop(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetTVTop")
+	defer func() { End(span, err) }()
+	return p.api.GetTVTop(ctx, page)
+}
+
+func (p *DataProvider) GetTVTrending(ctx context.Context, window types.TrendingWindow, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetTVTrending")
+	defer func() { End(span, err) }()
+	return p.api.GetTVTrending(ctx, window, page)
+}
+
+func (p *DataProvider) GetTVAiringToday(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetTVAiringToday")
+	defer func() { End(span, err) }()
+	return p.api.GetTVAiringToday(ctx, page)
+}
+
+func (p *DataProvider) GetTVOnTheAir(ctx context.Context, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetTVOnTheAir")
+	defer func() { End(span, err) }()
+	return p.api.GetTVOnTheAir(ctx, page)
+}
+
+func (p *DataProvider) GetTVsByGenre(ctx context.Context, genreIDs []int, page int) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetTVsByGenre")
+	defer func() { End(span, err) }()
+	return p.api.GetTVsByGenre(ctx, genreIDs, page)
+}
+
+func (p *DataProvider) GetGenres(ctx context.Context, contentType types.ContentType) (res types.Genres, err error) {
+	ctx, span := Start(ctx, "tmdb GetGenres")
+	defer func() { End(span, err) }()
+	return p.api.GetGenres(ctx, contentType)
+}
+
+func (p *DataProvider) GetCollection(ctx context.Context, id int) (res types.Collection, err error) {
+	ctx, span := Start(ctx, "tmdb GetCollection")
+	defer func() { End(span, err) }()
+	return p.api.GetCollection(ctx, id)
+}
+
+func (p *DataProvider) GetContent(ctx context.Context, contentType types.ContentType, ids []int64) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetContent")
+	defer func() { End(span, err) }()
+	return p.api.GetContent(ctx, contentType, ids)
+}
+
+func (p *DataProvider) GetRecommendations(ctx context.Context, contentType types.ContentType, ids []int64) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb GetRecommendations")
+	defer func() { End(span, err) }()
+	return p.api.GetRecommendations(ctx, contentType, ids)
+}
+
+func (p *DataProvider) SearchByTitles(ctx context.Context, titles []string) (res types.Content, err error) {
+	ctx, span := Start(ctx, "tmdb SearchByTitles")
+	defer func() { End(span, err) }()
+	return p.api.SearchByTitles(ctx, titles)
+}
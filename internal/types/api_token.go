@@ -0,0 +1,27 @@
+package types
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// apiTokenPrefix makes the tokens easy to recognize, e.g. by the secret scanners.
+const apiTokenPrefix = "wtw_"
+
+// NewAPIToken returns a new random API token and its hash, only the hash is stored.
+func NewAPIToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = apiTokenPrefix + hex.EncodeToString(buf)
+	return token, HashAPIToken(token), nil
+}
+
+// HashAPIToken returns the hex SHA-256 of the token.
+// The token is random enough, so no salt or slow hash is needed.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Collection struct {
+	ID           int64
+	Name         string
+	Overview     string
+	PosterPath   string
+	BackdropPath string
+	Parts        Content
+}
+
+// SortParts sorts the collection parts in the release order. Parts without the release date go last.
+func (c *Collection) SortParts() {
+	sort.SliceStable(c.Parts, func(i, j int) bool {
+		di, dj := c.Parts[i].ReleaseDate, c.Parts[j].ReleaseDate
+		if di.IsZero() || dj.IsZero() {
+			return !di.IsZero() && dj.IsZero()
+		}
+		return di.Before(dj)
+	})
+}
+
+// NextPart returns the index of the first part not in viewedIDs or -1 if all the parts are viewed.
+func (c Collection) NextPart(viewedIDs []int64) int {
+	viewed := make(map[int64]struct{}, len(viewedIDs))
+	for _, id := range viewedIDs {
+		viewed[id] = struct{}{}
+	}
+
+	for i, part := range c.Parts {
+		if _, ok := viewed[part.ID]; !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetInfo returns the collection parts in the watch order. Viewed parts are marked with ✅,
+// the next unwatched part is highlighted.
+func (c Collection) GetInfo(viewedIDs []int64) string {
+	sb := strings.Builder{}
+
+	viewed := make(map[int64]struct{}, len(viewedIDs))
+	for _, id := range viewedIDs {
+		viewed[id] = struct{}{}
+	}
+	next := c.NextPart(viewedIDs)
+
+	sb.WriteString(fmt.Sprintf("*%s*\n", c.Name))
+	for i, part := range c.Parts {
+		year := "TBA"
+		if !part.ReleaseDate.IsZero() {
+			year = fmt.Sprintf("%d", part.ReleaseDate.Year())
+		}
+
+		mark := "▫️"
+		if _, ok := viewed[part.ID]; ok {
+			mark = "✅"
+		}
+
+		if i == next {
+			sb.WriteString(fmt.Sprintf("👉 *%d. %s (%s)* /%s%d\n", i+1, part.Title, year, part.ContentType.Sign(), part.ID))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s %d. %s (%s) /%s%d\n", mark, i+1, part.Title, year, part.ContentType.Sign(), part.ID))
+	}
+
+	if next == -1 {
+		sb.WriteString("\nВы посмотрели всю франшизу!")
+	}
+
+	return sb.String()
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
@@ -22,6 +23,10 @@ type ContentItem struct {
 	Genres       Genres
 	TrailerURL   string
 	Counties     []string
+	Adult        bool
+	CollectionID int64
+	// Runtime is the movie or the whole TV show duration in minutes, if known.
+	Runtime int
 }
 
 func SerializeContentItem(c ContentItem) []byte {
@@ -48,15 +53,25 @@ func UnserializeContentItem(data []byte) (ContentItem, error) {
 	return ci, nil
 }
 
+// titleInfo formats the title line with the release year and the countries. Announced titles have no year.
+func (c ContentItem) titleInfo() string {
+	details := make([]string, 0, 2)
+	if !c.ReleaseDate.IsZero() {
+		details = append(details, fmt.Sprintf("%d год", c.ReleaseDate.Year()))
+	}
+	if len(c.Counties) > 0 {
+		details = append(details, strings.Join(c.Counties, ", "))
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("*Название:* %s\n", c.Title)
+	}
+	return fmt.Sprintf("*Название:* %s (%s)\n", c.Title, strings.Join(details, "; "))
+}
+
 func (c ContentItem) GetInfo() string {
 	sb := strings.Builder{}
 
-	sb.WriteString(fmt.Sprintf("*Название:* %s (%d год", c.Title, c.ReleaseDate.Year()))
-	if len(c.Counties) > 0 {
-		sb.WriteString(fmt.Sprintf("; %s)\n", strings.Join(c.Counties, ", ")))
-	} else {
-		sb.WriteString(")\n")
-	}
+	sb.WriteString(c.titleInfo())
 	if len(c.Genres) > 0 {
 		sb.WriteString(fmt.Sprintf("*Жанры:* %s\n", c.Genres.String()))
 	}
@@ -75,12 +90,7 @@ func (c ContentItem) GetShortInfo() string {
 	sb := strings.Builder{}
 
 	sb.WriteString(fmt.Sprintf("/%s%d\n", c.ContentType.Sign(), c.ID))
-	sb.WriteString(fmt.Sprintf("*Название:* %s (%d год", c.Title, c.ReleaseDate.Year()))
-	if len(c.Counties) > 0 {
-		sb.WriteString(fmt.Sprintf("; %s)\n", strings.Join(c.Counties, ", ")))
-	} else {
-		sb.WriteString(")\n")
-	}
+	sb.WriteString(c.titleInfo())
 	sb.WriteString(fmt.Sprintf("*Рейтинг:* %s (%d чел.)\n", fmt.Sprintf("%.2f", c.VoteAverage), c.VoteCount))
 	if c.Overview != "" {
 		overview := c.Overview
@@ -93,6 +103,20 @@ func (c ContentItem) GetShortInfo() string {
 	return sb.String()
 }
 
+func (c ContentItem) GetCompactInfo() string {
+	sb := strings.Builder{}
+
+	sb.WriteString(fmt.Sprintf("/%s%d\n", c.ContentType.Sign(), c.ID))
+	if c.ReleaseDate.IsZero() {
+		sb.WriteString(fmt.Sprintf("*%s*\n", c.Title))
+	} else {
+		sb.WriteString(fmt.Sprintf("*%s* (%d)\n", c.Title, c.ReleaseDate.Year()))
+	}
+	sb.WriteString(fmt.Sprintf("*Рейтинг:* %.2f\n", c.VoteAverage))
+
+	return sb.String()
+}
+
 type Content []ContentItem
 
 func (content Content) IDs() []int64 {
@@ -131,3 +155,25 @@ func (content Content) RemoveDuplicates() Content {
 
 	return result
 }
+
+// SortByIDs orders the content as the ids, e.g. ranked by the storage. The content missing in the ids goes last.
+func (content Content) SortByIDs(ids []int64) Content {
+	order := make(map[int64]int, len(ids))
+	for i, id := range ids {
+		order[id] = i
+	}
+
+	result := slices.Clone(content)
+	slices.SortStableFunc(result, func(a, b ContentItem) int {
+		ia, ok := order[a.ID]
+		if !ok {
+			ia = len(ids)
+		}
+		ib, ok := order[b.ID]
+		if !ok {
+			ib = len(ids)
+		}
+		return ia - ib
+	})
+	return result
+}
@@ -1,9 +1,8 @@
 package types
 
-import "github.com/gofrs/uuid"
-
+// ContentGenre links the title to its genre in the local catalog.
 type ContentGenre struct {
-	ID        int
-	ContentID uuid.UUID
-	GenreID   uuid.UUID
+	ContentID   int64
+	ContentType ContentType
+	GenreID     int64
 }
@@ -1,9 +1,55 @@
 package types
 
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"whattowatch/internal/utils"
+)
+
+// captionNoteLength is the length of the note shown in the card, the caption is limited to 1024 characters
+// and the overview takes most of it. The full notes are shown by /notes.
+const captionNoteLength = 150
+
 type ContentStatus struct {
 	UserID      int64
 	ContentID   int64
 	ContentType ContentType
 	IsViewed    bool
 	IsFavorite  bool
+	ViewsCount  int
+	WatchedAt   sql.NullTime
+	Note        string
+	// Rating is the personal rating from 1 to 10, zero if the title is not rated.
+	Rating int
+}
+
+func (cs ContentStatus) GetInfo() string {
+	statuses := make([]string, 0, 2)
+	if cs.IsFavorite {
+		statuses = append(statuses, "⭐ В избранном")
+	}
+	if cs.IsViewed {
+		viewed := "✅ Просмотрено"
+		if cs.WatchedAt.Valid {
+			viewed += " " + cs.WatchedAt.Time.Format("02.01.2006")
+		}
+		if cs.ViewsCount > 1 {
+			viewed += fmt.Sprintf(" (просмотров: %d)", cs.ViewsCount)
+		}
+		statuses = append(statuses, viewed)
+	}
+	if cs.Rating > 0 {
+		statuses = append(statuses, fmt.Sprintf("🏅 Ваша оценка: %d/10", cs.Rating))
+	}
+	info := strings.Join(statuses, " | ")
+
+	if cs.Note != "" {
+		if info != "" {
+			info += "\n"
+		}
+		info += fmt.Sprintf("📝 *Заметка:* %s", utils.EscapeMarkdown(utils.Truncate(cs.Note, captionNoteLength)))
+	}
+
+	return info
 }
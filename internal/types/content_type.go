@@ -35,3 +35,12 @@ func (w ContentType) Sign() string {
 	}
 	return ""
 }
+
+func ParseContentTypeSign(s string) (ContentType, error) {
+	for _, ct := range [...]ContentType{Movie, TV} {
+		if ct.Sign() == s {
+			return ct, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown content type sign: %s", s)
+}
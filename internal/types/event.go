@@ -0,0 +1,121 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventCommand  EventType = "command"
+	EventButton   EventType = "button"
+	EventCallback EventType = "callback"
+
+	EventCardOpen       EventType = "card_open"
+	EventFavoriteAdd    EventType = "favorite_add"
+	EventFavoriteRemove EventType = "favorite_remove"
+	EventViewedAdd      EventType = "viewed_add"
+	EventViewedRemove   EventType = "viewed_remove"
+
+	EventSearch                   EventType = "search"
+	EventRecommendationImpression EventType = "recommendation_impression"
+)
+
+// Event is the user action recorded to the append-only event log.
+// The content fields are set for the actions on the title, the payload keeps the action details, e.g. the search query.
+type Event struct {
+	UserID      int64
+	Type        EventType
+	Name        string
+	ContentID   int64
+	ContentType ContentType
+	Payload     map[string]any
+	CreatedAt   time.Time
+}
+
+// NewContentEvent returns the event of the action on the title.
+func NewContentEvent(userID int64, eventType EventType, item ContentItem) Event {
+	return Event{
+		UserID:      userID,
+		Type:        eventType,
+		ContentID:   item.ID,
+		ContentType: item.ContentType,
+	}
+}
+
+// FunnelStep is the number of the users passed the funnel step after the previous ones.
+type FunnelStep struct {
+	Type  EventType
+	Users int
+}
+
+// Funnel is the ordered funnel of the event types, e.g. search → card → favorite.
+type Funnel []FunnelStep
+
+func (f Funnel) GetInfo() string {
+	sb := strings.Builder{}
+	for i, step := range f {
+		sb.WriteString(fmt.Sprintf("%d. %s: %d", i+1, step.Type, step.Users))
+		if i > 0 {
+			sb.WriteString(fmt.Sprintf(" (%s от пред., %s от начала)", percent(step.Users, f[i-1].Users), percent(step.Users, f[0].Users)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// RetentionCohort is the users first seen in the week and how many of them were active in the following weeks.
+// Active[0] is the cohort size.
+type RetentionCohort struct {
+	Week   time.Time
+	Active []int
+}
+
+type RetentionCohorts []RetentionCohort
+
+func (c RetentionCohorts) GetInfo() string {
+	if len(c) == 0 {
+		return "Нет данных\n"
+	}
+
+	sb := strings.Builder{}
+	for _, cohort := range c {
+		sb.WriteString(fmt.Sprintf("%s (%d):", cohort.Week.Format("02.01.2006"), cohort.Active[0]))
+		for _, active := range cohort.Active[1:] {
+			sb.WriteString(" " + percent(active, cohort.Active[0]))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// EventUsage is the number of the events and their users by the event type and name, e.g. the menu button.
+type EventUsage struct {
+	Type   EventType
+	Name   string
+	Events int
+	Users  int
+}
+
+type EventsUsage []EventUsage
+
+func (u EventsUsage) GetInfo() string {
+	if len(u) == 0 {
+		return "Нет данных\n"
+	}
+
+	sb := strings.Builder{}
+	for _, row := range u {
+		sb.WriteString(fmt.Sprintf("%s %s: %d (пользователей: %d)\n", row.Type, row.Name, row.Events, row.Users))
+	}
+	return sb.String()
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+}
@@ -0,0 +1,20 @@
+package types
+
+import "time"
+
+type LoaderMode string
+
+const (
+	// LoaderModeFull loads the daily export of all the ids.
+	LoaderModeFull LoaderMode = "full"
+	// LoaderModeChanges loads the ids changed since the watermark of the last successful run.
+	LoaderModeChanges LoaderMode = "changes"
+)
+
+// LoaderWatermark is the time the successful loader runs loaded the content up to.
+type LoaderWatermark struct {
+	// Last is the watermark of the last successful run of any mode.
+	Last time.Time
+	// Full is the watermark of the last successful full run.
+	Full time.Time
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RemovedListItem is the title removed from TMDb still kept in the user lists.
+type RemovedListItem struct {
+	ID          int64
+	ContentType ContentType
+	Title       string
+	RemovedAt   time.Time
+	Lists       int
+	Users       int
+}
+
+type RemovedListItems []RemovedListItem
+
+func (items RemovedListItems) GetInfo() string {
+	if len(items) == 0 {
+		return "Нет данных\n"
+	}
+
+	sb := strings.Builder{}
+	for _, item := range items {
+		sb.WriteString(fmt.Sprintf("/%s%d %s, удален %s: списков %d (пользователей: %d)\n",
+			item.ContentType.Sign(), item.ID, item.Title, item.RemovedAt.Format("02.01.2006"), item.Lists, item.Users))
+	}
+	return sb.String()
+}
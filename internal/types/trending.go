@@ -0,0 +1,15 @@
+package types
+
+type TrendingWindow string
+
+const (
+	TrendingDay  TrendingWindow = "day"
+	TrendingWeek TrendingWindow = "week"
+)
+
+func (w TrendingWindow) Toggle() TrendingWindow {
+	if w == TrendingWeek {
+		return TrendingDay
+	}
+	return TrendingWeek
+}
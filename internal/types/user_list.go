@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// UserList is a user-defined named list of movies and TV shows.
+type UserList struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	ShareToken string
+	ItemsCount int
+	CreatedAt  time.Time
+}
+
+type UserLists []UserList
+
+func (l UserList) IsShared() bool {
+	return l.ShareToken != ""
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"whattowatch/internal/utils"
+)
+
+type UserNote struct {
+	UserID      int64
+	ContentID   int64
+	ContentType ContentType
+	Title       string
+	Text        string
+	UpdatedAt   time.Time
+}
+
+type UserNotes []UserNote
+
+// GetInfo returns the notes as a single message, see Fit to keep it within the message length.
+func (notes UserNotes) GetInfo() string {
+	sb := strings.Builder{}
+
+	sb.WriteString("*Ваши заметки:*\n")
+	for _, n := range notes {
+		sb.WriteString(fmt.Sprintf("\n📝 *%s* /%s%d\n%s\n", utils.EscapeMarkdown(n.Title), n.ContentType.Sign(), n.ContentID, utils.EscapeMarkdown(n.Text)))
+	}
+
+	return sb.String()
+}
+
+// Fit returns the first notes the message of which is at most maxLength characters long.
+// The first note is always kept, a note is short enough to fit alone.
+func (notes UserNotes) Fit(maxLength int) UserNotes {
+	for n := len(notes); n > 1; n-- {
+		if utf8.RuneCountInString(notes[:n].GetInfo()) <= maxLength {
+			return notes[:n]
+		}
+	}
+	return notes[:min(len(notes), 1)]
+}
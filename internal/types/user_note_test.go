@@ -0,0 +1,31 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UserNotesFit(t *testing.T) {
+	notes := make(UserNotes, 10)
+	for i := range notes {
+		notes[i] = UserNote{ContentID: int64(i), ContentType: Movie, Title: "Начало", Text: strings.Repeat("я", 1000)}
+	}
+
+	fit := notes.Fit(4096)
+	assert.Len(t, fit, 4)
+	assert.LessOrEqual(t, utf8.RuneCountInString(fit.GetInfo()), 4096)
+
+	assert.Len(t, notes[:1].Fit(10), 1)
+	assert.Empty(t, UserNotes{}.Fit(4096))
+}
+
+func Test_ContentStatusNoteTruncated(t *testing.T) {
+	cs := ContentStatus{Note: strings.Repeat("я", 1000)}
+
+	info := cs.GetInfo()
+	assert.True(t, strings.HasSuffix(info, "…"))
+	assert.Equal(t, captionNoteLength, utf8.RuneCountInString(strings.TrimPrefix(info, "📝 *Заметка:* ")))
+}
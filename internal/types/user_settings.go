@@ -0,0 +1,63 @@
+package types
+
+import "context"
+
+type CardLayout string
+
+const (
+	CardLayoutFull    CardLayout = "full"
+	CardLayoutCompact CardLayout = "compact"
+)
+
+// DefaultLanguage is the language of the content details if the user hasn't chosen one.
+const DefaultLanguage = "ru-RU"
+
+type UserSettings struct {
+	UserID       int64      `json:"-"`
+	Language     string     `json:"language"`
+	Region       string     `json:"region"`
+	HideViewed   bool       `json:"hide_viewed"`
+	MinRating    float32    `json:"min_rating"`
+	IncludeAdult bool       `json:"include_adult"`
+	CardLayout   CardLayout `json:"card_layout"`
+}
+
+func DefaultUserSettings(userID int64) UserSettings {
+	return UserSettings{
+		UserID:       userID,
+		Language:     DefaultLanguage,
+		Region:       "RU",
+		HideViewed:   false,
+		MinRating:    0,
+		IncludeAdult: false,
+		CardLayout:   CardLayoutFull,
+	}
+}
+
+// Filter removes the content items that don't match the user settings.
+func (s UserSettings) Filter(content Content) Content {
+	result := make(Content, 0, len(content))
+	for _, c := range content {
+		if c.Adult && !s.IncludeAdult {
+			continue
+		}
+		if c.VoteAverage < s.MinRating {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+type userSettingsKey struct{}
+
+// ContextWithUserSettings returns a copy of ctx with the user settings,
+// so data providers can apply the language, region and adult filter to their requests.
+func ContextWithUserSettings(ctx context.Context, s UserSettings) context.Context {
+	return context.WithValue(ctx, userSettingsKey{}, s)
+}
+
+func UserSettingsFromContext(ctx context.Context) (UserSettings, bool) {
+	s, ok := ctx.Value(userSettingsKey{}).(UserSettings)
+	return s, ok
+}
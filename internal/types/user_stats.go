@@ -0,0 +1,194 @@
+package types
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"whattowatch/internal/utils"
+)
+
+const (
+	statsBarWidth = 12
+	// StatsTopLimit limits the genres, decades and countries in the stats.
+	StatsTopLimit = 5
+)
+
+// StatCount is a number of the viewed titles in the group.
+type StatCount struct {
+	Name  string
+	Count int
+}
+
+// GenreCount is a number of the viewed titles of the genre, the storage knows the genre ids only.
+type GenreCount struct {
+	ContentType ContentType
+	GenreID     int64
+	Count       int
+}
+
+// MonthCount is a number of the views in the month.
+type MonthCount struct {
+	Month time.Time
+	Count int
+}
+
+// WatchedItem is the viewed title with the number of its views.
+type WatchedItem struct {
+	ContentID   int64
+	ContentType ContentType
+	Views       int
+}
+
+// UserStats is the user activity summary. The stats are aggregated by the storage
+// or collected from the content details of Items by Collect, the genre names are set by SetGenreNames.
+type UserStats struct {
+	Views     int
+	Movies    int
+	TVs       int
+	Rewatches int
+	PerMonth  []MonthCount
+	Items     []WatchedItem
+
+	Minutes     int
+	VoteAverage float32
+	// Rated is the number of the rated titles, PersonalRating is their average personal rating
+	// and RatedVoteAverage is their average TMDb rating.
+	Rated            int
+	PersonalRating   float32
+	RatedVoteAverage float32
+	GenreCounts      []GenreCount
+	Genres           []StatCount
+	Decades          []StatCount
+	Countries        []StatCount
+}
+
+// SetGenreNames fills the genres stats from GenreCounts, the movie and TV genres of the same name are summed.
+func (s *UserStats) SetGenreNames(genres map[ContentType]Genres) {
+	names := make(map[ContentType]map[int64]string, len(genres))
+	for contentType, gs := range genres {
+		names[contentType] = make(map[int64]string, len(gs))
+		for _, g := range gs {
+			names[contentType][g.ID] = g.Name
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, c := range s.GenreCounts {
+		if name, ok := names[c.ContentType][c.GenreID]; ok {
+			counts[name] += c.Count
+		}
+	}
+	s.Genres = topCounts(counts, StatsTopLimit)
+}
+
+// Collect fills the runtime, rating, genres, decades and countries stats from the details of the viewed titles.
+func (s *UserStats) Collect(content Content) {
+	views := make(map[ContentType]map[int64]int)
+	for _, item := range s.Items {
+		if views[item.ContentType] == nil {
+			views[item.ContentType] = make(map[int64]int)
+		}
+		views[item.ContentType][item.ContentID] = item.Views
+	}
+
+	genres := make(map[string]int)
+	decades := make(map[string]int)
+	countries := make(map[string]int)
+
+	var voteSum float32
+	var voteCount int
+	for _, c := range content {
+		s.Minutes += c.Runtime * max(views[c.ContentType][c.ID], 1)
+
+		if c.VoteCount > 0 {
+			voteSum += c.VoteAverage
+			voteCount++
+		}
+		for _, g := range c.Genres {
+			genres[g.Name]++
+		}
+		if !c.ReleaseDate.IsZero() {
+			decades[fmt.Sprintf("%d-е", c.ReleaseDate.Year()/10*10)]++
+		}
+		for _, country := range c.Counties {
+			countries[country]++
+		}
+	}
+
+	if voteCount > 0 {
+		s.VoteAverage = voteSum / float32(voteCount)
+	}
+	s.Genres = topCounts(genres, StatsTopLimit)
+	s.Decades = topCounts(decades, StatsTopLimit)
+	s.Countries = topCounts(countries, StatsTopLimit)
+}
+
+func topCounts(m map[string]int, limit int) []StatCount {
+	counts := make([]StatCount, 0, len(m))
+	for name, count := range m {
+		counts = append(counts, StatCount{Name: name, Count: count})
+	}
+
+	slices.SortFunc(counts, func(a, b StatCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+func writeBars(sb *strings.Builder, title string, counts []StatCount) {
+	if len(counts) == 0 {
+		return
+	}
+
+	maxCount, nameWidth := 0, 0
+	for _, c := range counts {
+		maxCount = max(maxCount, c.Count)
+		nameWidth = max(nameWidth, len([]rune(c.Name)))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n*%s:*\n```\n", title))
+	for _, c := range counts {
+		name := c.Name + strings.Repeat(" ", nameWidth-len([]rune(c.Name)))
+		sb.WriteString(fmt.Sprintf("%s %s %d\n", name, utils.Bar(float64(c.Count), float64(maxCount), statsBarWidth), c.Count))
+	}
+	sb.WriteString("```\n")
+}
+
+func (s UserStats) GetInfo() string {
+	sb := strings.Builder{}
+
+	sb.WriteString("📊 *Ваша статистика*\n\n")
+	sb.WriteString(fmt.Sprintf("Просмотрено: %d (фильмов: %d, сериалов: %d)\n", s.Movies+s.TVs, s.Movies, s.TVs))
+	if s.Rewatches > 0 {
+		sb.WriteString(fmt.Sprintf("Повторных просмотров: %d\n", s.Rewatches))
+	}
+	if s.Minutes > 0 {
+		sb.WriteString(fmt.Sprintf("Время просмотра: ~%d ч\n", s.Minutes/60))
+	}
+	if s.VoteAverage > 0 {
+		sb.WriteString(fmt.Sprintf("Средний рейтинг TMDb просмотренного: %.1f\n", s.VoteAverage))
+	}
+	if s.Rated > 0 {
+		sb.WriteString(fmt.Sprintf("Ваша средняя оценка: %.1f, TMDb тех же: %.1f (оценено: %d)\n", s.PersonalRating, s.RatedVoteAverage, s.Rated))
+	}
+
+	months := make([]StatCount, 0, len(s.PerMonth))
+	for _, m := range s.PerMonth {
+		months = append(months, StatCount{Name: fmt.Sprintf("%.3s %d", strings.ToLower(monthNames[m.Month.Month()-1]), m.Month.Year()), Count: m.Count})
+	}
+	writeBars(&sb, "По месяцам", months)
+	writeBars(&sb, "Жанры", s.Genres)
+	writeBars(&sb, "Десятилетия", s.Decades)
+	writeBars(&sb, "Страны", s.Countries)
+
+	return sb.String()
+}
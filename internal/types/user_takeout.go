@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+// UserTakeout is everything stored about the user. It is sent to the user as JSON before the account is deleted.
+type UserTakeout struct {
+	ID           int64           `json:"id"`
+	FirstName    string          `json:"first_name"`
+	LastName     string          `json:"last_name"`
+	Username     string          `json:"username"`
+	LanguageCode string          `json:"language_code"`
+	CreatedAt    *time.Time      `json:"created_at,omitempty"`
+	Settings     UserSettings    `json:"settings"`
+	Favorites    []TakeoutItem   `json:"favorites"`
+	Viewed       []TakeoutItem   `json:"viewed"`
+	History      []TakeoutWatch  `json:"history"`
+	Notes        []TakeoutNote   `json:"notes"`
+	Ratings      []TakeoutRating `json:"ratings"`
+	Lists        []TakeoutList   `json:"lists"`
+	ExportedAt   time.Time       `json:"exported_at"`
+}
+
+type TakeoutItem struct {
+	ContentID   int64  `json:"content_id"`
+	ContentType string `json:"content_type"`
+	Title       string `json:"title"`
+}
+
+type TakeoutNote struct {
+	TakeoutItem
+	Text string `json:"text"`
+}
+
+type TakeoutRating struct {
+	TakeoutItem
+	Rating int `json:"rating"`
+}
+
+type TakeoutList struct {
+	Name  string        `json:"name"`
+	Items []TakeoutItem `json:"items"`
+}
+
+type TakeoutWatch struct {
+	TakeoutItem
+	WatchedAt *time.Time `json:"watched_at"`
+}
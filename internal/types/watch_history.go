@@ -0,0 +1,59 @@
+package types
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"whattowatch/internal/utils"
+)
+
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+// WatchEntry is a single view of the content. The watch date is unknown for the views marked before the watch log existed.
+type WatchEntry struct {
+	ID          int64
+	ContentID   int64
+	ContentType ContentType
+	Title       string
+	WatchedAt   sql.NullTime
+	ViewNumber  int
+}
+
+// WatchHistory is the watch log, the latest first.
+type WatchHistory []WatchEntry
+
+func (e WatchEntry) monthTitle() string {
+	if !e.WatchedAt.Valid {
+		return "Дата неизвестна"
+	}
+	return fmt.Sprintf("%s %d", monthNames[e.WatchedAt.Time.Month()-1], e.WatchedAt.Time.Year())
+}
+
+// GetInfo returns the history grouped by month.
+func (history WatchHistory) GetInfo() string {
+	sb := strings.Builder{}
+
+	sb.WriteString("*История просмотров:*\n")
+	month := ""
+	for _, e := range history {
+		if m := e.monthTitle(); m != month {
+			month = m
+			sb.WriteString(fmt.Sprintf("\n*%s*\n", month))
+		}
+
+		date := "··.··"
+		if e.WatchedAt.Valid {
+			date = e.WatchedAt.Time.Format("02.01")
+		}
+		sb.WriteString(fmt.Sprintf("%s %s /%s%d", date, utils.EscapeMarkdown(e.Title), e.ContentType.Sign(), e.ContentID))
+		if e.ViewNumber > 1 {
+			sb.WriteString(fmt.Sprintf(" 🔁 %d-й раз", e.ViewNumber))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
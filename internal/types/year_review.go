@@ -0,0 +1,49 @@
+package types
+
+import (
+	"cmp"
+	"slices"
+)
+
+// YearReview is the summary of the titles viewed by the user in the year.
+type YearReview struct {
+	Year     int
+	Views    int
+	Minutes  int
+	TopGenre string
+	// Top are the top-rated viewed titles for the poster collage.
+	Top Content
+	// Ratings is the histogram of the TMDb ratings of the viewed titles, Ratings[i] counts the ratings in [i, i+1).
+	Ratings [10]int
+}
+
+// NewYearReview builds the review from the titles viewed in the year and their details.
+func NewYearReview(year int, items []WatchedItem, content Content, topLimit int) YearReview {
+	stats := UserStats{Items: items}
+	stats.Collect(content)
+
+	review := YearReview{Year: year, Minutes: stats.Minutes}
+	for _, item := range items {
+		review.Views += item.Views
+	}
+	if len(stats.Genres) > 0 {
+		review.TopGenre = stats.Genres[0].Name
+	}
+
+	for _, c := range content {
+		if c.VoteCount == 0 {
+			continue
+		}
+		review.Ratings[min(int(c.VoteAverage), len(review.Ratings)-1)]++
+	}
+
+	review.Top = slices.Clone(content)
+	slices.SortStableFunc(review.Top, func(a, b ContentItem) int {
+		return cmp.Compare(b.VoteAverage, a.VoteAverage)
+	})
+	if len(review.Top) > topLimit {
+		review.Top = review.Top[:topLimit]
+	}
+
+	return review
+}
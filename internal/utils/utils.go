@@ -43,6 +43,43 @@ func EscapeString(s string) string {
 	return s
 }
 
+// EscapeMarkdown escapes the user text for the legacy Markdown parse mode.
+func EscapeMarkdown(s string) string {
+	for _, c := range []string{"_", "*", "`", "["} {
+		s = strings.ReplaceAll(s, c, "\\"+c)
+	}
+	return s
+}
+
+// Truncate cuts the string to n runes, the cut string ends with an ellipsis.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+var barBlocks = []rune("▏▎▍▌▋▊▉█")
+
+// Bar draws a horizontal bar of the value relative to max, width is the bar length for max in characters.
+func Bar(value, max float64, width int) string {
+	if max <= 0 || value <= 0 {
+		return ""
+	}
+
+	eighths := int(value / max * float64(width*8))
+	if eighths == 0 {
+		eighths = 1
+	}
+
+	bar := strings.Repeat("█", eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		bar += string(barBlocks[rest-1])
+	}
+	return bar
+}
+
 func ParseCommand(s string) (string, []string, error) {
 	if s[0] != '/' {
 		return "", nil, fmt.Errorf("command %s should be started with '/': %s", s, s)
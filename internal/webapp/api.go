@@ -0,0 +1,287 @@
+package webapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"whattowatch/internal/storage/postgresql"
+	"whattowatch/internal/types"
+)
+
+const (
+	favoritesCollection = "favorites"
+	viewedCollection    = "viewed"
+	// listCollectionPrefix is a prefix of the user list collection id, e.g. "list_12".
+	listCollectionPrefix = "list_"
+
+	searchQueryMaxLength = 200
+)
+
+var errUnknownCollection = errors.New("unknown collection")
+
+type item struct {
+	// Ref is the content type sign and id, e.g. "f123".
+	Ref        string  `json:"ref"`
+	Type       string  `json:"type"`
+	Title      string  `json:"title"`
+	Year       int     `json:"year,omitempty"`
+	Rating     float32 `json:"rating"`
+	Popularity float32 `json:"popularity"`
+	Poster     string  `json:"poster,omitempty"`
+}
+
+type collection struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// collectionStore is the storage of one user collection.
+type collectionStore struct {
+	ids    func(ctx context.Context, contentType types.ContentType) ([]int64, error)
+	add    func(ctx context.Context, item types.ContentItem) error
+	remove func(ctx context.Context, item types.ContentItem) error
+}
+
+func (s *Server) collectionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromContext(r.Context())
+
+	lists, err := s.storer.GetLists(r.Context(), userID)
+	if err != nil {
+		s.internalError(w, "collectionsHandler", err)
+		return
+	}
+
+	collections := []collection{
+		{ID: favoritesCollection, Title: "Избранное"},
+		{ID: viewedCollection, Title: "Просмотренное"},
+	}
+	for _, list := range lists {
+		collections = append(collections, collection{ID: fmt.Sprintf("%s%d", listCollectionPrefix, list.ID), Title: list.Name})
+	}
+	writeJSON(w, http.StatusOK, collections)
+}
+
+func (s *Server) itemsHandler(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.collectionStore(w, r)
+	if !ok {
+		return
+	}
+
+	items := make([]item, 0)
+	for _, contentType := range []types.ContentType{types.Movie, types.TV} {
+		ids, err := store.ids(r.Context(), contentType)
+		if err != nil {
+			s.internalError(w, "itemsHandler", err)
+			return
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		content, err := s.api.GetContent(r.Context(), contentType, ids)
+		if err != nil {
+			s.internalError(w, "itemsHandler", err)
+			return
+		}
+		items = append(items, newItems(content)...)
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) addItemHandler(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.collectionStore(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	contentItem, err := parseRef(body.Ref)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = store.add(r.Context(), contentItem)
+	switch {
+	case errors.Is(err, postgresql.ErrContentNotFound):
+		writeError(w, http.StatusNotFound, "title is not in the catalog")
+		return
+	case err != nil:
+		s.internalError(w, "addItemHandler", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeItemHandler(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.collectionStore(w, r)
+	if !ok {
+		return
+	}
+
+	contentItem, err := parseRef(r.PathValue("ref"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err = store.remove(r.Context(), contentItem); err != nil {
+		s.internalError(w, "removeItemHandler", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// searchHandler searches the titles to add to the collections.
+func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len([]rune(query)) > searchQueryMaxLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("query must be from 1 to %d characters", searchQueryMaxLength))
+		return
+	}
+
+	content, err := s.api.SearchByTitles(r.Context(), []string{query})
+	if err != nil {
+		s.internalError(w, "searchHandler", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newItems(content))
+}
+
+// collectionStore resolves the collection of the request path, it writes the error response if not found.
+func (s *Server) collectionStore(w http.ResponseWriter, r *http.Request) (collectionStore, bool) {
+	store, err := s.getCollectionStore(r.Context(), userIDFromContext(r.Context()), r.PathValue("id"))
+	switch {
+	case errors.Is(err, errUnknownCollection):
+		writeError(w, http.StatusNotFound, err.Error())
+		return collectionStore{}, false
+	case err != nil:
+		s.internalError(w, "collectionStore", err)
+		return collectionStore{}, false
+	}
+	return store, true
+}
+
+func (s *Server) getCollectionStore(ctx context.Context, userID int64, id string) (collectionStore, error) {
+	switch id {
+	case favoritesCollection:
+		return collectionStore{
+			ids: func(ctx context.Context, contentType types.ContentType) ([]int64, error) {
+				return s.storer.GetFavoriteContentIDs(ctx, userID, contentType)
+			},
+			add: func(ctx context.Context, item types.ContentItem) error {
+				cs, err := s.storer.GetContentStatus(ctx, userID, item)
+				if err != nil || cs.IsFavorite {
+					return err
+				}
+				return s.storer.AddContentItemToFavorite(ctx, userID, item)
+			},
+			remove: func(ctx context.Context, item types.ContentItem) error {
+				return s.storer.RemoveContentItemFromFavorite(ctx, userID, item)
+			},
+		}, nil
+	case viewedCollection:
+		return collectionStore{
+			ids: func(ctx context.Context, contentType types.ContentType) ([]int64, error) {
+				return s.storer.GetViewedContentIDs(ctx, userID, contentType)
+			},
+			add: func(ctx context.Context, item types.ContentItem) error {
+				cs, err := s.storer.GetContentStatus(ctx, userID, item)
+				if err != nil || cs.IsViewed {
+					return err
+				}
+				return s.storer.AddContentItemToViewed(ctx, userID, item)
+			},
+			remove: func(ctx context.Context, item types.ContentItem) error {
+				return s.storer.RemoveContentItemFromViewed(ctx, userID, item)
+			},
+		}, nil
+	}
+
+	listID, err := strconv.ParseInt(strings.TrimPrefix(id, listCollectionPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(id, listCollectionPrefix) {
+		return collectionStore{}, errUnknownCollection
+	}
+	// The list is looked up among the user lists as the storage errors are not typed.
+	lists, err := s.storer.GetLists(ctx, userID)
+	if err != nil {
+		return collectionStore{}, err
+	}
+	if !slices.ContainsFunc(lists, func(l types.UserList) bool { return l.ID == listID }) {
+		return collectionStore{}, errUnknownCollection
+	}
+
+	return collectionStore{
+		ids: func(ctx context.Context, contentType types.ContentType) ([]int64, error) {
+			return s.storer.GetListContentIDs(ctx, listID, contentType)
+		},
+		add: func(ctx context.Context, item types.ContentItem) error {
+			return s.storer.AddListItem(ctx, userID, listID, item)
+		},
+		remove: func(ctx context.Context, item types.ContentItem) error {
+			return s.storer.RemoveListItem(ctx, userID, listID, item)
+		},
+	}, nil
+}
+
+func newItems(content types.Content) []item {
+	items := make([]item, 0, len(content))
+	for _, c := range content {
+		i := item{
+			Ref:        fmt.Sprintf("%s%d", c.ContentType.Sign(), c.ID),
+			Type:       strings.ToLower(c.ContentType.String()),
+			Title:      c.Title,
+			Rating:     c.VoteAverage,
+			Popularity: c.Popularity,
+			Poster:     c.PosterPath,
+		}
+		if !c.ReleaseDate.IsZero() {
+			i.Year = c.ReleaseDate.Year()
+		}
+		items = append(items, i)
+	}
+	return items
+}
+
+// parseRef parses the content reference like "f123" to the item with the type and id only,
+// the storage needs nothing more.
+func parseRef(ref string) (types.ContentItem, error) {
+	if len(ref) < 2 {
+		return types.ContentItem{}, fmt.Errorf("invalid content ref: %q", ref)
+	}
+	contentType, err := types.ParseContentTypeSign(ref[:1])
+	if err != nil {
+		return types.ContentItem{}, err
+	}
+	id, err := strconv.ParseInt(ref[1:], 10, 64)
+	if err != nil || id <= 0 {
+		return types.ContentItem{}, fmt.Errorf("invalid content ref: %q", ref)
+	}
+	return types.ContentItem{ID: id, ContentType: contentType}, nil
+}
+
+func (s *Server) internalError(w http.ResponseWriter, fn string, err error) {
+	s.log.Error("request failed", "fn", fn, "error", err.Error())
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,92 @@
+package webapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+var (
+	ErrNoHash      = errors.New("init data has no hash")
+	ErrInvalidHash = errors.New("init data hash is invalid")
+	ErrExpired     = errors.New("init data is expired")
+	ErrNoUser      = errors.New("init data has no user")
+)
+
+// InitData is the validated data the Mini App is launched with.
+type InitData struct {
+	User     models.User
+	AuthDate time.Time
+}
+
+// ValidateInitData checks the initData signature made with the bot token and its age,
+// see https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func ValidateInitData(raw string, botToken string, maxAge time.Duration, now time.Time) (InitData, error) {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return InitData{}, fmt.Errorf("failed to parse init data: %s", err.Error())
+	}
+
+	hash := values.Get("hash")
+	if hash == "" {
+		return InitData{}, ErrNoHash
+	}
+	if !hmac.Equal([]byte(hash), []byte(signInitData(values, botToken))) {
+		return InitData{}, ErrInvalidHash
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return InitData{}, fmt.Errorf("failed to parse auth date: %s", err.Error())
+	}
+	data := InitData{AuthDate: time.Unix(authDate, 0)}
+	if maxAge > 0 && now.Sub(data.AuthDate) > maxAge {
+		return InitData{}, ErrExpired
+	}
+
+	if values.Get("user") == "" {
+		return InitData{}, ErrNoUser
+	}
+	if err = json.Unmarshal([]byte(values.Get("user")), &data.User); err != nil {
+		return InitData{}, fmt.Errorf("failed to parse user: %s", err.Error())
+	}
+	if data.User.ID == 0 {
+		return InitData{}, ErrNoUser
+	}
+
+	return data, nil
+}
+
+// signInitData returns the hex HMAC-SHA256 of the "key=value" lines of all the fields but the hash sorted by the key,
+// the key is HMAC-SHA256 of the bot token with the "WebAppData" key.
+func signInitData(values url.Values, botToken string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+values.Get(key))
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,132 @@
+package webapp
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+	"whattowatch/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBotToken = "123456:test-token"
+
+// signed returns the init data signed with the token like Telegram does.
+func signed(token string, authDate time.Time, user string) string {
+	values := url.Values{}
+	values.Set("query_id", "AAHdF6IQAAAAAN0XohDhrOrc")
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	if user != "" {
+		values.Set("user", user)
+	}
+	values.Set("hash", signInitData(values, token))
+	return values.Encode()
+}
+
+func Test_ValidateInitData(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	user := `{"id":42,"first_name":"Иван","username":"ivan","language_code":"ru"}`
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantID  int64
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			raw:    signed(testBotToken, now.Add(-time.Hour), user),
+			wantID: 42,
+		},
+		{
+			name:    "other bot token",
+			raw:     signed("654321:other-token", now.Add(-time.Hour), user),
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "tampered user",
+			raw:     signed(testBotToken, now.Add(-time.Hour), user) + "&start_param=x",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "expired",
+			raw:     signed(testBotToken, now.Add(-48*time.Hour), user),
+			wantErr: ErrExpired,
+		},
+		{
+			name:    "no hash",
+			raw:     "auth_date=1714564800&user=%7B%22id%22%3A42%7D",
+			wantErr: ErrNoHash,
+		},
+		{
+			name:    "no user",
+			raw:     signed(testBotToken, now.Add(-time.Hour), ""),
+			wantErr: ErrNoUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ValidateInitData(tt.raw, testBotToken, 24*time.Hour, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, data.User.ID)
+			assert.Equal(t, "ivan", data.User.Username)
+		})
+	}
+}
+
+func Test_parseRef(t *testing.T) {
+	item, err := parseRef("t1399")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1399), item.ID)
+
+	for _, ref := range []string{"", "f", "x12", "f-1", "f1a"} {
+		_, err = parseRef(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func Test_auth(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &config.Config{Tokens: config.Tokens{TGBot: testBotToken}}
+	s := &Server{cfg: cfg, log: slog.New(slog.NewTextHandler(io.Discard, nil)), now: func() time.Time { return now }}
+
+	var gotUserID int64
+	handler := s.auth(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = userIDFromContext(r.Context())
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantUserID int64
+	}{
+		{"valid", "tma " + signed(testBotToken, now, `{"id":42}`), http.StatusOK, 42},
+		{"invalid", "tma " + signed("654321:other-token", now, `{"id":42}`), http.StatusUnauthorized, 0},
+		{"missing", "", http.StatusUnauthorized, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID = 0
+			r := httptest.NewRequest(http.MethodGet, "/api/collections", nil)
+			r.Header.Set("Authorization", tt.header)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantUserID, gotUserID)
+		})
+	}
+}
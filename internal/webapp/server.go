@@ -0,0 +1,116 @@
+// Package webapp is the Telegram Mini App for browsing and managing the user collections:
+// an HTTP server with the embedded static frontend and the JSON API.
+package webapp
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"whattowatch/internal/botkit"
+	"whattowatch/internal/config"
+)
+
+const (
+	// initDataMaxAge is how long the Mini App launch is valid.
+	initDataMaxAge = 24 * time.Hour
+
+	shutdownTimeout = 5 * time.Second
+)
+
+//go:embed static
+var static embed.FS
+
+type Server struct {
+	storer botkit.Storer
+	api    botkit.DataProvider
+
+	log *slog.Logger
+	cfg *config.Config
+
+	now func() time.Time
+}
+
+func New(cfg *config.Config, log *slog.Logger, storer botkit.Storer, api botkit.DataProvider) *Server {
+	return &Server{
+		storer: storer,
+		api:    api,
+
+		log: log.With("pkg", "webapp"),
+		cfg: cfg,
+
+		now: time.Now,
+	}
+}
+
+// Start serves the Mini App until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	log := s.log.With("fn", "Start")
+
+	srv := &http.Server{
+		Addr:              s.cfg.WebApp.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to shutdown server", "error", err.Error())
+		}
+	}()
+
+	log.Info("starting web app", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	files, _ := fs.Sub(static, "static")
+	mux.Handle("GET /", http.FileServer(http.FS(files)))
+
+	mux.Handle("GET /api/collections", s.auth(s.collectionsHandler))
+	mux.Handle("GET /api/collections/{id}/items", s.auth(s.itemsHandler))
+	mux.Handle("POST /api/collections/{id}/items", s.auth(s.addItemHandler))
+	mux.Handle("DELETE /api/collections/{id}/items/{ref}", s.auth(s.removeItemHandler))
+	mux.Handle("GET /api/search", s.auth(s.searchHandler))
+
+	return mux
+}
+
+type userIDKey struct{}
+
+// auth validates the "Authorization: tma <initData>" header and puts the user id into the request context.
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "tma ")
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "authorization required")
+			return
+		}
+
+		data, err := ValidateInitData(raw, s.cfg.Tokens.TGBot, initDataMaxAge, s.now())
+		if err != nil {
+			s.log.Debug("invalid init data", "fn", "auth", "error", err.Error())
+			writeError(w, http.StatusUnauthorized, "invalid init data")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userIDKey{}, data.User.ID)))
+	})
+}
+
+func userIDFromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(userIDKey{}).(int64)
+	return id
+}
@@ -0,0 +1,126 @@
+"use strict";
+
+const tg = window.Telegram.WebApp;
+tg.ready();
+tg.expand();
+
+const state = {
+	collection: "favorites",
+	items: [],
+};
+
+const $ = (id) => document.getElementById(id);
+
+async function api(method, path, body) {
+	const resp = await fetch("api/" + path, {
+		method,
+		headers: {
+			"Authorization": "tma " + tg.initData,
+			"Content-Type": "application/json",
+		},
+		body: body === undefined ? undefined : JSON.stringify(body),
+	});
+	if (!resp.ok) {
+		const data = await resp.json().catch(() => ({}));
+		throw new Error(data.error || resp.statusText);
+	}
+	return resp.status === 204 ? null : resp.json();
+}
+
+function showStatus(text) {
+	$("status").textContent = text;
+}
+
+function card(item, label, onClick) {
+	const node = $("card").content.firstElementChild.cloneNode(true);
+	node.querySelector("img").src = item.poster || "";
+	node.querySelector("img").alt = item.title;
+	node.querySelector(".title").textContent = item.title;
+	node.querySelector(".meta").textContent = [item.year, item.rating ? "★ " + item.rating.toFixed(1) : ""].filter(Boolean).join(" · ");
+
+	const button = node.querySelector(".action");
+	button.textContent = label;
+	button.addEventListener("click", async () => {
+		button.disabled = true;
+		try {
+			await onClick();
+		} catch (e) {
+			tg.showAlert("Ошибка: " + e.message);
+			button.disabled = false;
+		}
+	});
+	return node;
+}
+
+const comparators = {
+	title: (a, b) => a.title.localeCompare(b.title),
+	year: (a, b) => (b.year || 0) - (a.year || 0),
+	rating: (a, b) => b.rating - a.rating,
+	popularity: (a, b) => b.popularity - a.popularity,
+};
+
+function render() {
+	const filter = $("filter").value.trim().toLowerCase();
+	let items = state.items.filter((item) => item.title.toLowerCase().includes(filter));
+	const compare = comparators[$("sort").value];
+	if (compare) {
+		items = [...items].sort(compare);
+	}
+
+	$("grid").replaceChildren(...items.map((item) => card(item, "✕", async () => {
+		await api("DELETE", `collections/${state.collection}/items/${item.ref}`);
+		state.items = state.items.filter((i) => i.ref !== item.ref);
+		render();
+	})));
+	showStatus(state.items.length === 0 ? "Здесь пока пусто" : `Всего: ${state.items.length}`);
+}
+
+async function loadItems() {
+	showStatus("Загрузка…");
+	$("grid").replaceChildren();
+	try {
+		state.items = await api("GET", `collections/${state.collection}/items`);
+		render();
+	} catch (e) {
+		showStatus("Не удалось загрузить: " + e.message);
+	}
+}
+
+async function loadCollections() {
+	const collections = await api("GET", "collections");
+	$("collection").replaceChildren(...collections.map((c) => new Option(c.title, c.id)));
+	$("collection").value = state.collection;
+}
+
+async function search(event) {
+	event.preventDefault();
+	const query = $("search").value.trim();
+	if (!query) {
+		return;
+	}
+
+	try {
+		const results = await api("GET", "search?q=" + encodeURIComponent(query));
+		$("results").replaceChildren(...results.map((item) => card(item, "+", async () => {
+			await api("POST", `collections/${state.collection}/items`, { ref: item.ref });
+			if (!state.items.some((i) => i.ref === item.ref)) {
+				state.items.push(item);
+			}
+			render();
+		})));
+	} catch (e) {
+		tg.showAlert("Ошибка поиска: " + e.message);
+	}
+}
+
+$("collection").addEventListener("change", () => {
+	state.collection = $("collection").value;
+	loadItems();
+});
+$("filter").addEventListener("input", render);
+$("sort").addEventListener("change", render);
+$("search-form").addEventListener("submit", search);
+
+loadCollections()
+	.then(loadItems)
+	.catch((e) => showStatus("Не удалось загрузить: " + e.message));
@@ -0,0 +1,48 @@
+package yearreview
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+)
+
+// PosterFetcher loads the poster image by its URL.
+type PosterFetcher interface {
+	FetchPoster(ctx context.Context, url string) (image.Image, error)
+}
+
+// HTTPPosterFetcher downloads the posters from the image CDN.
+type HTTPPosterFetcher struct {
+	client *http.Client
+}
+
+func NewHTTPPosterFetcher(timeout time.Duration) *HTTPPosterFetcher {
+	return &HTTPPosterFetcher{client: &http.Client{Timeout: timeout}}
+}
+
+func (f *HTTPPosterFetcher) FetchPoster(ctx context.Context, url string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch poster %s: %s", url, resp.Status)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode poster %s: %s", url, err.Error())
+	}
+	return img, nil
+}
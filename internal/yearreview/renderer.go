@@ -0,0 +1,185 @@
+package yearreview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"whattowatch/internal/types"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	width  = 1080
+	height = 1350
+	margin = 60
+
+	collageColumns = 4
+	collageRows    = 2
+	collageGap     = 30
+	collageTop     = 170
+
+	// PostersLimit is the number of posters in the collage.
+	PostersLimit = collageColumns * collageRows
+)
+
+var (
+	backgroundColor  = color.RGBA{R: 0x14, G: 0x18, B: 0x24, A: 0xff}
+	placeholderColor = color.RGBA{R: 0x2c, G: 0x33, B: 0x45, A: 0xff}
+	accentColor      = color.RGBA{R: 0xf5, G: 0xc5, B: 0x18, A: 0xff}
+	textColor        = color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+	mutedColor       = color.RGBA{R: 0x9a, G: 0xa3, B: 0xb5, A: 0xff}
+)
+
+// Renderer draws the "Year in review" PNG card.
+type Renderer struct {
+	fetcher PosterFetcher
+
+	titleFace font.Face
+	textFace  font.Face
+	smallFace font.Face
+}
+
+func New(fetcher PosterFetcher) (*Renderer, error) {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bold font: %s", err.Error())
+	}
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse regular font: %s", err.Error())
+	}
+
+	r := &Renderer{fetcher: fetcher}
+	if r.titleFace, err = opentype.NewFace(bold, &opentype.FaceOptions{Size: 56, DPI: 72}); err != nil {
+		return nil, err
+	}
+	if r.textFace, err = opentype.NewFace(regular, &opentype.FaceOptions{Size: 40, DPI: 72}); err != nil {
+		return nil, err
+	}
+	if r.smallFace, err = opentype.NewFace(regular, &opentype.FaceOptions{Size: 26, DPI: 72}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Render draws the review card and encodes it as PNG. The posters failed to load are replaced with placeholders.
+func (r *Renderer) Render(ctx context.Context, review types.YearReview) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	r.drawText(img, r.titleFace, accentColor, margin, 120, fmt.Sprintf("Мой %d год в кино", review.Year))
+
+	r.drawCollage(ctx, img, review.Top)
+
+	y := collageTop + collageRows*(posterHeight()+collageGap) + 40
+	r.drawText(img, r.textFace, textColor, margin, y,
+		fmt.Sprintf("%d %s · %d %s", review.Views, plural(review.Views, "просмотр", "просмотра", "просмотров"),
+			review.Minutes/60, plural(review.Minutes/60, "час", "часа", "часов")))
+	if review.TopGenre != "" {
+		r.drawText(img, r.textFace, textColor, margin, y+56, "Любимый жанр: "+review.TopGenre)
+	}
+
+	r.drawHistogram(img, review.Ratings, y+100)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+func posterWidth() int {
+	return (width - 2*margin - (collageColumns-1)*collageGap) / collageColumns
+}
+
+func posterHeight() int {
+	return posterWidth() * 3 / 2
+}
+
+func (r *Renderer) drawCollage(ctx context.Context, img *image.RGBA, content types.Content) {
+	if len(content) > PostersLimit {
+		content = content[:PostersLimit]
+	}
+
+	posters := make([]image.Image, len(content))
+	wg := sync.WaitGroup{}
+	for i, c := range content {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			poster, err := r.fetcher.FetchPoster(ctx, url)
+			if err == nil {
+				posters[i] = poster
+			}
+		}(i, c.PosterPath)
+	}
+	wg.Wait()
+
+	for i := 0; i < PostersLimit; i++ {
+		x := margin + (i%collageColumns)*(posterWidth()+collageGap)
+		y := collageTop + (i/collageColumns)*(posterHeight()+collageGap)
+		rect := image.Rect(x, y, x+posterWidth(), y+posterHeight())
+
+		if i < len(posters) && posters[i] != nil {
+			draw.CatmullRom.Scale(img, rect, posters[i], posters[i].Bounds(), draw.Src, nil)
+			continue
+		}
+		draw.Draw(img, rect, image.NewUniform(placeholderColor), image.Point{}, draw.Src)
+	}
+}
+
+// drawHistogram draws the bars of the rating bins starting at the top y, each bin is labeled by its lower bound.
+func (r *Renderer) drawHistogram(img *image.RGBA, ratings [10]int, top int) {
+	r.drawText(img, r.smallFace, mutedColor, margin, top+26, "Рейтинги TMDb просмотренного")
+
+	maxCount := 0
+	for _, c := range ratings {
+		maxCount = max(maxCount, c)
+	}
+
+	barsTop, barsBottom := top+50, height-margin-30
+	barWidth := (width - 2*margin) / len(ratings)
+	for i, c := range ratings {
+		x := margin + i*barWidth
+		if maxCount > 0 && c > 0 {
+			barHeight := (barsBottom - barsTop) * c / maxCount
+			draw.Draw(img, image.Rect(x+6, barsBottom-barHeight, x+barWidth-6, barsBottom), image.NewUniform(accentColor), image.Point{}, draw.Src)
+		}
+		r.drawText(img, r.smallFace, mutedColor, x+barWidth/2-8, barsBottom+28, fmt.Sprint(i))
+	}
+}
+
+func (r *Renderer) drawText(img *image.RGBA, face font.Face, c color.Color, x, y int, text string) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// plural chooses the russian word form for the number.
+func plural(n int, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	}
+	return many
+}
@@ -0,0 +1,117 @@
+package yearreview
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"whattowatch/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureFetcher loads the posters from the testdata directory by the file name.
+type fixtureFetcher struct{}
+
+func (fixtureFetcher) FetchPoster(ctx context.Context, url string) (image.Image, error) {
+	f, err := os.Open(filepath.Join("testdata", url))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func Test_Render(t *testing.T) {
+	r, err := New(fixtureFetcher{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		review types.YearReview
+	}{
+		{
+			name:   "empty",
+			review: types.YearReview{Year: 2024},
+		},
+		{
+			name: "posters",
+			review: types.YearReview{
+				Year:     2024,
+				Views:    42,
+				Minutes:  5000,
+				TopGenre: "Драма",
+				Top: types.Content{
+					{ID: 1, PosterPath: "poster.png"},
+					{ID: 2, PosterPath: "poster.jpg"},
+					{ID: 3, PosterPath: "missing.png"},
+				},
+				Ratings: [10]int{0, 0, 0, 1, 2, 5, 10, 15, 7, 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := r.Render(context.Background(), tt.review)
+			require.NoError(t, err)
+
+			img, format, err := image.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, "png", format)
+			assert.Equal(t, image.Rect(0, 0, width, height), img.Bounds())
+		})
+	}
+}
+
+func Test_RenderPoster(t *testing.T) {
+	r, err := New(fixtureFetcher{})
+	require.NoError(t, err)
+
+	data, err := r.Render(context.Background(), types.YearReview{
+		Year: 2024,
+		Top:  types.Content{{ID: 1, PosterPath: "poster.png"}, {ID: 2, PosterPath: "missing.png"}},
+	})
+	require.NoError(t, err)
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// The fixture poster is drawn in the first cell, the missing one is replaced with the placeholder.
+	center := func(i int) (int, int) {
+		return margin + i*(posterWidth()+collageGap) + posterWidth()/2, collageTop + posterHeight()/2
+	}
+	x, y := center(0)
+	assert.NotEqual(t, rgba(placeholderColor), rgba(img.At(x, y)))
+	x, y = center(1)
+	assert.Equal(t, rgba(placeholderColor), rgba(img.At(x, y)))
+}
+
+func rgba(c interface{ RGBA() (r, g, b, a uint32) }) [4]uint32 {
+	r, g, b, a := c.RGBA()
+	return [4]uint32{r, g, b, a}
+}
+
+func Test_plural(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "час"},
+		{2, "часа"},
+		{5, "часов"},
+		{11, "часов"},
+		{21, "час"},
+		{112, "часов"},
+		{0, "часов"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, plural(tt.n, "час", "часа", "часов"), tt.n)
+	}
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists public.users_api_tokens (
+	id serial primary key,
+	user_id bigint not null unique,
+	token_hash text not null unique,
+	created_at timestamptz not null default now(),
+	last_used_at timestamptz,
+	constraint public_fk_users_api_tokens_user_id foreign key (user_id) references public.users(id) on delete cascade
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.users_api_tokens;
+-- +goose StatementEnd
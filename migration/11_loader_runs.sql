@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists public.loader_runs (
+	id serial primary key,
+	started_at timestamptz not null default now(),
+	finished_at timestamptz,
+	succeeded boolean not null default false,
+	error text
+);
+
+create index if not exists loader_runs_succeeded_idx on public.loader_runs (succeeded, finished_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.loader_runs;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists public.events (
+	id bigserial primary key,
+	user_id bigint not null,
+	type text not null,
+	name text not null default '',
+	content_id bigint,
+	content_type_id int,
+	payload jsonb,
+	created_at timestamptz not null default now()
+);
+
+create index if not exists events_type_created_at_idx on public.events (type, created_at);
+create index if not exists events_user_id_created_at_idx on public.events (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.events;
+-- +goose StatementEnd
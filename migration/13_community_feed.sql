@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The existing favorites get the migration time, so they count as the recent ones for the first month.
+alter table public.users_favorites add column if not exists created_at timestamptz not null default now();
+
+-- community_feed ranks the titles by the favorites and the views of the bot users for the last 30 days,
+-- the last 7 days count in full and the rest in half. It is refreshed by the bot periodically.
+create materialized view if not exists public.community_feed as
+with actions as (
+	select f.user_id, f.content_id, f.content_type_id, 2.0 as weight, f.created_at
+	from public.users_favorites f
+	where f.created_at >= now() - interval '30 days'
+	union all
+	select w.user_id, w.content_id, w.content_type_id, 1.0 as weight, w.created_at
+	from public.users_watch_log w
+	where w.created_at >= now() - interval '30 days'
+)
+select
+	a.content_id,
+	a.content_type_id,
+	sum(a.weight * case when a.created_at >= now() - interval '7 days' then 1.0 else 0.5 end) as score,
+	count(distinct a.user_id) as users
+from actions a
+join public.users u on u.id = a.user_id and u.deleted_at is null
+group by a.content_id, a.content_type_id;
+
+-- The unique index allows the concurrent refresh not blocking the readers.
+create unique index if not exists community_feed_content_idx on public.community_feed (content_id, content_type_id);
+create index if not exists community_feed_score_idx on public.community_feed (content_type_id, score desc);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop materialized view if exists public.community_feed;
+alter table public.users_favorites drop column if exists created_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- content_neighbors are the top titles co-occurring with the title in the favorites and the views of the same users.
+create table if not exists public.content_neighbors (
+	content_id int not null,
+	content_type_id int not null,
+	neighbor_id int not null,
+	neighbor_type_id int not null,
+	score double precision not null,
+	users int not null,
+	primary key (content_id, content_type_id, neighbor_id, neighbor_type_id),
+	constraint fk_content_neighbors_content foreign key (content_id, content_type_id) references public.content(id, content_type_id) on delete cascade,
+	constraint fk_content_neighbors_neighbor foreign key (neighbor_id, neighbor_type_id) references public.content(id, content_type_id) on delete cascade
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.content_neighbors;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+create extension if not exists pg_trgm;
+
+-- The title is the original title of the TMDb export, the index serves the similarity search by it.
+create index if not exists content_title_trgm_idx on public.content using gin (title gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists public.content_title_trgm_idx;
+-- +goose StatementEnd
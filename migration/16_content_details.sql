@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The title stays the original title of the TMDb export, the details are in the language of details_language.
+alter table public.content
+	add column if not exists localized_title text,
+	add column if not exists overview text,
+	add column if not exists poster_path text,
+	add column if not exists backdrop_path text,
+	add column if not exists release_date date,
+	add column if not exists vote_average real,
+	add column if not exists vote_count int,
+	add column if not exists runtime int,
+	add column if not exists countries text[],
+	add column if not exists adult boolean,
+	add column if not exists collection_id int,
+	add column if not exists details_language text,
+	add column if not exists details_updated_at timestamptz;
+
+create table if not exists public.content_genres (
+	content_id int not null,
+	content_type_id int not null,
+	genre_id int not null,
+	primary key (content_id, content_type_id, genre_id),
+	constraint fk_content_genres_content foreign key (content_id, content_type_id) references public.content(id, content_type_id) on delete cascade
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.content_genres;
+
+alter table public.content
+	drop column if exists localized_title,
+	drop column if exists overview,
+	drop column if exists poster_path,
+	drop column if exists backdrop_path,
+	drop column if exists release_date,
+	drop column if exists vote_average,
+	drop column if exists vote_count,
+	drop column if exists runtime,
+	drop column if exists countries,
+	drop column if exists adult,
+	drop column if exists collection_id,
+	drop column if exists details_language,
+	drop column if exists details_updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The watermark is the time the run loaded the changes up to, the next incremental run loads the changes since it.
+alter table public.loader_runs
+	add column if not exists mode text not null default 'full',
+	add column if not exists watermark timestamptz;
+
+create index if not exists loader_runs_mode_idx on public.loader_runs (succeeded, mode, watermark);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists public.loader_runs_mode_idx;
+
+alter table public.loader_runs
+	drop column if exists mode,
+	drop column if exists watermark;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The full export sets last_seen_at of the titles it contains, the titles missing from it are marked removed_at.
+alter table public.content
+	add column if not exists last_seen_at timestamptz,
+	add column if not exists removed_at timestamptz;
+
+create index if not exists content_removed_at_idx on public.content (removed_at) where removed_at is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists public.content_removed_at_idx;
+
+alter table public.content
+	drop column if exists last_seen_at,
+	drop column if exists removed_at;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The loader copies the full export here and merges it into the content by a single statement.
+-- The table is unlogged as its rows are temporary and the copy is the hot path.
+create unlogged table if not exists public.content_staging (
+	id int not null,
+	content_type_id int not null,
+	title text not null,
+	popularity numeric
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.content_staging;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The conversation state of the bot users survives the restarts, expires_at is the deadline of the state with the timeout.
+create table if not exists public.fsm_sessions (
+	user_id bigint primary key,
+	data jsonb not null,
+	expires_at timestamptz,
+	updated_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.fsm_sessions;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The localized title is filled with the details, the index serves the similarity search by the translated titles.
+create index if not exists content_localized_title_trgm_idx on public.content using gin (localized_title gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists public.content_localized_title_trgm_idx;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The titles added after the full export was made are missing from it but not removed, created_at tells them apart.
+-- The loaded titles are taken as created when they were last seen, the never seen ones as created now.
+alter table public.content add column if not exists created_at timestamptz;
+
+update public.content set created_at = coalesce(last_seen_at, now()) where created_at is null;
+
+alter table public.content
+	alter column created_at set default now(),
+	alter column created_at set not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table public.content drop column if exists created_at;
+-- +goose StatementEnd
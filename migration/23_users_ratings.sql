@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The personal rating of the viewed title from 1 to 10, the TMDb scale, so the two are comparable.
+create table if not exists public.users_ratings (
+	user_id bigint not null,
+	content_id int not null,
+	content_type_id int not null,
+	rating smallint not null check (rating between 1 and 10),
+	created_at timestamptz not null default now(),
+	updated_at timestamptz,
+	primary key (user_id, content_id, content_type_id),
+	constraint public_fk_users_ratings_user_id foreign key (user_id) references public.users(id) on delete cascade,
+	constraint public_fk_users_ratings_content_id foreign key (content_id, content_type_id) references public.content(id, content_type_id) on delete cascade
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.users_ratings;
+-- +goose StatementEnd
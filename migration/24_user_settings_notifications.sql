@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The bot sends no notifications, the switches of them did nothing.
+alter table public.user_settings
+	drop column if exists notify_new_releases,
+	drop column if exists notify_recommendations;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table public.user_settings
+	add column if not exists notify_new_releases boolean not null default true,
+	add column if not exists notify_recommendations boolean not null default true;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists public.user_settings (
+	user_id bigint primary key,
+	language text not null default 'ru-RU',
+	region text not null default 'RU',
+	hide_viewed boolean not null default false,
+	min_rating real not null default 0,
+	include_adult boolean not null default false,
+	card_layout text not null default 'full',
+	notify_new_releases boolean not null default true,
+	notify_recommendations boolean not null default true,
+	updated_at timestamptz,
+	constraint public_fk_user_settings_user_id foreign key (user_id) references public.users(id) on delete cascade
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.user_settings;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists public.user_lists (
+	id serial primary key,
+	user_id bigint not null,
+	name text not null,
+	share_token text unique,
+	created_at timestamptz not null default now(),
+	updated_at timestamptz,
+	unique(user_id, name),
+	constraint public_fk_user_lists_user_id foreign key (user_id) references public.users(id) on delete cascade
+);
+
+create table if not exists public.user_list_items (
+	id serial primary key,
+	list_id int not null,
+	content_id int not null,
+	content_type_id int not null,
+	created_at timestamptz not null default now(),
+	unique(list_id, content_id, content_type_id),
+	constraint public_fk_user_list_items_list_id foreign key (list_id) references public.user_lists(id) on delete cascade,
+	constraint public_fk_user_list_items_content_id foreign key (content_id, content_type_id) references public.content(id, content_type_id) on delete cascade
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists public.user_list_items;
+drop table if exists public.user_lists;
+-- +goose StatementEnd
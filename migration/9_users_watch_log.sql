@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists public.users_watch_log (
+	id serial primary key,
+	user_id bigint not null,
+	content_id int not null,
+	content_type_id int not null,
+	watched_at timestamptz default now(),
+	created_at timestamptz not null default now(),
+	constraint public_fk_users_watch_log_user_id foreign key (user_id) references public.users(id) on delete cascade,
+	constraint public_fk_users_watch_log_content_id foreign key (content_id, content_type_id) references public.content(id, content_type_id) on delete cascade
+);
+
+create index if not exists users_watch_log_user_id_idx on public.users_watch_log (user_id, watched_at);
+
+-- The viewed table has no dates, so the migrated entries have the unknown watch date.
+insert into public.users_watch_log (user_id, content_id, content_type_id, watched_at)
+select user_id, content_id, content_type_id, null
+from public.users_viewed
+order by id;
+
+drop table if exists public.users_viewed;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+create table if not exists public.users_viewed (
+	id serial primary key,
+	user_id bigint not null,
+	content_id int not null,
+	content_type_id int not null,
+	unique(user_id, content_id),
+	constraint public_fk_users_viewed_user_id foreign key (user_id) references public.users(id) on delete cascade,
+	constraint public_fk_users_viewed_content_id foreign key (content_id, content_type_id) references public.content(id, content_type_id) on delete cascade
+);
+
+insert into public.users_viewed (user_id, content_id, content_type_id)
+select user_id, content_id, min(content_type_id)
+from public.users_watch_log
+group by user_id, content_id
+order by min(id);
+
+drop table if exists public.users_watch_log;
+-- +goose StatementEnd